@@ -0,0 +1,174 @@
+// Package client is the Go client of user-service for the other GoCampus
+// services. It finds the healthy instances of user-service in Consul,
+// balances calls across them round-robin, retries idempotent calls that
+// failed because an instance was unavailable, and propagates the caller's
+// trace context.
+//
+//	users, err := client.New(client.WithConsulAddress("consul:8500"))
+//	if err != nil {
+//		return err
+//	}
+//	defer users.Close()
+//	resp, err := users.GetUserInfo(ctx, &userv1.GetUserInfoRequest{UserId: id})
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/consul"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/tracing"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultTarget resolves the passing user-service instances in Consul.
+const DefaultTarget = "consul:///user-service"
+
+// idempotentMethods are the methods that are safe to call again after a
+// failure, because they do not change anything.
+var idempotentMethods = []string{
+	"GetUserInfo",
+	"ListUserRoles",
+	"GetLockoutStatus",
+}
+
+// Client is a UserServiceClient over a load-balanced connection.
+type Client struct {
+	userv1.UserServiceClient
+	conn *grpc.ClientConn
+}
+
+// options are the settings of New.
+type options struct {
+	target        string
+	consulAddress string
+	maxAttempts   int
+	dialOptions   []grpc.DialOption
+}
+
+// Option configures New.
+type Option func(*options)
+
+// WithTarget sets the gRPC target, DefaultTarget by default. With a target
+// of another scheme, such as etcd:///user-service, pass its resolver with
+// WithDialOptions(grpc.WithResolvers(...)).
+func WithTarget(target string) Option {
+	return func(o *options) {
+		o.target = target
+	}
+}
+
+// WithConsulAddress sets the address of the Consul agent, localhost:8500
+// by default.
+func WithConsulAddress(address string) Option {
+	return func(o *options) {
+		o.consulAddress = address
+	}
+}
+
+// WithMaxAttempts sets how many times an idempotent call is attempted in
+// total, 3 by default. 1 disables retries.
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// WithDialOptions adds options to the connection, for example transport
+// credentials, which are insecure by default, or more interceptors.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// New returns a Client. The connection is established lazily, on the first
+// call.
+func New(opts ...Option) (*Client, error) {
+	o := options{
+		target:        DefaultTarget,
+		consulAddress: "localhost:8500",
+		maxAttempts:   3,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	consulConfig := api.DefaultConfig()
+	consulConfig.Address = o.consulAddress
+	consulClient, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %w", err)
+	}
+	serviceConfig, err := serviceConfig(o.maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(consul.NewResolverBuilder(consulClient)),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor()),
+	}, o.dialOptions...)
+	conn, err := grpc.NewClient(o.target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user-service client: %w", err)
+	}
+	return &Client{UserServiceClient: userv1.NewUserServiceClient(conn), conn: conn}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// serviceConfig returns the gRPC service config that balances round-robin
+// and retries the idempotent methods on UNAVAILABLE.
+func serviceConfig(maxAttempts int) (string, error) {
+	type name struct {
+		Service string `json:"service"`
+		Method  string `json:"method"`
+	}
+	type retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+	type methodConfig struct {
+		Name        []name       `json:"name"`
+		RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+	}
+
+	config := struct {
+		LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+		MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+	}{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+	}
+	// gRPC needs at least two attempts for a retry policy.
+	if maxAttempts > 1 {
+		mc := methodConfig{RetryPolicy: &retryPolicy{
+			MaxAttempts:          maxAttempts,
+			InitialBackoff:       "0.1s",
+			MaxBackoff:           "1s",
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []string{"UNAVAILABLE"},
+		}}
+		for _, method := range idempotentMethods {
+			mc.Name = append(mc.Name, name{Service: userv1.UserService_ServiceDesc.ServiceName, Method: method})
+		}
+		config.MethodConfig = []methodConfig{mc}
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode service config: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+	"github.com/hashicorp/consul/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeUserService is one user-service instance. It fails the first
+// unavailable calls with UNAVAILABLE.
+type fakeUserService struct {
+	userv1.UnimplementedUserServiceServer
+
+	mu          sync.Mutex
+	calls       int
+	unavailable int
+	traceparent string
+}
+
+func (s *fakeUserService) call(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("traceparent"); len(values) > 0 {
+		s.traceparent = values[0]
+	}
+	if s.unavailable > 0 {
+		s.unavailable--
+		return status.Error(codes.Unavailable, "draining")
+	}
+	return nil
+}
+
+func (s *fakeUserService) GetUserInfo(ctx context.Context, req *userv1.GetUserInfoRequest) (*userv1.GetUserInfoResponse, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	return &userv1.GetUserInfoResponse{}, nil
+}
+
+func (s *fakeUserService) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	return &userv1.LoginResponse{}, nil
+}
+
+func (s *fakeUserService) reset(unavailable int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls, s.unavailable = 0, unavailable
+}
+
+func (s *fakeUserService) lastTraceparent() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.traceparent
+}
+
+func (s *fakeUserService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// startInstance serves svc on a loopback port and returns its port.
+func startInstance(t *testing.T, svc *fakeUserService) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := grpc.NewServer()
+	userv1.RegisterUserServiceServer(server, svc)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// startConsul serves the passing instances of user-service at ports. Blocking
+// queries wait until the request is canceled, since nothing changes.
+func startConsul(t *testing.T, ports ...int) string {
+	t.Helper()
+	var entries []*api.ServiceEntry
+	for _, port := range ports {
+		entries = append(entries, &api.ServiceEntry{
+			Node:    &api.Node{Address: "127.0.0.1"},
+			Service: &api.AgentService{Port: port},
+		})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health/service/user-service" {
+			http.NotFound(w, r)
+			return
+		}
+		if index, _ := strconv.Atoi(r.URL.Query().Get("index")); index > 0 {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("X-Consul-Index", "1")
+		_ = json.NewEncoder(w).Encode(entries)
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestClient(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	a, b := &fakeUserService{}, &fakeUserService{}
+	consulAddress := startConsul(t, startInstance(t, a), startInstance(t, b))
+
+	users, err := New(WithConsulAddress(consulAddress))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer users.Close()
+	ctx := context.Background()
+
+	// Calls are spread over both instances once both are connected.
+	for i := 0; a.count() == 0 || b.count() == 0; i++ {
+		if i == 100 {
+			t.Fatalf("calls = %d and %d, want both instances used", a.count(), b.count())
+		}
+		if _, err := users.GetUserInfo(ctx, &userv1.GetUserInfoRequest{UserId: "u1"}); err != nil {
+			t.Fatalf("GetUserInfo() error = %v", err)
+		}
+	}
+	a.reset(0)
+	b.reset(0)
+	for i := 0; i < 4; i++ {
+		if _, err := users.GetUserInfo(ctx, &userv1.GetUserInfoRequest{UserId: "u1"}); err != nil {
+			t.Fatalf("GetUserInfo() error = %v", err)
+		}
+	}
+	if a.count() != 2 || b.count() != 2 {
+		t.Errorf("calls = %d and %d, want round-robin", a.count(), b.count())
+	}
+
+	// An idempotent call is retried on another instance.
+	a.reset(1)
+	b.reset(1)
+	if _, err := users.GetUserInfo(ctx, &userv1.GetUserInfoRequest{UserId: "u1"}); err != nil {
+		t.Errorf("GetUserInfo() with an unavailable instance error = %v, want a retry", err)
+	}
+
+	// Login is not idempotent.
+	a.reset(1)
+	b.reset(1)
+	_, err = users.Login(ctx, &userv1.LoginRequest{Email: "a@example.edu"})
+	if status.Code(err) != codes.Unavailable || a.count()+b.count() != 1 {
+		t.Errorf("Login() = %v after %d calls, want UNAVAILABLE without retry", err, a.count()+b.count())
+	}
+
+	// The trace context travels with the call.
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	a.reset(0)
+	b.reset(0)
+	if _, err := users.GetUserInfo(traced, &userv1.GetUserInfoRequest{UserId: "u1"}); err != nil {
+		t.Fatalf("GetUserInfo() error = %v", err)
+	}
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if a.lastTraceparent() != want && b.lastTraceparent() != want {
+		t.Errorf("traceparent = %q / %q, want %q", a.lastTraceparent(), b.lastTraceparent(), want)
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	config, err := serviceConfig(1)
+	if err != nil {
+		t.Fatalf("serviceConfig() error = %v", err)
+	}
+	if strings.Contains(config, "retryPolicy") {
+		t.Errorf("serviceConfig(1) = %s, want no retries", config)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/handler"
+	"github.com/Tao-Zzzz/GoCampus/user-service/observability"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/campus"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/consul"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/etcd"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/gateway"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/health"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/lifecycle"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/passhash"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/rbac"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/registry"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/tracing"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+	"github.com/Tao-Zzzz/GoCampus/user-service/repository"
+	"github.com/Tao-Zzzz/GoCampus/user-service/service"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to the configuration file")
+	checkConfig := flag.Bool("check-config", false, "validate the configuration file and the environment overrides, then exit")
+	flag.Parse()
+
+	if *checkConfig {
+		if _, err := config.LoadConfig(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "user-service: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("user-service: configuration %s is valid\n", *configPath)
+		return
+	}
+
+	if err := run(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "user-service: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run wires every component together and blocks until SIGINT/SIGTERM. The
+// components are registered with a lifecycle manager in dependency order,
+// so they start in that order and stop in reverse.
+func run(configPath string) error {
+	store, err := config.NewStore(configPath)
+	if err != nil {
+		return err
+	}
+	cfg := store.Config()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	obs, err := observability.InitObservability(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	log := obs.Logger
+	shutdownTimeout := time.Duration(cfg.Service.ShutdownTimeoutSeconds) * time.Second
+
+	lc := lifecycle.New(log)
+	lc.Add(lifecycle.Component{Name: "observability", Stop: obs.Shutdown})
+	// abort releases what has been set up so far when wiring fails.
+	abort := func(err error) error {
+		stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = lc.Stop(stopCtx)
+		return err
+	}
+
+	// Settings listed in config.RuntimeKeys are applied from config events;
+	// everything else reads the configuration once, below, and a change to
+	// it only takes effect after a restart.
+	store.Subscribe(func(e config.Event) {
+		log.Info(ctx).Strs("keys", e.Keys).Msgf("Configuration reloaded from %s", e.Source)
+		if e.Has("service.log_level") {
+			logger.SetLevel(e.New.Service.LogLevel)
+		}
+		if keys := e.RestartKeys(); len(keys) > 0 {
+			log.Warn(ctx).Strs("keys", keys).Msg("Changed settings take effect after a restart")
+		}
+	})
+
+	// etcd is the top configuration layer, so it is applied before the
+	// remaining components are wired.
+	etcdClient, err := etcd.NewEtcdClient(cfg, log)
+	if err != nil {
+		return abort(err)
+	}
+	lc.Add(lifecycle.Component{Name: "etcd", Stop: func(context.Context) error { return etcdClient.Close() }})
+	if cfg.Etcd.Enabled {
+		if err := store.SetRemote(ctx, etcdClient); err != nil {
+			return abort(err)
+		}
+		cfg = store.Config()
+	}
+	lc.Add(lifecycle.Component{
+		Name: "config watcher",
+		Start: func(ctx context.Context) error {
+			store.Watch(ctx, func(err error) {
+				log.Error(ctx).Err(err).Msg("Failed to reload configuration, keeping the current one")
+			})
+			return nil
+		},
+	})
+
+	repo, err := repository.NewPostgresRepository(ctx, cfg, log)
+	if err != nil {
+		return abort(fmt.Errorf("failed to initialize repository: %w", err))
+	}
+	lc.Add(lifecycle.Component{Name: "postgres", Stop: func(context.Context) error { return repo.Close() }})
+
+	var denylist jwt.Denylist = repo
+	if cfg.JWT.Denylist == "memory" {
+		denylist = jwt.NewMemoryDenylist()
+	}
+	if cfg.JWT.DenylistCleanupMinutes > 0 {
+		jwt.StartDenylistCleanup(ctx, denylist, time.Duration(cfg.JWT.DenylistCleanupMinutes)*time.Minute, log)
+	}
+
+	jwtOpts := []jwt.Option{jwt.WithDenylist(denylist)}
+	httpMux := http.NewServeMux()
+	if len(cfg.JWT.Keys) > 0 {
+		keys, err := jwt.NewKeySet(cfg.JWT.Keys)
+		if err != nil {
+			return abort(fmt.Errorf("failed to load JWT keys: %w", err))
+		}
+		if cfg.JWT.KeyRotationMinutes > 0 {
+			jwt.StartKeyRotation(ctx, keys, time.Duration(cfg.JWT.KeyRotationMinutes)*time.Minute, log)
+		}
+		jwtOpts = append(jwtOpts, jwt.WithKeySet(keys))
+		httpMux.Handle(jwt.JWKSPath, keys)
+		log.Info(ctx).Msgf("Signing JWTs with key %q", keys.CurrentKeyID())
+	}
+
+	jwtUtil := jwt.NewJWTUtilFromConfig(cfg, jwtOpts...)
+	publicMethods := []string{
+		userv1.UserService_RegisterUser_FullMethodName,
+		userv1.UserService_Login_FullMethodName,
+		userv1.UserService_RefreshToken_FullMethodName,
+		userv1.UserService_RequestPasswordReset_FullMethodName,
+		userv1.UserService_ConfirmPasswordReset_FullMethodName,
+		userv1.UserService_VerifyEmail_FullMethodName,
+		userv1.UserService_ResendVerificationEmail_FullMethodName,
+		userv1.UserService_VerifyMFA_FullMethodName,
+		healthpb.Health_Check_FullMethodName,
+		healthpb.Health_List_FullMethodName,
+		healthpb.Health_Watch_FullMethodName,
+	}
+	policy, err := rbac.LoadPolicy(cfg.RBAC.PolicyFile)
+	if err != nil {
+		return abort(err)
+	}
+	authorizer := rbac.NewAuthorizer(policy, repo)
+	// Authentication runs before authorization so the authorizer sees the
+	// token's role claims; the caller's trace is picked up before either, so
+	// authentication and authorization failures are traced.
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			tracing.UnaryServerInterceptor(),
+			jwtUtil.UnaryServerInterceptor(publicMethods...),
+			authorizer.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			tracing.StreamServerInterceptor(),
+			jwtUtil.StreamServerInterceptor(publicMethods...),
+			authorizer.StreamServerInterceptor(),
+		),
+	)
+	notifier, err := notify.NewNotifier(cfg, log)
+	if err != nil {
+		return abort(err)
+	}
+	directory, err := campus.NewDirectory(cfg)
+	if err != nil {
+		return abort(fmt.Errorf("invalid campus configuration: %w", err))
+	}
+	passwords, err := pwpolicy.New(cfg.PasswordPolicy)
+	if err != nil {
+		return abort(fmt.Errorf("invalid password policy: %w", err))
+	}
+	hasher, err := passhash.New(cfg.PasswordHashing)
+	if err != nil {
+		return abort(fmt.Errorf("invalid password hashing configuration: %w", err))
+	}
+	userHandler := handler.NewUserHandler(repo, cfg, log, obs.Metrics,
+		service.WithJWTUtil(jwtUtil),
+		service.WithNotifier(notifier),
+		service.WithDirectory(directory),
+		service.WithPasswordPolicy(passwords),
+		service.WithPasswordHasher(hasher),
+	)
+	userv1.RegisterUserServiceServer(grpcServer, userHandler)
+	store.Subscribe(func(e config.Event) {
+		if e.Has("lockout") || e.Has("email_verification.required_for_login") {
+			userHandler.ApplyConfig(e.New)
+		}
+	})
+
+	checker := health.NewChecker(time.Duration(cfg.Health.TimeoutSeconds)*time.Second, userv1.UserService_ServiceDesc.ServiceName)
+	checker.Add("postgres", repo.Ping)
+	if cfg.Etcd.Enabled {
+		checker.Add("etcd", etcdClient.Ping)
+	}
+	if probe := tracing.ExporterProbe(cfg); probe != nil {
+		checker.AddOptional("tracing", probe)
+	}
+	healthpb.RegisterHealthServer(grpcServer, checker.Server())
+	httpMux.Handle(health.LivenessPath, checker.LivenessHandler())
+	httpMux.Handle(health.ReadinessPath, checker.ReadinessHandler())
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Service.Port))
+	if err != nil {
+		return abort(fmt.Errorf("failed to listen on port %d: %w", cfg.Service.Port, err))
+	}
+
+	var registrar registry.Registrar
+	switch cfg.Registry.Type {
+	case registry.TypeConsul:
+		consulClient, err := consul.NewConsulClient(cfg, log)
+		if err != nil {
+			return abort(err)
+		}
+		registrar = consulClient
+	case registry.TypeEtcd:
+		registrar = etcdClient
+	case registry.TypeNone:
+	default:
+		return abort(fmt.Errorf("unknown registry type %q", cfg.Registry.Type))
+	}
+
+	// The gateway forwards over loopback so REST calls pass through the same
+	// interceptors as gRPC calls.
+	var gw *gateway.Gateway
+	if cfg.Gateway.Enabled {
+		gw, err = gateway.New(fmt.Sprintf("localhost:%d", cfg.Service.Port))
+		if err != nil {
+			return abort(fmt.Errorf("failed to create REST gateway: %w", err))
+		}
+		httpMux.Handle("/", gw)
+		lc.Add(lifecycle.Component{Name: "REST gateway connection", Stop: func(context.Context) error { return gw.Close() }})
+	}
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Service.HTTPPort),
+		Handler:           httpMux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	lc.Add(lifecycle.Component{
+		Name: "gRPC server",
+		Start: func(ctx context.Context) error {
+			go func() {
+				if err := grpcServer.Serve(lis); err != nil {
+					lc.Fail(fmt.Errorf("gRPC server: %w", err))
+				}
+			}()
+			log.Info(ctx).Msgf("gRPC server listening on %s", lis.Addr())
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return lifecycle.GracefulStop(ctx, grpcServer)
+		},
+	})
+	lc.Add(lifecycle.Component{
+		Name: "HTTP server",
+		Start: func(ctx context.Context) error {
+			go func() {
+				if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					lc.Fail(fmt.Errorf("HTTP server: %w", err))
+				}
+			}()
+			log.Info(ctx).Msgf("HTTP server listening on %s", httpServer.Addr)
+			return nil
+		},
+		Stop: httpServer.Shutdown,
+	})
+	if registrar != nil {
+		lc.Add(lifecycle.Component{
+			Name: cfg.Registry.Type + " registration",
+			Start: func(ctx context.Context) error {
+				if err := registrar.RegisterService(ctx); err != nil {
+					log.Error(ctx).Err(err).Msgf("Continuing without %s registration", cfg.Registry.Type)
+				}
+				return nil
+			},
+			Stop: registrar.DeregisterService,
+		})
+	}
+	// The checker is stopped first, reporting not serving before the service
+	// is deregistered so that nothing new is routed here while in-flight
+	// requests drain.
+	lc.Add(lifecycle.Component{
+		Name: "health checker",
+		Start: func(ctx context.Context) error {
+			go checker.Run(ctx, time.Duration(cfg.Health.IntervalSeconds)*time.Second)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			checker.Shutdown()
+			return nil
+		},
+	})
+
+	if err := lc.Start(ctx); err != nil {
+		return abort(err)
+	}
+	err = lc.Wait(ctx)
+	if err != nil {
+		log.Error(ctx).Err(err).Msg("Server stopped unexpectedly")
+	} else {
+		log.Info(ctx).Msg("Shutdown signal received")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return errors.Join(err, lc.Stop(shutdownCtx))
+}
@@ -2,19 +2,42 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration.
 type Config struct {
+	// Environment is dev, staging or prod. Validate refuses insecure
+	// defaults in prod.
+	Environment string `mapstructure:"environment"`
 	Service  ServiceConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Consul   ConsulConfig
+	// Registry selects Consul or etcd for service discovery.
+	Registry RegistryConfig `mapstructure:"registry"`
 	Etcd     EtcdConfig
 	Tracing  TracingConfig
 	Metrics  MetricsConfig
+	Notifier NotifierConfig
+	// PasswordReset configures the forgotten-password flow.
+	PasswordReset PasswordResetConfig `mapstructure:"password_reset"`
+	// EmailVerification configures the registration email check.
+	EmailVerification EmailVerificationConfig `mapstructure:"email_verification"`
+	Campus            CampusConfig
+	RBAC              RBACConfig    `mapstructure:"rbac"`
+	MFA               MFAConfig     `mapstructure:"mfa"`
+	Lockout           LockoutConfig `mapstructure:"lockout"`
+	// PasswordPolicy sets the rules new passwords must satisfy.
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+	// PasswordHashing selects how passwords are hashed.
+	PasswordHashing PasswordHashingConfig `mapstructure:"password_hashing"`
+	// Gateway serves the API as REST/JSON on service.http_port.
+	Gateway GatewayConfig `mapstructure:"gateway"`
+	// Health controls the dependency probes behind the health endpoints.
+	Health HealthConfig `mapstructure:"health"`
 }
 // MetricsConfig holds metrics settings.
 type MetricsConfig struct {
@@ -25,6 +48,11 @@ type ServiceConfig struct {
 	Name     string `mapstructure:"name"`
 	Port     int    `mapstructure:"port"`
 	LogLevel string `mapstructure:"log_level"`
+	HTTPPort int    `mapstructure:"http_port"` // serves the JWKS document and the REST gateway
+	Version  string `mapstructure:"version"`   // release reported in the service registry
+	// ShutdownTimeoutSeconds bounds how long in-flight requests may take to
+	// drain before the remaining connections are closed.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 }
 
 type DatabaseConfig struct {
@@ -39,20 +67,296 @@ type DatabaseConfig struct {
 
 type JWTConfig struct {
 	Secret        string        `mapstructure:"secret"`
-	DurationHours int `mapstructure:"duration_hours"`
+	DurationHours int `mapstructure:"duration_hours"` // used only when AccessTokenMinutes is 0
+	Issuer        string `mapstructure:"issuer"`
+	Audience      string `mapstructure:"audience"`
+	// AccessTokenMinutes is the lifetime of access tokens issued at login and refresh.
+	AccessTokenMinutes int `mapstructure:"access_token_minutes"`
+	// RefreshTokenHours is the lifetime of opaque refresh tokens.
+	RefreshTokenHours int `mapstructure:"refresh_token_hours"`
+	// Denylist selects where revoked tokens are stored: "postgres" or "memory".
+	Denylist string `mapstructure:"denylist"`
+	// DenylistCleanupMinutes is how often expired denylist entries are purged.
+	DenylistCleanupMinutes int `mapstructure:"denylist_cleanup_minutes"`
+	// Keys are the RS256/EdDSA signing keys. When empty, tokens are signed
+	// with Secret using HS256.
+	Keys []JWTKeyConfig `mapstructure:"keys"`
+	// KeyRotationMinutes is how often key files are reloaded and the active
+	// signing key is re-selected.
+	KeyRotationMinutes int `mapstructure:"key_rotation_minutes"`
+}
+
+// JWTKeyConfig describes one signing key loaded from PEM files.
+type JWTKeyConfig struct {
+	ID string `mapstructure:"id"` // published as the "kid" header
+	// PrivateKeyFile holds an RSA or Ed25519 private key. Keys without one
+	// are only used to verify tokens and are published in the JWKS.
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	// PublicKeyFile is only needed for verify-only keys.
+	PublicKeyFile string `mapstructure:"public_key_file"`
+	// ActivateAt is the RFC 3339 time from which the key signs new tokens.
+	// The newest activated key signs; empty means active immediately.
+	ActivateAt string `mapstructure:"activate_at"`
+}
+
+// AccessTokenDuration returns the configured access token lifetime.
+func (c *JWTConfig) AccessTokenDuration() time.Duration {
+	if c.AccessTokenMinutes > 0 {
+		return time.Duration(c.AccessTokenMinutes) * time.Minute
+	}
+	return time.Duration(c.DurationHours) * time.Hour
+}
+
+// RefreshTokenDuration returns the configured refresh token lifetime.
+func (c *JWTConfig) RefreshTokenDuration() time.Duration {
+	return time.Duration(c.RefreshTokenHours) * time.Hour
 }
 
 type ConsulConfig struct {
 	Enabled   bool   `mapstructure:"enabled"`
 	Address   string `mapstructure:"address"`
 	ServiceID string `mapstructure:"service_id"`
+	// DeregisterCriticalAfterSeconds removes the instance once its health
+	// check has been critical this long, e.g. after its pod died without
+	// deregistering. Zero keeps critical instances registered.
+	DeregisterCriticalAfterSeconds int `mapstructure:"deregister_critical_after_seconds"`
+}
+
+// RegistryConfig selects where the service registers itself for discovery
+// and what it publishes there.
+type RegistryConfig struct {
+	Type string `mapstructure:"type"` // consul, etcd or none
+	// AdvertiseAddress is the address other services reach this instance
+	// on. When empty it is taken from the ADVERTISE_ADDRESS environment
+	// variable, then from Interface.
+	AdvertiseAddress string `mapstructure:"advertise_address"`
+	// Interface names the network interface whose IPv4 address is
+	// advertised. When empty the interface of the default route is used.
+	Interface string            `mapstructure:"interface"`
+	Tags      []string          `mapstructure:"tags"`
+	Meta      map[string]string `mapstructure:"meta"` // merged with the version and proto package
+}
+
+
+// NotifierConfig selects how messages such as password reset tokens are
+// delivered to users.
+type NotifierConfig struct {
+	Type     string `mapstructure:"type"`      // "log" or "file"
+	FilePath string `mapstructure:"file_path"` // JSON lines output of the file notifier
+}
+
+// PasswordResetConfig holds password reset settings.
+type PasswordResetConfig struct {
+	// TokenMinutes is how long a reset token stays valid.
+	TokenMinutes int `mapstructure:"token_minutes"`
+}
+
+// TokenDuration returns the lifetime of password reset tokens.
+func (c *PasswordResetConfig) TokenDuration() time.Duration {
+	if c.TokenMinutes <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(c.TokenMinutes) * time.Minute
+}
+
+// EmailVerificationConfig holds email verification settings.
+type EmailVerificationConfig struct {
+	// RequiredForLogin makes Login refuse accounts whose email is unverified.
+	RequiredForLogin bool `mapstructure:"required_for_login"`
+	// TokenHours is how long a verification token stays valid.
+	TokenHours int `mapstructure:"token_hours"`
+}
+
+// TokenDuration returns the lifetime of email verification tokens.
+func (c *EmailVerificationConfig) TokenDuration() time.Duration {
+	if c.TokenHours <= 0 {
+		return 48 * time.Hour
+	}
+	return time.Duration(c.TokenHours) * time.Hour
+}
+
+// CampusConfig restricts registration to institutional email domains.
+type CampusConfig struct {
+	// Institutions lists the schools whose members may register. When it is
+	// empty, any email domain is accepted and users have no affiliation.
+	Institutions []InstitutionConfig `mapstructure:"institutions"`
+}
+
+// InstitutionConfig maps the email domains of one school to roles.
+type InstitutionConfig struct {
+	ID      string         `mapstructure:"id"` // exposed as UserInfo.affiliation.institution_id
+	Name    string         `mapstructure:"name"`
+	Domains []DomainConfig `mapstructure:"domains"`
+}
+
+// DomainConfig assigns a role to addresses under one email domain.
+type DomainConfig struct {
+	// Domain also matches its subdomains; the longest matching domain wins.
+	Domain string `mapstructure:"domain"`
+	// Role is "student", "staff" or "alumni".
+	Role string `mapstructure:"role"`
 }
 
+// RBACConfig holds role-based access control settings.
+type RBACConfig struct {
+	// PolicyFile declares the permission each gRPC method requires.
+	PolicyFile string `mapstructure:"policy_file"`
+}
+
+// MFAConfig holds TOTP two-factor authentication settings.
+type MFAConfig struct {
+	// Issuer is the account issuer shown in authenticator apps.
+	Issuer string `mapstructure:"issuer"`
+	// ChallengeMinutes is how long the challenge returned by Login stays valid.
+	ChallengeMinutes int `mapstructure:"challenge_minutes"`
+	// MaxAttempts is the number of wrong codes a challenge accepts.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Skew is the number of 30-second steps of clock drift accepted either way.
+	Skew int `mapstructure:"skew"`
+	// RecoveryCodes is the number of one-time recovery codes issued at enrollment.
+	RecoveryCodes int `mapstructure:"recovery_codes"`
+	// RequiredRoles lists the campus roles that must use a second factor.
+	// Their access tokens only allow enrollment until they have one.
+	RequiredRoles []string `mapstructure:"required_roles"`
+}
+
+// ChallengeDuration returns the lifetime of MFA challenges.
+func (c *MFAConfig) ChallengeDuration() time.Duration {
+	if c.ChallengeMinutes <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.ChallengeMinutes) * time.Minute
+}
+
+// Attempts returns the number of wrong codes a challenge accepts.
+func (c *MFAConfig) Attempts() int {
+	if c.MaxAttempts <= 0 {
+		return 5
+	}
+	return c.MaxAttempts
+}
+
+// RecoveryCodeCount returns the number of recovery codes to issue.
+func (c *MFAConfig) RecoveryCodeCount() int {
+	if c.RecoveryCodes <= 0 {
+		return 10
+	}
+	return c.RecoveryCodes
+}
+
+// LockoutConfig holds the brute-force protection applied to Login. Failed
+// attempts are counted per account and per client IP; past BackoffAfter
+// failures each attempt waits an exponentially growing delay, and past
+// LockoutAfter the key is locked for LockoutMinutes.
+type LockoutConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// WindowMinutes is how long failures are remembered after the last one.
+	WindowMinutes      int               `mapstructure:"window_minutes"`
+	BackoffBaseSeconds int               `mapstructure:"backoff_base_seconds"`
+	BackoffMaxSeconds  int               `mapstructure:"backoff_max_seconds"`
+	LockoutMinutes     int               `mapstructure:"lockout_minutes"`
+	Account            LockoutThresholds `mapstructure:"account"`
+	// IP thresholds are usually higher, since campus networks put many
+	// users behind one NAT address.
+	IP LockoutThresholds `mapstructure:"ip"`
+}
+
+// LockoutThresholds are the failure counts that trigger backoff and lockout.
+type LockoutThresholds struct {
+	BackoffAfter int `mapstructure:"backoff_after"`
+	LockoutAfter int `mapstructure:"lockout_after"`
+}
+
+// Window returns how long failures are remembered.
+func (c *LockoutConfig) Window() time.Duration {
+	return time.Duration(c.WindowMinutes) * time.Minute
+}
+
+// BackoffBase returns the first backoff delay.
+func (c *LockoutConfig) BackoffBase() time.Duration {
+	return time.Duration(c.BackoffBaseSeconds) * time.Second
+}
+
+// BackoffMax returns the longest backoff delay.
+func (c *LockoutConfig) BackoffMax() time.Duration {
+	return time.Duration(c.BackoffMaxSeconds) * time.Second
+}
+
+// LockoutDuration returns how long a lockout lasts.
+func (c *LockoutConfig) LockoutDuration() time.Duration {
+	return time.Duration(c.LockoutMinutes) * time.Minute
+}
+
+// PasswordPolicyConfig holds the rules applied to new passwords at
+// registration, password change and password reset.
+type PasswordPolicyConfig struct {
+	MinLength int `mapstructure:"min_length"` // in characters
+	// MaxLength is in bytes, since bcrypt ignores everything past 72 bytes.
+	// Zero means unlimited.
+	MaxLength        int  `mapstructure:"max_length"`
+	RequireLowercase bool `mapstructure:"require_lowercase"`
+	RequireUppercase bool `mapstructure:"require_uppercase"`
+	RequireDigit     bool `mapstructure:"require_digit"`
+	RequireSymbol    bool `mapstructure:"require_symbol"`
+	// DisallowPersonalInfo rejects passwords containing the email address,
+	// its local part or the nickname.
+	DisallowPersonalInfo bool `mapstructure:"disallow_personal_info"`
+	// BreachedFile lists SHA-1 hashes of breached passwords, one
+	// "HASH[:COUNT]" per line as in the Pwned Passwords downloads. Empty
+	// disables the check.
+	BreachedFile string `mapstructure:"breached_file"`
+}
+
+// PasswordHashingConfig selects the algorithm new password hashes are made
+// with. Hashes made with another algorithm or weaker parameters are
+// replaced on the user's next successful login.
+type PasswordHashingConfig struct {
+	Algorithm string         `mapstructure:"algorithm"` // "bcrypt" or "argon2id"
+	Bcrypt    BcryptConfig   `mapstructure:"bcrypt"`
+	Argon2id  Argon2idConfig `mapstructure:"argon2id"`
+}
+
+// BcryptConfig holds the bcrypt work factor.
+type BcryptConfig struct {
+	Cost int `mapstructure:"cost"`
+}
+
+// Argon2idConfig holds the Argon2id parameters.
+type Argon2idConfig struct {
+	MemoryKiB   int `mapstructure:"memory_kib"`
+	Iterations  int `mapstructure:"iterations"`
+	Parallelism int `mapstructure:"parallelism"`
+	SaltLength  int `mapstructure:"salt_length"` // in bytes
+	KeyLength   int `mapstructure:"key_length"`  // in bytes
+}
+
+// GatewayConfig controls the REST/JSON gateway. It forwards each request to
+// the gRPC server on service.port and publishes the OpenAPI description at
+// /openapi.json.
+type GatewayConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// HealthConfig controls the dependency probes reported by the
+// grpc.health.v1 service and the /readyz endpoint.
+type HealthConfig struct {
+	IntervalSeconds int `mapstructure:"interval_seconds"` // between probes for the gRPC status
+	TimeoutSeconds  int `mapstructure:"timeout_seconds"`  // for each round of probes
+}
 
 // EtcdConfig holds etcd settings.
 type EtcdConfig struct {
 	Enabled   bool `mapstructure:"enabled"`
 	Endpoints []string `mapstructure:"endpoints"`
+	// ConfigPrefix is the key prefix of the dynamic configuration layer. A
+	// key such as <prefix>service/log_level overrides service.log_level.
+	ConfigPrefix string `mapstructure:"config_prefix"`
+	// RegistryPrefix is where instances register when registry.type is
+	// etcd, one key per instance under <prefix><service name>/.
+	RegistryPrefix string `mapstructure:"registry_prefix"`
+	// LeaseTTLSeconds is how long the registration of an instance that
+	// stopped renewing its lease, e.g. after a crash, survives.
+	LeaseTTLSeconds int `mapstructure:"lease_ttl_seconds"`
 }
 
 // TracingConfig holds tracing settings.
@@ -63,30 +367,90 @@ type TracingConfig struct {
     OTLPEndpoint   string `mapstructure:"otlp_endpoint"`
 }
 
-// LoadConfig initializes and returns the application configuration.
+// LoadConfig reads the configuration from the defaults, the YAML file at
+// configPath and the environment. Use NewStore to also apply etcd and to
+// reload on changes.
 func LoadConfig(configPath string) (*Config, error) {
-	v := viper.New()
-	v.SetConfigFile(configPath)
-	v.SetConfigType("yaml")
+	store, err := NewStore(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return store.Config(), nil
+}
 
-	// Set defaults
+// setDefaults registers the lowest configuration layer on v.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("environment", EnvDev)
 	v.SetDefault("service.name", "user-service")
 	v.SetDefault("service.port", 8080)
 	v.SetDefault("service.log_level", "info")
+	v.SetDefault("service.http_port", 8081)
+	v.SetDefault("service.version", "dev")
+	v.SetDefault("service.shutdown_timeout_seconds", 10)
 	v.SetDefault("database.driver", "postgres")
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
 	v.SetDefault("database.user", "postgres")
-	v.SetDefault("database.password", "postgres")
+	v.SetDefault("database.password", defaultDatabasePassword)
 	v.SetDefault("database.dbname", "users")
 	v.SetDefault("database.sslmode", "disable")
-	v.SetDefault("jwt.secret", "secret-key")
+	v.SetDefault("jwt.secret", defaultJWTSecret)
 	v.SetDefault("jwt.duration_hours", 24)
+	v.SetDefault("jwt.issuer", "gocampus-user-service")
+	v.SetDefault("jwt.audience", "gocampus")
+	v.SetDefault("jwt.access_token_minutes", 15)
+	v.SetDefault("jwt.refresh_token_hours", 720)
+	v.SetDefault("jwt.denylist", "postgres")
+	v.SetDefault("jwt.denylist_cleanup_minutes", 10)
+	v.SetDefault("jwt.key_rotation_minutes", 5)
+	v.SetDefault("notifier.type", "log")
+	v.SetDefault("password_reset.token_minutes", 30)
+	v.SetDefault("email_verification.required_for_login", false)
+	v.SetDefault("email_verification.token_hours", 48)
+	v.SetDefault("rbac.policy_file", "config/policy.yaml")
+	v.SetDefault("mfa.issuer", "GoCampus")
+	v.SetDefault("mfa.challenge_minutes", 5)
+	v.SetDefault("mfa.max_attempts", 5)
+	v.SetDefault("mfa.skew", 1)
+	v.SetDefault("mfa.recovery_codes", 10)
+	v.SetDefault("mfa.required_roles", []string{"staff"})
+	v.SetDefault("lockout.enabled", true)
+	v.SetDefault("lockout.window_minutes", 30)
+	v.SetDefault("lockout.backoff_base_seconds", 1)
+	v.SetDefault("lockout.backoff_max_seconds", 60)
+	v.SetDefault("lockout.lockout_minutes", 15)
+	v.SetDefault("lockout.account.backoff_after", 3)
+	v.SetDefault("lockout.account.lockout_after", 10)
+	v.SetDefault("lockout.ip.backoff_after", 20)
+	v.SetDefault("lockout.ip.lockout_after", 100)
+	v.SetDefault("password_policy.min_length", 8)
+	v.SetDefault("password_policy.max_length", 72)
+	v.SetDefault("password_policy.require_lowercase", true)
+	v.SetDefault("password_policy.require_uppercase", false)
+	v.SetDefault("password_policy.require_digit", true)
+	v.SetDefault("password_policy.require_symbol", false)
+	v.SetDefault("password_policy.disallow_personal_info", true)
+	v.SetDefault("password_policy.breached_file", "")
+	v.SetDefault("password_hashing.algorithm", "argon2id")
+	v.SetDefault("password_hashing.bcrypt.cost", 12)
+	v.SetDefault("password_hashing.argon2id.memory_kib", 19456)
+	v.SetDefault("password_hashing.argon2id.iterations", 2)
+	v.SetDefault("password_hashing.argon2id.parallelism", 1)
+	v.SetDefault("password_hashing.argon2id.salt_length", 16)
+	v.SetDefault("password_hashing.argon2id.key_length", 32)
+	v.SetDefault("gateway.enabled", true)
+	v.SetDefault("health.interval_seconds", 5)
+	v.SetDefault("health.timeout_seconds", 2)
 	v.SetDefault("consul.enabled", false)
 	v.SetDefault("consul.address", "localhost:8500")
 	v.SetDefault("consul.service_id", "user-service-1")
+	v.SetDefault("consul.deregister_critical_after_seconds", 90)
 	v.SetDefault("etcd.enabled", false)
 	v.SetDefault("etcd.endpoints", []string{"localhost:2379"})
+	v.SetDefault("etcd.config_prefix", "/gocampus/user-service/config/")
+	v.SetDefault("etcd.registry_prefix", "/gocampus/services/")
+	v.SetDefault("etcd.lease_ttl_seconds", 10)
+	v.SetDefault("registry.type", "consul")
 
 	v.SetDefault("tracing.jaeger_enabled", false)
 	v.SetDefault("tracing.jaeger_endpoint", "http://localhost:14268/api/traces")
@@ -94,21 +458,6 @@ func LoadConfig(configPath string) (*Config, error) {
 	v.SetDefault("tracing.otlp_endpoint", "localhost:4317")
 
 	v.SetDefault("metrics.port", "9090")
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	// Enable hot-reloading
-	v.WatchConfig()
-
-	// Load configuration
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-	}
-
-	return &cfg, nil
 }
 
 // GetDSN returns the PostgreSQL connection string.
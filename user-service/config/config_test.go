@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -160,4 +161,75 @@ func TestDatabaseConfig_GetDSN(t *testing.T) {
 	if dsn := cfg.GetDSN(); dsn != expectedDSN {
 		t.Errorf("GetDSN() = %v, want %v", dsn, expectedDSN)
 	}
-}
\ No newline at end of file
+}
+
+func TestLoadConfig_Campus(t *testing.T) {
+	configContent := `
+campus:
+  institutions:
+    - id: example
+      name: Example University
+      domains:
+        - domain: stu.example.edu
+          role: student
+        - domain: example.edu
+          role: staff
+tracing:
+  otlp_enabled: true
+`
+	tmpFile, err := os.CreateTemp("", "config*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write([]byte(configContent)); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Campus.Institutions) != 1 {
+		t.Fatalf("Campus.Institutions = %+v, want 1 institution", cfg.Campus.Institutions)
+	}
+	inst := cfg.Campus.Institutions[0]
+	if inst.ID != "example" || inst.Name != "Example University" || len(inst.Domains) != 2 ||
+		inst.Domains[0].Domain != "stu.example.edu" || inst.Domains[0].Role != "student" {
+		t.Errorf("Campus.Institutions[0] = %+v", inst)
+	}
+}
+
+func TestLoadConfig_Lockout(t *testing.T) {
+	configContent := `
+lockout:
+  lockout_minutes: 5
+  account:
+    lockout_after: 6
+tracing:
+  otlp_enabled: true
+`
+	tmpFile, err := os.CreateTemp("", "config*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write([]byte(configContent)); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	lockout := cfg.Lockout
+	if !lockout.Enabled || lockout.LockoutDuration() != 5*time.Minute || lockout.Window() != 30*time.Minute {
+		t.Errorf("Lockout = %+v", lockout)
+	}
+	// Unset nested keys keep their defaults
+	if lockout.Account.LockoutAfter != 6 || lockout.Account.BackoffAfter != 3 || lockout.IP.LockoutAfter != 100 {
+		t.Errorf("Lockout thresholds = %+v, %+v", lockout.Account, lockout.IP)
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// bindEnv makes every key of Config overridable from the environment:
+// GOCAMPUS_DATABASE_HOST sets database.host, and GOCAMPUS_DATABASE_PASSWORD_FILE
+// names a file holding database.password, for secrets mounted into the
+// container. Every key is bound explicitly, since viper only consults the
+// environment for keys that have a default or appear in the file.
+func bindEnv(v *viper.Viper) error {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return fmt.Errorf("failed to bind %s to the environment: %w", key, err)
+		}
+
+		name := EnvName(key)
+		path := os.Getenv(name + "_FILE")
+		if path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			return fmt.Errorf("both %s and %s_FILE are set", name, name)
+		}
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		v.Set(key, strings.TrimRight(string(value), "\r\n"))
+	}
+	return nil
+}
+
+// EnvName returns the environment variable that overrides key, e.g.
+// GOCAMPUS_DATABASE_HOST for database.host.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// configKeys returns the dotted keys of the leaves of the struct type t,
+// named as viper decodes them: by mapstructure tag, else by field name.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(field.Type, name)...)
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
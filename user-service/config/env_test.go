@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "database:\n  host: localhost\n")
+	secret := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("GOCAMPUS_DATABASE_HOST", "postgres")
+	t.Setenv("GOCAMPUS_DATABASE_PASSWORD_FILE", secret)
+	// Neither a default nor the file sets these keys.
+	t.Setenv("GOCAMPUS_NOTIFIER_FILE_PATH", "/var/log/notifications.jsonl")
+	t.Setenv("GOCAMPUS_REGISTRY_TAGS", "grpc,rest")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Database.Host != "postgres" {
+		t.Errorf("Database.Host = %q, want the environment value", cfg.Database.Host)
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Errorf("Database.Password = %q, want the content of the _FILE without the newline", cfg.Database.Password)
+	}
+	if cfg.Notifier.FilePath != "/var/log/notifications.jsonl" {
+		t.Errorf("Notifier.FilePath = %q", cfg.Notifier.FilePath)
+	}
+	if len(cfg.Registry.Tags) != 2 || cfg.Registry.Tags[1] != "rest" {
+		t.Errorf("Registry.Tags = %v", cfg.Registry.Tags)
+	}
+}
+
+func TestEnvOverrides_FileErrors(t *testing.T) {
+	path := writeConfig(t, "")
+
+	t.Setenv("GOCAMPUS_JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "GOCAMPUS_JWT_SECRET_FILE") {
+		t.Errorf("LoadConfig() with a missing secret file error = %v", err)
+	}
+
+	t.Setenv("GOCAMPUS_JWT_SECRET", "inline")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("LoadConfig() with both variables error = %v", err)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		keys[key] = true
+	}
+	for _, key := range []string{"service.log_level", "database.password", "jwt.secret", "lockout.account.lockout_after", "password_hashing.argon2id.memory_kib", "campus.institutions"} {
+		if !keys[key] {
+			t.Errorf("configKeys() lacks %s", key)
+		}
+	}
+	if EnvName("lockout.account.lockout_after") != "GOCAMPUS_LOCKOUT_ACCOUNT_LOCKOUT_AFTER" {
+		t.Errorf("EnvName() = %s", EnvName("lockout.account.lockout_after"))
+	}
+}
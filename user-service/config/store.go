@@ -0,0 +1,328 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the
+// configuration file, e.g. GOCAMPUS_SERVICE_LOG_LEVEL for service.log_level.
+// See bindEnv.
+const EnvPrefix = "GOCAMPUS"
+
+// Sources of a configuration change.
+const (
+	SourceFile   = "file"
+	SourceRemote = "remote"
+)
+
+// Remote is a configuration layer applied over the file and the
+// environment, such as an etcd prefix. Keys are dotted paths such as
+// "service.log_level"; values are YAML, so "5", "true" and "[a, b]" are
+// decoded as a number, a bool and a list.
+type Remote interface {
+	// ConfigValues returns every key of the layer.
+	ConfigValues(ctx context.Context) (map[string]string, error)
+	// WatchConfig calls changed after each change of the layer until ctx
+	// is done.
+	WatchConfig(ctx context.Context, changed func())
+}
+
+// Event is published to subscribers after a reload changed the
+// configuration.
+type Event struct {
+	Source string   // SourceFile or SourceRemote
+	Keys   []string // dotted keys whose value changed, sorted
+	Old    *Config
+	New    *Config
+}
+
+// Has reports whether key or a key below it changed, so that Has("lockout")
+// is true after lockout.account.lockout_after changed.
+func (e Event) Has(key string) bool {
+	for _, k := range e.Keys {
+		if k == key || strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// RuntimeKeys are the keys, with the keys below them, that the service
+// applies while it runs. A change to any other key takes effect after a
+// restart.
+var RuntimeKeys = []string{
+	"service.log_level",
+	"lockout",
+	"email_verification.required_for_login",
+}
+
+// RestartKeys returns the changed keys that are not among RuntimeKeys.
+func (e Event) RestartKeys() []string {
+	var keys []string
+	for _, k := range e.Keys {
+		runtime := false
+		for _, r := range RuntimeKeys {
+			if k == r || strings.HasPrefix(k, r+".") {
+				runtime = true
+				break
+			}
+		}
+		if !runtime {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Store holds the current configuration, layered from lowest to highest
+// precedence: the defaults, the YAML file, the GOCAMPUS_ environment
+// variables and the remote layer. Each reload builds a new Config, so a
+// *Config obtained from the store is never modified; components that
+// support changes at runtime subscribe to the store instead.
+type Store struct {
+	path string
+
+	// reloadMu serializes reloads so that subscribers see events in order.
+	reloadMu     sync.Mutex
+	remote       Remote
+	remoteValues map[string]string
+	settings     map[string]any // flattened, of the current configuration
+
+	mu          sync.RWMutex
+	cfg         *Config
+	subscribers []func(Event)
+}
+
+// NewStore loads the configuration from the defaults, the YAML file at path
+// and the environment.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	cfg, settings, err := s.load(nil)
+	if err != nil {
+		return nil, err
+	}
+	s.cfg, s.settings = cfg, settings
+	return s, nil
+}
+
+// Config returns the current configuration.
+func (s *Store) Config() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Subscribe registers fn to be called after every change. fn runs on the
+// goroutine that reloaded the configuration and must not call Reload.
+func (s *Store) Subscribe(fn func(Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// SetRemote adds the remote layer and applies it.
+func (s *Store) SetRemote(ctx context.Context, remote Remote) error {
+	s.reloadMu.Lock()
+	s.remote = remote
+	s.reloadMu.Unlock()
+	return s.Reload(ctx, SourceRemote)
+}
+
+// Reload rebuilds the configuration after source changed, rereading the
+// remote layer if source is SourceRemote, and publishes an Event if any
+// value changed. On failure the current configuration is kept.
+func (s *Store) Reload(ctx context.Context, source string) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	values := s.remoteValues
+	if source == SourceRemote && s.remote != nil {
+		var err error
+		if values, err = s.remote.ConfigValues(ctx); err != nil {
+			return fmt.Errorf("failed to read remote configuration: %w", err)
+		}
+	}
+	cfg, settings, err := s.load(values)
+	if err != nil {
+		return err
+	}
+	s.remoteValues = values
+
+	keys := changedKeys(s.settings, settings)
+	s.settings = settings
+	s.mu.Lock()
+	event := Event{Source: source, Keys: keys, Old: s.cfg, New: cfg}
+	s.cfg = cfg
+	subscribers := s.subscribers
+	s.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return nil
+}
+
+// Watch reloads the configuration whenever the file or the remote layer
+// changes, until ctx is done, when both watches stop. It returns
+// immediately. A failed reload keeps the current configuration and is
+// reported to onError.
+func (s *Store) Watch(ctx context.Context, onError func(error)) {
+	reload := func(source string) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.Reload(ctx, source); err != nil {
+			onError(err)
+		}
+	}
+
+	if err := s.watchFile(ctx, func() { reload(SourceFile) }, onError); err != nil {
+		onError(err)
+	}
+
+	s.reloadMu.Lock()
+	remote := s.remote
+	s.reloadMu.Unlock()
+	if remote != nil {
+		go remote.WatchConfig(ctx, func() { reload(SourceRemote) })
+	}
+}
+
+// fileSettleDelay is how long the file must stay unchanged before it is
+// reloaded, so that a write seen as several events is read once, complete.
+const fileSettleDelay = 100 * time.Millisecond
+
+// watchFile calls changed after the configuration file changed, until ctx
+// is done. The directory is watched rather than the file so that the file
+// is followed through renames and symlink swaps, as when a Kubernetes
+// ConfigMap is updated.
+func (s *Store) watchFile(ctx context.Context, changed func(), onError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	path := filepath.Clean(s.path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	target, _ := filepath.EvalSymlinks(path)
+
+	go func() {
+		defer watcher.Close()
+		settle := time.NewTimer(0)
+		<-settle.C
+		defer settle.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				current, _ := filepath.EvalSymlinks(path)
+				written := filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create)
+				if written || (current != "" && current != target) {
+					target = current
+					settle.Reset(fileSettleDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onError(fmt.Errorf("config file watch: %w", err))
+			case <-settle.C:
+				changed()
+			}
+		}
+	}()
+	return nil
+}
+
+// load builds and validates the configuration from every layer, with remote
+// as the top layer, and returns it along with its flattened settings.
+func (s *Store) load(remote map[string]string) (*Config, map[string]any, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetConfigFile(s.path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := bindEnv(v); err != nil {
+		return nil, nil, err
+	}
+
+	for key, value := range remote {
+		v.Set(key, parseValue(value))
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	settings := make(map[string]any)
+	flatten(settings, "", v.AllSettings())
+	return &cfg, settings, nil
+}
+
+// parseValue decodes a remote value as YAML, falling back to the raw string.
+func parseValue(value string) any {
+	var decoded any
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil || decoded == nil {
+		return value
+	}
+	return decoded
+}
+
+// flatten adds the leaves of settings to out, keyed by dotted path.
+func flatten(out map[string]any, prefix string, settings map[string]any) {
+	for key, value := range settings {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			flatten(out, key, nested)
+			continue
+		}
+		out[key] = value
+	}
+}
+
+// changedKeys returns the sorted keys whose value differs between old and
+// new.
+func changedKeys(old, new map[string]any) []string {
+	var keys []string
+	for key, value := range new {
+		if oldValue, ok := old[key]; !ok || !reflect.DeepEqual(oldValue, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRemote is an in-memory remote layer.
+type fakeRemote struct {
+	values  map[string]string
+	err     error
+	changed chan func()
+}
+
+func (r *fakeRemote) ConfigValues(ctx context.Context) (map[string]string, error) {
+	return r.values, r.err
+}
+
+func (r *fakeRemote) WatchConfig(ctx context.Context, changed func()) {
+	r.changed <- changed
+}
+
+// tracingYAML enables the one tracing exporter every valid config needs.
+const tracingYAML = "tracing:\n  otlp_enabled: true\n"
+
+// writeConfig writes content, followed by tracingYAML, to a config file.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content+tracingYAML), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestStore_Layers(t *testing.T) {
+	path := writeConfig(t, `
+service:
+  name: from-file
+  log_level: warn
+lockout:
+  account:
+    lockout_after: 6
+`)
+	t.Setenv("GOCAMPUS_SERVICE_LOG_LEVEL", "error")
+	t.Setenv("GOCAMPUS_LOCKOUT_ACCOUNT_LOCKOUT_AFTER", "8")
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	cfg := store.Config()
+	if cfg.Service.Name != "from-file" || cfg.Service.Port != 8080 {
+		t.Errorf("Service = %+v, want the name from the file and the default port", cfg.Service)
+	}
+	if cfg.Service.LogLevel != "error" || cfg.Lockout.Account.LockoutAfter != 8 {
+		t.Errorf("environment not applied: log level %q, lockout after %d", cfg.Service.LogLevel, cfg.Lockout.Account.LockoutAfter)
+	}
+
+	remote := &fakeRemote{values: map[string]string{
+		"service.log_level":           "debug",
+		"lockout.backoff_max_seconds": "60",
+		"mfa.required_roles":          "[admin, staff]",
+	}}
+	if err := store.SetRemote(context.Background(), remote); err != nil {
+		t.Fatalf("SetRemote() error = %v", err)
+	}
+	cfg = store.Config()
+	if cfg.Service.LogLevel != "debug" || cfg.Lockout.BackoffMaxSeconds != 60 {
+		t.Errorf("remote layer not applied over the environment: log level %q, backoff max %d", cfg.Service.LogLevel, cfg.Lockout.BackoffMaxSeconds)
+	}
+	if !reflect.DeepEqual(cfg.MFA.RequiredRoles, []string{"admin", "staff"}) {
+		t.Errorf("MFA.RequiredRoles = %v", cfg.MFA.RequiredRoles)
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+	path := writeConfig(t, "service:\n  log_level: info\n")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	var events []Event
+	store.Subscribe(func(e Event) { events = append(events, e) })
+
+	remote := &fakeRemote{values: map[string]string{"service.log_level": "debug"}}
+	if err := store.SetRemote(context.Background(), remote); err != nil {
+		t.Fatalf("SetRemote() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %+v, want one", events)
+	}
+	e := events[0]
+	if e.Source != SourceRemote || !reflect.DeepEqual(e.Keys, []string{"service.log_level"}) {
+		t.Errorf("event = %s %v", e.Source, e.Keys)
+	}
+	if e.Old.Service.LogLevel != "info" || e.New.Service.LogLevel != "debug" || store.Config() != e.New {
+		t.Errorf("event configs: old %q, new %q", e.Old.Service.LogLevel, e.New.Service.LogLevel)
+	}
+	if !e.Has("service") || !e.Has("service.log_level") || e.Has("service.log") || e.Has("lockout") {
+		t.Error("Event.Has() does not match the changed key and its sections only")
+	}
+
+	// Nothing changed: no event.
+	if err := store.Reload(context.Background(), SourceRemote); err != nil || len(events) != 1 {
+		t.Errorf("Reload() without changes = %v, %d events", err, len(events))
+	}
+
+	// A removed remote key falls back to the file.
+	remote.values = nil
+	if err := store.Reload(context.Background(), SourceRemote); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if store.Config().Service.LogLevel != "info" || len(events) != 2 {
+		t.Errorf("log level after removing the remote key = %q", store.Config().Service.LogLevel)
+	}
+
+	// A failed reload keeps the current configuration.
+	current := store.Config()
+	remote.err = errors.New("etcd unavailable")
+	if err := store.Reload(context.Background(), SourceRemote); err == nil {
+		t.Error("Reload() error = nil, want the remote error")
+	}
+	if store.Config() != current || len(events) != 2 {
+		t.Error("failed reload replaced the configuration")
+	}
+}
+
+func TestStore_Watch(t *testing.T) {
+	path := writeConfig(t, "service:\n  log_level: info\n")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	remote := &fakeRemote{changed: make(chan func(), 1)}
+	if err := store.SetRemote(context.Background(), remote); err != nil {
+		t.Fatalf("SetRemote() error = %v", err)
+	}
+	events := make(chan Event, 2)
+	store.Subscribe(func(e Event) { events <- e })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store.Watch(ctx, func(err error) { t.Errorf("reload error = %v", err) })
+
+	if err := os.WriteFile(path, []byte("service:\n  log_level: warn\n"+tracingYAML), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	select {
+	case e := <-events:
+		if e.Source != SourceFile || e.New.Service.LogLevel != "warn" {
+			t.Errorf("file event = %s, log level %q", e.Source, e.New.Service.LogLevel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event after the file changed")
+	}
+
+	remote.values = map[string]string{"health.interval_seconds": "30"}
+	changed := <-remote.changed
+	changed()
+	select {
+	case e := <-events:
+		if e.Source != SourceRemote || e.New.Health.IntervalSeconds != 30 {
+			t.Errorf("remote event = %s, interval %d", e.Source, e.New.Health.IntervalSeconds)
+		}
+	default:
+		t.Fatal("no event after the remote layer changed")
+	}
+}
+
+func TestStore_WatchFileStops(t *testing.T) {
+	path := writeConfig(t, "")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	changed := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := store.watchFile(ctx, func() { changed <- struct{}{} }, func(err error) { t.Errorf("watch error = %v", err) }); err != nil {
+		t.Fatalf("watchFile() error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("service:\n  log_level: warn\n"+tracingYAML), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported after the file changed")
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("service:\n  log_level: info\n"+tracingYAML), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	select {
+	case <-changed:
+		t.Error("change reported after ctx was done")
+	case <-time.After(3 * fileSettleDelay):
+	}
+}
+
+func TestEvent_RestartKeys(t *testing.T) {
+	e := Event{Keys: []string{
+		"email_verification.required_for_login",
+		"email_verification.token_hours",
+		"lockout.account.lockout_after",
+		"service.log_level",
+		"service.port",
+	}}
+	want := []string{"email_verification.token_hours", "service.port"}
+	if got := e.RestartKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RestartKeys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Environments of Config.Environment.
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// Insecure defaults that prod refuses.
+const (
+	defaultJWTSecret        = "secret-key"
+	defaultDatabasePassword = "postgres"
+)
+
+// minSecretLength is the minimum length of the HS256 JWT secret outside
+// dev: 256 bits, the size of the HMAC-SHA256 key.
+const minSecretLength = 32
+
+// ValidationError lists every problem found by Validate.
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.Error())
+	}
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// Validate checks the configuration as a whole and returns a
+// *ValidationError listing every problem, or nil.
+func (c *Config) Validate() error {
+	var problems []error
+	fail := func(key, format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	switch c.Environment {
+	case EnvDev, EnvStaging, EnvProd:
+	default:
+		fail("environment", "must be %s, %s or %s, got %q", EnvDev, EnvStaging, EnvProd, c.Environment)
+	}
+
+	checkPort := func(key string, port int) {
+		if port < 1 || port > 65535 {
+			fail(key, "must be between 1 and 65535, got %d", port)
+		}
+	}
+	checkPort("service.port", c.Service.Port)
+	checkPort("service.http_port", c.Service.HTTPPort)
+	if metricsPort, err := strconv.Atoi(c.Metrics.Port); err != nil {
+		fail("metrics.port", "must be a port number, got %q", c.Metrics.Port)
+	} else {
+		checkPort("metrics.port", metricsPort)
+	}
+	if c.Service.Port == c.Service.HTTPPort {
+		fail("service.http_port", "must differ from service.port %d", c.Service.Port)
+	}
+	if c.Service.ShutdownTimeoutSeconds <= 0 {
+		fail("service.shutdown_timeout_seconds", "must be positive, got %d", c.Service.ShutdownTimeoutSeconds)
+	}
+
+	// Every part of the DSN must be set.
+	for _, field := range []struct{ key, value string }{
+		{"database.driver", c.Database.Driver},
+		{"database.host", c.Database.Host},
+		{"database.user", c.Database.User},
+		{"database.dbname", c.Database.DBName},
+	} {
+		if field.value == "" {
+			fail(field.key, "must not be empty")
+		}
+	}
+	checkPort("database.port", c.Database.Port)
+
+	if len(c.JWT.Keys) == 0 {
+		switch {
+		case c.JWT.Secret == "":
+			fail("jwt.secret", "must not be empty when jwt.keys is empty")
+		case c.Environment != EnvDev && len(c.JWT.Secret) < minSecretLength:
+			fail("jwt.secret", "must be at least %d bytes in %s, got %d", minSecretLength, c.Environment, len(c.JWT.Secret))
+		}
+	}
+
+	if countTrue(c.Tracing.JaegerEnabled, c.Tracing.OTLPEnabled) != 1 {
+		fail("tracing", "exactly one of jaeger_enabled and otlp_enabled must be set")
+	}
+	if c.Tracing.JaegerEnabled && c.Tracing.JaegerEndpoint == "" {
+		fail("tracing.jaeger_endpoint", "must not be empty when jaeger_enabled is set")
+	}
+	if c.Tracing.OTLPEnabled && c.Tracing.OTLPEndpoint == "" {
+		fail("tracing.otlp_endpoint", "must not be empty when otlp_enabled is set")
+	}
+
+	switch c.Registry.Type {
+	case "consul":
+		if c.Consul.Enabled && c.Consul.Address == "" {
+			fail("consul.address", "must not be empty when consul is enabled")
+		}
+	case "etcd":
+		if !c.Etcd.Enabled {
+			fail("registry.type", "etcd requires etcd.enabled")
+		}
+		if c.Etcd.LeaseTTLSeconds < 1 {
+			fail("etcd.lease_ttl_seconds", "must be at least 1, got %d", c.Etcd.LeaseTTLSeconds)
+		}
+	case "none":
+	default:
+		fail("registry.type", "must be consul, etcd or none, got %q", c.Registry.Type)
+	}
+	if c.Etcd.Enabled && len(c.Etcd.Endpoints) == 0 {
+		fail("etcd.endpoints", "must not be empty when etcd is enabled")
+	}
+
+	if c.Environment == EnvProd {
+		if len(c.JWT.Keys) == 0 && c.JWT.Secret == defaultJWTSecret {
+			fail("jwt.secret", "must not be the default in prod")
+		}
+		if c.Database.Password == defaultDatabasePassword {
+			fail("database.password", "must not be the default in prod")
+		}
+		if c.Database.SSLMode == "disable" {
+			fail("database.sslmode", "must not be disable in prod")
+		}
+		// An empty type selects the log notifier too.
+		if c.Notifier.Type == "" || c.Notifier.Type == "log" {
+			fail("notifier.type", "must not be log in prod, got %q", c.Notifier.Type)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// countTrue returns how many of values are true.
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
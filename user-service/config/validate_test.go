@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	base, err := LoadConfig("config.yaml")
+	if err != nil {
+		t.Fatalf("LoadConfig(config.yaml) error = %v", err)
+	}
+	strongSecret := strings.Repeat("k", minSecretLength)
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // keys of the expected problems, in order
+	}{
+		{
+			name:   "shipped config",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "ports",
+			modify: func(c *Config) {
+				c.Service.Port = 0
+				c.Service.HTTPPort = 70000
+				c.Metrics.Port = "metrics"
+			},
+			want: []string{"service.port", "service.http_port", "metrics.port"},
+		},
+		{
+			name: "same gRPC and HTTP port",
+			modify: func(c *Config) {
+				c.Service.HTTPPort = c.Service.Port
+			},
+			want: []string{"service.http_port"},
+		},
+		{
+			name: "empty DSN",
+			modify: func(c *Config) {
+				c.Database.Driver = ""
+				c.Database.Host = ""
+			},
+			want: []string{"database.driver", "database.host"},
+		},
+		{
+			name: "two tracing exporters",
+			modify: func(c *Config) {
+				c.Tracing.JaegerEnabled = true
+				c.Tracing.OTLPEnabled = true
+			},
+			want: []string{"tracing"},
+		},
+		{
+			// dev is not exempt.
+			name: "no tracing exporter",
+			modify: func(c *Config) {
+				c.Tracing.OTLPEnabled = false
+			},
+			want: []string{"tracing"},
+		},
+		{
+			name: "short secret in staging",
+			modify: func(c *Config) {
+				c.Environment = EnvStaging
+				c.JWT.Secret = "too-short"
+			},
+			want: []string{"jwt.secret"},
+		},
+		{
+			name: "short secret with signing keys",
+			modify: func(c *Config) {
+				c.Environment = EnvStaging
+				c.JWT.Secret = ""
+				c.JWT.Keys = []JWTKeyConfig{{ID: "k1"}}
+			},
+		},
+		{
+			name: "insecure defaults in prod",
+			modify: func(c *Config) {
+				c.Environment = EnvProd
+				c.JWT.Secret = defaultJWTSecret
+			},
+			want: []string{"jwt.secret", "jwt.secret", "database.password", "database.sslmode", "notifier.type"},
+		},
+		{
+			name: "hardened prod",
+			modify: func(c *Config) {
+				c.Environment = EnvProd
+				c.JWT.Secret = strongSecret
+				c.Database.Password = "db-password"
+				c.Database.SSLMode = "verify-full"
+				c.Notifier.Type = "file"
+				c.Notifier.FilePath = "/var/lib/user-service/notifications.jsonl"
+			},
+		},
+		{
+			name: "empty notifier type in prod",
+			modify: func(c *Config) {
+				c.Environment = EnvProd
+				c.JWT.Secret = strongSecret
+				c.Database.Password = "db-password"
+				c.Database.SSLMode = "verify-full"
+				c.Notifier.Type = ""
+			},
+			want: []string{"notifier.type"},
+		},
+		{
+			// An unknown environment gets the checks of staging.
+			name: "unknown environment and registry",
+			modify: func(c *Config) {
+				c.Environment = "production"
+				c.Registry.Type = "zookeeper"
+			},
+			want: []string{"environment", "jwt.secret", "registry.type"},
+		},
+		{
+			name: "etcd registry without etcd",
+			modify: func(c *Config) {
+				c.Registry.Type = "etcd"
+				c.Etcd.Enabled = false
+			},
+			want: []string{"registry.type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *base
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+			}
+			var got []string
+			for _, p := range verr.Problems {
+				key, _, _ := strings.Cut(p.Error(), ":")
+				got = append(got, key)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Validate() problems = %v, want %v", verr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	path := writeConfig(t, "service:\n  port: 0\nenvironment: prod\n")
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("LoadConfig() error = nil, want the validation problems")
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "invalid configuration:\n  - ") || !strings.Contains(msg, "service.port") || !strings.Contains(msg, "database.password") {
+		t.Errorf("LoadConfig() error = %q, want every problem listed", msg)
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain is the ErrorInfo domain of every error the service returns.
+const errorDomain = "user.gocampus"
+
+// grpcCodes maps service error codes to gRPC status codes.
+var grpcCodes = map[service.Code]codes.Code{
+	service.CodeInternal:           codes.Internal,
+	service.CodeInvalidArgument:    codes.InvalidArgument,
+	service.CodeNotFound:           codes.NotFound,
+	service.CodeAlreadyExists:      codes.AlreadyExists,
+	service.CodeUnauthenticated:    codes.Unauthenticated,
+	service.CodePermissionDenied:   codes.PermissionDenied,
+	service.CodeFailedPrecondition: codes.FailedPrecondition,
+	service.CodeAborted:            codes.Aborted,
+	service.CodeResourceExhausted:  codes.ResourceExhausted,
+	service.CodeUnavailable:        codes.Unavailable,
+}
+
+// statusError converts an error from the service into a gRPC status error.
+// The status carries an ErrorInfo with the stable reason, a BadRequest for
+// invalid fields, a RetryInfo when the client should back off, and a
+// LocalizedMessage in the caller's preferred language. Errors that are not
+// a *service.Error become Internal without revealing their text.
+func statusError(ctx context.Context, err error) error {
+	var se *service.Error
+	if !errors.As(err, &se) {
+		se = service.NewError(service.CodeInternal, service.ReasonInternal, "internal error")
+	}
+	code, ok := grpcCodes[se.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	locale := requestLocale(ctx)
+
+	st := status.New(code, se.Message)
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: se.Reason, Domain: errorDomain},
+		&errdetails.LocalizedMessage{Locale: locale, Message: localize(locale, se.Reason, se.Message)},
+	}
+	if len(se.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range se.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Reason:      f.Reason,
+				Description: f.Description,
+				LocalizedMessage: &errdetails.LocalizedMessage{
+					Locale:  locale,
+					Message: localize(locale, f.Reason, f.Description),
+				},
+			})
+		}
+		details = append(details, badRequest)
+	}
+	if se.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(se.RetryAfter)})
+	}
+	withDetails, derr := st.WithDetails(details...)
+	if derr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// requestLocale returns the supported locale that best matches the
+// accept-language metadata of the request, or defaultLocale.
+func requestLocale(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("accept-language") {
+		for _, tag := range strings.Split(header, ",") {
+			tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+			if locale, ok := matchLocale(tag); ok {
+				return locale
+			}
+		}
+	}
+	return defaultLocale
+}
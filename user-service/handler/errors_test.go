@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestStatusError(t *testing.T) {
+	zh := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "fr-FR, zh-CN;q=0.8"))
+
+	t.Run("Field violations", func(t *testing.T) {
+		err := statusError(zh, service.MissingFields("email is required", "email"))
+		st := checkStatus(t, err, codes.InvalidArgument, service.ReasonRequiredFieldMissing)
+		if st.Message() != "email is required" {
+			t.Errorf("Message() = %q", st.Message())
+		}
+		var localized *errdetails.LocalizedMessage
+		var badRequest *errdetails.BadRequest
+		for _, d := range st.Details() {
+			switch d := d.(type) {
+			case *errdetails.LocalizedMessage:
+				localized = d
+			case *errdetails.BadRequest:
+				badRequest = d
+			}
+		}
+		if localized == nil || localized.Locale != "zh-CN" || localized.Message != "缺少必填字段" {
+			t.Errorf("LocalizedMessage = %v", localized)
+		}
+		if badRequest == nil || len(badRequest.FieldViolations) != 1 {
+			t.Fatalf("BadRequest = %v", badRequest)
+		}
+		if v := badRequest.FieldViolations[0]; v.Field != "email" || v.LocalizedMessage.GetLocale() != "zh-CN" {
+			t.Errorf("FieldViolation = %v", v)
+		}
+	})
+
+	t.Run("Retry info", func(t *testing.T) {
+		blocked := service.NewError(service.CodeResourceExhausted, service.ReasonAccountLocked, "account locked")
+		blocked.RetryAfter = 90 * time.Second
+		st := checkStatus(t, statusError(context.Background(), blocked), codes.ResourceExhausted, service.ReasonAccountLocked)
+		var retry *errdetails.RetryInfo
+		for _, d := range st.Details() {
+			if r, ok := d.(*errdetails.RetryInfo); ok {
+				retry = r
+			}
+		}
+		if retry == nil || retry.RetryDelay.AsDuration() != 90*time.Second {
+			t.Errorf("RetryInfo = %v", retry)
+		}
+	})
+
+	t.Run("Untyped error", func(t *testing.T) {
+		st := checkStatus(t, statusError(zh, errors.New("pq: connection refused")), codes.Internal, service.ReasonInternal)
+		if st.Message() != "internal error" {
+			t.Errorf("Message() = %q, want the cause hidden", st.Message())
+		}
+	})
+}
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: "en-US"},
+		{header: "zh", want: "zh-CN"},
+		{header: "en-GB,zh-CN;q=0.5", want: "en-US"},
+		{header: "de-DE", want: "en-US"},
+	}
+	for _, tt := range tests {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", tt.header))
+		if got := requestLocale(ctx); got != tt.want {
+			t.Errorf("requestLocale(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
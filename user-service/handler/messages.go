@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+	"github.com/Tao-Zzzz/GoCampus/user-service/service"
+)
+
+// Supported locales of error messages. English messages come from the
+// service itself; other locales are looked up by reason in catalog.
+const (
+	defaultLocale = "en-US"
+	localeZhCN    = "zh-CN"
+)
+
+// catalog holds the translated error messages, keyed by locale and then by
+// error or field violation reason. Reasons without a translation fall back
+// to the English message.
+var catalog = map[string]map[string]string{
+	localeZhCN: {
+		service.ReasonInternal:                                         "服务内部错误，请稍后再试",
+		service.ReasonRequiredFieldMissing:                             "缺少必填字段",
+		service.ReasonInvalidEmail:                                     "邮箱地址无效",
+		service.ReasonEmailDomainNotAllowed:                            "该邮箱域名不允许注册",
+		service.ReasonInvalidNickname:                                  "昵称无效",
+		service.ReasonInvalidAvatar:                                    "头像地址无效",
+		service.ReasonPasswordPolicy:                                   "密码不符合安全要求",
+		service.ReasonPasswordUnchanged:                                "新密码不能与旧密码相同",
+		service.ReasonIncorrectPassword:                                "旧密码不正确",
+		service.ReasonFieldNotUpdatable:                                "该字段不允许修改",
+		service.ReasonUserNotFound:                                     "用户不存在",
+		service.ReasonUserAlreadyExists:                                "用户已存在",
+		service.ReasonVersionConflict:                                  "资料已被其他请求修改，请刷新后重试",
+		service.ReasonInvalidCredentials:                               "邮箱或密码错误",
+		service.ReasonEmailNotVerified:                                 "邮箱尚未验证",
+		service.ReasonUnauthenticated:                                  "请先登录",
+		service.ReasonPermissionDenied:                                 "没有权限执行此操作",
+		service.ReasonInvalidRefreshToken:                              "刷新令牌无效",
+		service.ReasonRefreshTokenExpired:                              "刷新令牌已过期，请重新登录",
+		service.ReasonRefreshTokenReused:                               "刷新令牌已被使用，请重新登录",
+		service.ReasonInvalidResetToken:                                "重置链接无效或已过期",
+		service.ReasonInvalidVerifyToken:                               "验证链接无效或已过期",
+		service.ReasonInvalidMFAToken:                                  "两步验证会话无效或已过期，请重新登录",
+		service.ReasonInvalidMFACode:                                   "验证码不正确",
+		service.ReasonMFAAlreadyEnabled:                                "两步验证已开启",
+		service.ReasonMFANotEnabled:                                    "两步验证未开启",
+		service.ReasonMFANotStarted:                                    "尚未开始设置两步验证",
+		service.ReasonMFARequired:                                      "该账号必须开启两步验证",
+		service.ReasonRoleNotFound:                                     "角色不存在",
+		service.ReasonRoleAlreadyGranted:                               "用户已拥有该角色",
+		service.ReasonRoleNotGranted:                                   "用户没有该角色",
+		service.ReasonSelfRevocation:                                   "不能撤销自己的角色",
+		service.ReasonLoginBackoff:                                     "登录失败次数过多，请稍后再试",
+		service.ReasonAccountLocked:                                    "账号已被暂时锁定，请稍后再试",
+		service.ReasonAddressLocked:                                    "该网络的登录失败次数过多，请稍后再试",
+		service.ReasonNoFailedLogins:                                   "没有登录失败记录",
+		service.ReasonLoginUnavailable:                                 "登录服务暂时不可用",
+		service.PasswordViolationReason(pwpolicy.CodeTooShort):         "密码太短",
+		service.PasswordViolationReason(pwpolicy.CodeTooLong):          "密码太长",
+		service.PasswordViolationReason(pwpolicy.CodeMissingLowercase): "密码必须包含小写字母",
+		service.PasswordViolationReason(pwpolicy.CodeMissingUppercase): "密码必须包含大写字母",
+		service.PasswordViolationReason(pwpolicy.CodeMissingDigit):     "密码必须包含数字",
+		service.PasswordViolationReason(pwpolicy.CodeMissingSymbol):    "密码必须包含符号",
+		service.PasswordViolationReason(pwpolicy.CodeContainsEmail):    "密码不能包含邮箱地址",
+		service.PasswordViolationReason(pwpolicy.CodeContainsNickname): "密码不能包含昵称",
+		service.PasswordViolationReason(pwpolicy.CodeBreached):         "该密码已在数据泄露中出现，请换一个",
+	},
+}
+
+// matchLocale returns the supported locale for a language tag such as
+// "zh-CN", "zh" or "en-GB".
+func matchLocale(tag string) (string, bool) {
+	lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	switch lang {
+	case "zh":
+		return localeZhCN, true
+	case "en":
+		return defaultLocale, true
+	}
+	return "", false
+}
+
+// localize returns the message for reason in locale, or fallback if there is
+// no translation.
+func localize(locale, reason, fallback string) string {
+	if msg, ok := catalog[locale][reason]; ok {
+		return msg
+	}
+	return fallback
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
     "context"
-    "errors"
     "time"
 
     "github.com/google/uuid"
@@ -11,11 +10,12 @@ import (
     "github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
     "github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
     "github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
-    "github.com/Tao-Zzzz/GoCampus/user-service/proto"
+    userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
     "github.com/Tao-Zzzz/GoCampus/user-service/service"
     "github.com/prometheus/client_golang/prometheus"
     "go.opentelemetry.io/otel"
     "go.opentelemetry.io/otel/attribute"
+    "google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // 包级指标，只注册一次，避免重复注册 panic
@@ -35,7 +35,7 @@ func init() {
 
 // UserHandler implements the gRPC UserServiceServer.
 type UserHandler struct {
-    proto.UnimplementedUserServiceServer
+    userv1.UnimplementedUserServiceServer
     userService *service.UserService
     logger      *logger.Logger
     metrics     *metrics.Metrics
@@ -43,8 +43,8 @@ type UserHandler struct {
 }
 
 // NewUserHandler creates a new UserHandler with dependencies.
-func NewUserHandler(repo service.UserRepository, cfg *config.Config, log *logger.Logger, met *metrics.Metrics) *UserHandler {
-    userService := service.NewUserService(repo, cfg, log, met)
+func NewUserHandler(repo service.UserRepository, cfg *config.Config, log *logger.Logger, met *metrics.Metrics, opts ...service.Option) *UserHandler {
+    userService := service.NewUserService(repo, cfg, log, met, opts...)
     return &UserHandler{
         userService: userService,
         logger:      log,
@@ -53,8 +53,14 @@ func NewUserHandler(repo service.UserRepository, cfg *config.Config, log *logger
     }
 }
 
+// ApplyConfig applies the settings of cfg that can change at runtime to the
+// underlying service. See service.UserService.ApplyConfig.
+func (h *UserHandler) ApplyConfig(cfg *config.Config) {
+    h.userService.ApplyConfig(cfg)
+}
+
 // RegisterUser handles user registration requests.
-func (h *UserHandler) RegisterUser(ctx context.Context, req *proto.RegisterUserRequest) (*proto.RegisterUserResponse, error) {
+func (h *UserHandler) RegisterUser(ctx context.Context, req *userv1.RegisterUserRequest) (*userv1.RegisterUserResponse, error) {
     tracer := otel.Tracer("user-service")
     ctx, span := tracer.Start(ctx, "UserHandler.RegisterUser")
     defer span.End()
@@ -68,22 +74,14 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *proto.RegisterUserR
 
     h.logger.Info(ctx).Msgf("Received RegisterUser request for email: %s", req.Email)
 
-    // 输入校验
-    if req.Email == "" || req.Password == "" || req.Nickname == "" {
-        err := errors.New("email, password, nickname are required")
-        h.logger.Warn(ctx).Err(err).Msg("Invalid register input")
-        h.metrics.RequestDuration().WithLabelValues("RegisterUser", "error").Observe(time.Since(start).Seconds())
-        requestCounter.WithLabelValues("RegisterUser", "error").Inc()
-        span.RecordError(err)
-        return nil, err
-    }
-
+    // 输入校验由 service 完成，缺失的字段会逐一列出
     user := &model.User{
-        ID:       uuid.New().String(),
-        Email:    req.Email,
-        Password: req.Password,
-        Nickname: req.Nickname,
-        Avatar:   req.Avatar,
+        ID:        uuid.New().String(),
+        Email:     req.Email,
+        Password:  req.Password,
+        Nickname:  req.Nickname,
+        Avatar:    req.Avatar,
+        CreatedAt: time.Now().UTC(),
     }
 
     userID, err := h.userService.Register(ctx, user)
@@ -92,16 +90,20 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *proto.RegisterUserR
         h.metrics.RequestDuration().WithLabelValues("RegisterUser", "error").Observe(time.Since(start).Seconds())
         requestCounter.WithLabelValues("RegisterUser", "error").Inc()
         span.RecordError(err)
-        return nil, err
+        return nil, statusError(ctx, err)
     }
 
     h.logger.Info(ctx).Msgf("User registered: %s", userID)
     span.SetAttributes(attribute.String("user_id", userID))
-    return &proto.RegisterUserResponse{UserId: userID}, nil
+    return &userv1.RegisterUserResponse{
+        Success: true,
+        Message: "User registered successfully",
+        UserId:  userID,
+    }, nil
 }
 
 // Login handles user login requests.
-func (h *UserHandler) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
+func (h *UserHandler) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
     tracer := otel.Tracer("user-service")
     ctx, span := tracer.Start(ctx, "UserHandler.Login")
     defer span.End()
@@ -116,29 +118,47 @@ func (h *UserHandler) Login(ctx context.Context, req *proto.LoginRequest) (*prot
     h.logger.Info(ctx).Msgf("Received Login request for email: %s", req.Email)
 
     if req.Email == "" || req.Password == "" {
-        err := errors.New("email and password are required")
+        err := service.MissingFields("email and password are required", "email", "password")
         h.logger.Warn(ctx).Err(err).Msg("Invalid login input")
         h.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
         requestCounter.WithLabelValues("Login", "error").Inc()
         span.RecordError(err)
-        return nil, err
+        return nil, statusError(ctx, err)
     }
 
-    token, err := h.userService.Login(ctx, req.Email, req.Password)
+    tokens, err := h.userService.Login(ctx, req.Email, req.Password)
     if err != nil {
         h.logger.Error(ctx).Err(err).Msg("Failed to login user")
         h.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
         requestCounter.WithLabelValues("Login", "error").Inc()
         span.RecordError(err)
-        return nil, err
+        return nil, statusError(ctx, err)
+    }
+
+    if tokens.MFAToken != "" {
+        h.logger.Info(ctx).Msg("Second factor required to complete login")
+        return &userv1.LoginResponse{
+            Success:      true,
+            Message:      "Two-factor authentication required",
+            MfaRequired:  true,
+            MfaToken:     tokens.MFAToken,
+            MfaExpiresIn: int64(tokens.MFAExpiresIn.Seconds()),
+        }, nil
     }
 
     h.logger.Info(ctx).Msg("User logged in successfully")
-    return &proto.LoginResponse{Token: token}, nil
+    return &userv1.LoginResponse{
+        Success:               true,
+        Message:               "Login successful",
+        Token:                 tokens.AccessToken,
+        RefreshToken:          tokens.RefreshToken,
+        ExpiresIn:             int64(tokens.ExpiresIn.Seconds()),
+        MfaEnrollmentRequired: tokens.MFAEnrollmentRequired,
+    }, nil
 }
 
-// GetUserInfo handles user info requests with JWT authentication.
-func (h *UserHandler) GetUserInfo(ctx context.Context, req *proto.GetUserInfoRequest) (*proto.GetUserInfoResponse, error) {
+// GetUserInfo handles user info requests.
+func (h *UserHandler) GetUserInfo(ctx context.Context, req *userv1.GetUserInfoRequest) (*userv1.GetUserInfoResponse, error) {
     tracer := otel.Tracer("user-service")
     ctx, span := tracer.Start(ctx, "UserHandler.GetUserInfo")
     defer span.End()
@@ -152,14 +172,19 @@ func (h *UserHandler) GetUserInfo(ctx context.Context, req *proto.GetUserInfoReq
 
     h.logger.Info(ctx).Msg("Received GetUserInfo request")
 
-    // Validate JWT token
-    userID, err := jwt.ValidateToken(req.Token, h.cfg.JWT.Secret)
-    if err != nil {
-        h.logger.Error(ctx).Err(err).Msg("Invalid JWT token")
-        h.metrics.RequestDuration().WithLabelValues("GetUserInfo", "error").Observe(time.Since(start).Seconds())
-        requestCounter.WithLabelValues("GetUserInfo", "error").Inc()
-        span.RecordError(err)
-        return nil, errors.New("invalid token")
+    // Authenticated callers may only read their own profile.
+    userID := req.UserId
+    if authID, ok := jwt.UserIDFromContext(ctx); ok {
+        if userID == "" {
+            userID = authID
+        } else if userID != authID {
+            err := service.NewError(service.CodePermissionDenied, service.ReasonPermissionDenied, "permission denied")
+            h.logger.Warn(ctx).Msgf("User %s attempted to read user %s", authID, userID)
+            h.metrics.RequestDuration().WithLabelValues("GetUserInfo", "error").Observe(time.Since(start).Seconds())
+            requestCounter.WithLabelValues("GetUserInfo", "error").Inc()
+            span.RecordError(err)
+            return nil, statusError(ctx, err)
+        }
     }
 
     user, err := h.userService.GetUserInfo(ctx, userID)
@@ -168,15 +193,614 @@ func (h *UserHandler) GetUserInfo(ctx context.Context, req *proto.GetUserInfoReq
         h.metrics.RequestDuration().WithLabelValues("GetUserInfo", "error").Observe(time.Since(start).Seconds())
         requestCounter.WithLabelValues("GetUserInfo", "error").Inc()
         span.RecordError(err)
-        return nil, err
+        return nil, statusError(ctx, err)
+    }
+
+    h.logger.Info(ctx).Msgf("User info retrieved for ID: %s", user.ID)
+    span.SetAttributes(attribute.String("user_id", user.ID))
+    return &userv1.GetUserInfoResponse{
+        Success: true,
+        Message: "User info retrieved successfully",
+        User:    toProtoUser(user),
+    }, nil
+}
+
+// UpdateUser handles partial profile updates for the authenticated user.
+func (h *UserHandler) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.UpdateUserResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.UpdateUser")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("UpdateUser", "success").Observe(duration)
+        requestCounter.WithLabelValues("UpdateUser", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received UpdateUser request")
+
+    if req.User == nil {
+        err := service.MissingFields("user is required", "user")
+        h.logger.Warn(ctx).Err(err).Msg("Invalid update input")
+        h.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("UpdateUser", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    // Authenticated callers may only update their own profile.
+    userID := req.User.UserId
+    if authID, ok := jwt.UserIDFromContext(ctx); ok {
+        if userID == "" {
+            userID = authID
+        } else if userID != authID {
+            err := service.NewError(service.CodePermissionDenied, service.ReasonPermissionDenied, "permission denied")
+            h.logger.Warn(ctx).Msgf("User %s attempted to update user %s", authID, userID)
+            h.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+            requestCounter.WithLabelValues("UpdateUser", "error").Inc()
+            span.RecordError(err)
+            return nil, statusError(ctx, err)
+        }
+    }
+
+    changes := &model.User{
+        ID:       userID,
+        Nickname: req.User.Nickname,
+        Avatar:   req.User.Avatar,
+        Version:  req.User.Version,
+    }
+    user, err := h.userService.UpdateUser(ctx, changes, req.UpdateMask.GetPaths())
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to update user")
+        h.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("UpdateUser", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    h.logger.Info(ctx).Msgf("User updated: %s", user.ID)
+    span.SetAttributes(attribute.String("user_id", user.ID))
+    return &userv1.UpdateUserResponse{
+        Success: true,
+        Message: "User updated successfully",
+        User:    toProtoUser(user),
+    }, nil
+}
+
+// RefreshToken handles refresh token rotation requests.
+func (h *UserHandler) RefreshToken(ctx context.Context, req *userv1.RefreshTokenRequest) (*userv1.RefreshTokenResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.RefreshToken")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("RefreshToken", "success").Observe(duration)
+        requestCounter.WithLabelValues("RefreshToken", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received RefreshToken request")
+
+    tokens, err := h.userService.RefreshToken(ctx, req.RefreshToken)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to refresh token")
+        h.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("RefreshToken", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    h.logger.Info(ctx).Msg("Token refreshed successfully")
+    return &userv1.RefreshTokenResponse{
+        Success:               true,
+        Message:               "Token refreshed successfully",
+        Token:                 tokens.AccessToken,
+        RefreshToken:          tokens.RefreshToken,
+        ExpiresIn:             int64(tokens.ExpiresIn.Seconds()),
+        MfaEnrollmentRequired: tokens.MFAEnrollmentRequired,
+    }, nil
+}
+
+// Logout handles logout requests for the authenticated user.
+func (h *UserHandler) Logout(ctx context.Context, req *userv1.LogoutRequest) (*userv1.LogoutResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.Logout")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("Logout", "success").Observe(duration)
+        requestCounter.WithLabelValues("Logout", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received Logout request")
+
+    claims, _ := jwt.ClaimsFromContext(ctx)
+    if err := h.userService.Logout(ctx, claims, req.RefreshToken); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to logout")
+        h.metrics.RequestDuration().WithLabelValues("Logout", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("Logout", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.LogoutResponse{Success: true, Message: "Logged out successfully"}, nil
+}
+
+// RevokeAllSessions handles requests to revoke every session of the authenticated user.
+func (h *UserHandler) RevokeAllSessions(ctx context.Context, req *userv1.RevokeAllSessionsRequest) (*userv1.RevokeAllSessionsResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.RevokeAllSessions")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "success").Observe(duration)
+        requestCounter.WithLabelValues("RevokeAllSessions", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received RevokeAllSessions request")
+
+    userID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.RevokeAllSessions(ctx, userID); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to revoke sessions")
+        h.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("RevokeAllSessions", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", userID))
+    return &userv1.RevokeAllSessionsResponse{Success: true, Message: "All sessions revoked"}, nil
+}
+
+// ChangePassword handles password changes for the authenticated user.
+func (h *UserHandler) ChangePassword(ctx context.Context, req *userv1.ChangePasswordRequest) (*userv1.ChangePasswordResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.ChangePassword")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("ChangePassword", "success").Observe(duration)
+        requestCounter.WithLabelValues("ChangePassword", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received ChangePassword request")
+
+    userID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.ChangePassword(ctx, userID, req.OldPassword, req.NewPassword); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to change password")
+        h.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("ChangePassword", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", userID))
+    return &userv1.ChangePasswordResponse{Success: true, Message: "Password changed successfully"}, nil
+}
+
+// RequestPasswordReset handles requests for a password reset token.
+func (h *UserHandler) RequestPasswordReset(ctx context.Context, req *userv1.RequestPasswordResetRequest) (*userv1.RequestPasswordResetResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.RequestPasswordReset")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "success").Observe(duration)
+        requestCounter.WithLabelValues("RequestPasswordReset", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received RequestPasswordReset request")
+
+    if err := h.userService.RequestPasswordReset(ctx, req.Email); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to request password reset")
+        h.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("RequestPasswordReset", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.RequestPasswordResetResponse{
+        Success: true,
+        Message: "If the email is registered, a reset token has been sent",
+    }, nil
+}
+
+// ConfirmPasswordReset handles password resets with a reset token.
+func (h *UserHandler) ConfirmPasswordReset(ctx context.Context, req *userv1.ConfirmPasswordResetRequest) (*userv1.ConfirmPasswordResetResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.ConfirmPasswordReset")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "success").Observe(duration)
+        requestCounter.WithLabelValues("ConfirmPasswordReset", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received ConfirmPasswordReset request")
+
+    if err := h.userService.ConfirmPasswordReset(ctx, req.Token, req.NewPassword); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to reset password")
+        h.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("ConfirmPasswordReset", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.ConfirmPasswordResetResponse{Success: true, Message: "Password reset successfully"}, nil
+}
+
+// VerifyEmail handles email verification requests.
+func (h *UserHandler) VerifyEmail(ctx context.Context, req *userv1.VerifyEmailRequest) (*userv1.VerifyEmailResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.VerifyEmail")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("VerifyEmail", "success").Observe(duration)
+        requestCounter.WithLabelValues("VerifyEmail", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received VerifyEmail request")
+
+    if err := h.userService.VerifyEmail(ctx, req.Token); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to verify email")
+        h.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("VerifyEmail", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.VerifyEmailResponse{Success: true, Message: "Email verified successfully"}, nil
+}
+
+// ResendVerificationEmail handles requests for a new verification token.
+func (h *UserHandler) ResendVerificationEmail(ctx context.Context, req *userv1.ResendVerificationEmailRequest) (*userv1.ResendVerificationEmailResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.ResendVerificationEmail")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("ResendVerificationEmail", "success").Observe(duration)
+        requestCounter.WithLabelValues("ResendVerificationEmail", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received ResendVerificationEmail request")
+
+    if err := h.userService.ResendVerificationEmail(ctx, req.Email); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to resend verification email")
+        h.metrics.RequestDuration().WithLabelValues("ResendVerificationEmail", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("ResendVerificationEmail", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.ResendVerificationEmailResponse{
+        Success: true,
+        Message: "If the email is registered and unverified, a verification token has been sent",
+    }, nil
+}
+
+// GrantRole handles admin requests to grant a role to a user.
+func (h *UserHandler) GrantRole(ctx context.Context, req *userv1.GrantRoleRequest) (*userv1.GrantRoleResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.GrantRole")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("GrantRole", "success").Observe(duration)
+        requestCounter.WithLabelValues("GrantRole", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received GrantRole request")
+
+    actorID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.GrantRole(ctx, actorID, req.UserId, req.Role); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to grant role")
+        h.metrics.RequestDuration().WithLabelValues("GrantRole", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("GrantRole", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", req.UserId), attribute.String("role", req.Role))
+    return &userv1.GrantRoleResponse{Success: true, Message: "Role granted successfully"}, nil
+}
+
+// RevokeRole handles admin requests to revoke a role from a user.
+func (h *UserHandler) RevokeRole(ctx context.Context, req *userv1.RevokeRoleRequest) (*userv1.RevokeRoleResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.RevokeRole")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("RevokeRole", "success").Observe(duration)
+        requestCounter.WithLabelValues("RevokeRole", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received RevokeRole request")
+
+    actorID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.RevokeRole(ctx, actorID, req.UserId, req.Role); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to revoke role")
+        h.metrics.RequestDuration().WithLabelValues("RevokeRole", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("RevokeRole", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", req.UserId), attribute.String("role", req.Role))
+    return &userv1.RevokeRoleResponse{Success: true, Message: "Role revoked successfully"}, nil
+}
+
+// ListUserRoles handles requests to list the roles of a user.
+func (h *UserHandler) ListUserRoles(ctx context.Context, req *userv1.ListUserRolesRequest) (*userv1.ListUserRolesResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.ListUserRoles")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("ListUserRoles", "success").Observe(duration)
+        requestCounter.WithLabelValues("ListUserRoles", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received ListUserRoles request")
+
+    roles, err := h.userService.GetUserRoles(ctx, req.UserId)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to list user roles")
+        h.metrics.RequestDuration().WithLabelValues("ListUserRoles", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("ListUserRoles", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", req.UserId))
+    return &userv1.ListUserRolesResponse{Success: true, Message: "User roles retrieved successfully", Roles: roles}, nil
+}
+
+// VerifyMFA handles the second step of a login.
+func (h *UserHandler) VerifyMFA(ctx context.Context, req *userv1.VerifyMFARequest) (*userv1.VerifyMFAResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.VerifyMFA")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("VerifyMFA", "success").Observe(duration)
+        requestCounter.WithLabelValues("VerifyMFA", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received VerifyMFA request")
+
+    tokens, err := h.userService.VerifyMFA(ctx, req.MfaToken, req.Code)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to verify second factor")
+        h.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("VerifyMFA", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.VerifyMFAResponse{
+        Success:      true,
+        Message:      "Login successful",
+        Token:        tokens.AccessToken,
+        RefreshToken: tokens.RefreshToken,
+        ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+    }, nil
+}
+
+// EnrollMFA handles requests to start TOTP enrollment.
+func (h *UserHandler) EnrollMFA(ctx context.Context, req *userv1.EnrollMFARequest) (*userv1.EnrollMFAResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.EnrollMFA")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("EnrollMFA", "success").Observe(duration)
+        requestCounter.WithLabelValues("EnrollMFA", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received EnrollMFA request")
+
+    userID, _ := jwt.UserIDFromContext(ctx)
+    enrollment, err := h.userService.EnrollMFA(ctx, userID)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to start MFA enrollment")
+        h.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("EnrollMFA", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", userID))
+    return &userv1.EnrollMFAResponse{
+        Success:         true,
+        Message:         "Scan the provisioning URI and confirm with a code",
+        Secret:          enrollment.Secret,
+        ProvisioningUri: enrollment.ProvisioningURI,
+    }, nil
+}
+
+// ConfirmMFA handles requests to enable a pending TOTP enrollment.
+func (h *UserHandler) ConfirmMFA(ctx context.Context, req *userv1.ConfirmMFARequest) (*userv1.ConfirmMFAResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.ConfirmMFA")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "success").Observe(duration)
+        requestCounter.WithLabelValues("ConfirmMFA", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received ConfirmMFA request")
+
+    userID, _ := jwt.UserIDFromContext(ctx)
+    codes, err := h.userService.ConfirmMFA(ctx, userID, req.Code)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to confirm MFA enrollment")
+        h.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("ConfirmMFA", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
     }
 
-    h.logger.Info(ctx).Msgf("User info retrieved for ID: %s", userID)
     span.SetAttributes(attribute.String("user_id", userID))
-    return &proto.GetUserInfoResponse{
-        UserId:   user.ID,
-        Email:    user.Email,
-        Nickname: user.Nickname,
-        Avatar:   user.Avatar,
+    return &userv1.ConfirmMFAResponse{
+        Success:       true,
+        Message:       "Two-factor authentication enabled",
+        RecoveryCodes: codes,
     }, nil
-}
\ No newline at end of file
+}
+
+// DisableMFA handles requests to remove the caller's second factor.
+func (h *UserHandler) DisableMFA(ctx context.Context, req *userv1.DisableMFARequest) (*userv1.DisableMFAResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.DisableMFA")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("DisableMFA", "success").Observe(duration)
+        requestCounter.WithLabelValues("DisableMFA", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received DisableMFA request")
+
+    userID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.DisableMFA(ctx, userID, req.Code); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to disable MFA")
+        h.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("DisableMFA", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("user_id", userID))
+    return &userv1.DisableMFAResponse{Success: true, Message: "Two-factor authentication disabled"}, nil
+}
+
+// GetLockoutStatus handles requests for the failed-login state of an account or IP.
+func (h *UserHandler) GetLockoutStatus(ctx context.Context, req *userv1.GetLockoutStatusRequest) (*userv1.GetLockoutStatusResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.GetLockoutStatus")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "success").Observe(duration)
+        requestCounter.WithLabelValues("GetLockoutStatus", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received GetLockoutStatus request")
+
+    status, err := h.userService.GetLockoutStatus(ctx, req.UserId, req.Email, req.Ip)
+    if err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to get lockout status")
+        h.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("GetLockoutStatus", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    return &userv1.GetLockoutStatusResponse{
+        Success: true,
+        Message: "Lockout status retrieved successfully",
+        Account: toProtoLockoutState(status.Account),
+        Ip:      toProtoLockoutState(status.IP),
+    }, nil
+}
+
+// UnlockAccount handles requests to clear the failed logins of an account or IP.
+func (h *UserHandler) UnlockAccount(ctx context.Context, req *userv1.UnlockAccountRequest) (*userv1.UnlockAccountResponse, error) {
+    tracer := otel.Tracer("user-service")
+    ctx, span := tracer.Start(ctx, "UserHandler.UnlockAccount")
+    defer span.End()
+
+    start := time.Now()
+    defer func() {
+        duration := time.Since(start).Seconds()
+        h.metrics.RequestDuration().WithLabelValues("UnlockAccount", "success").Observe(duration)
+        requestCounter.WithLabelValues("UnlockAccount", "success").Inc()
+    }()
+
+    h.logger.Info(ctx).Msg("Received UnlockAccount request")
+
+    actorID, _ := jwt.UserIDFromContext(ctx)
+    if err := h.userService.UnlockAccount(ctx, actorID, req.UserId, req.Email, req.Ip); err != nil {
+        h.logger.Error(ctx).Err(err).Msg("Failed to unlock account")
+        h.metrics.RequestDuration().WithLabelValues("UnlockAccount", "error").Observe(time.Since(start).Seconds())
+        requestCounter.WithLabelValues("UnlockAccount", "error").Inc()
+        span.RecordError(err)
+        return nil, statusError(ctx, err)
+    }
+
+    span.SetAttributes(attribute.String("actor_id", actorID))
+    return &userv1.UnlockAccountResponse{Success: true, Message: "Unlocked successfully"}, nil
+}
+
+// toProtoLockoutState converts a login throttle to its API representation.
+func toProtoLockoutState(throttle *model.LoginThrottle) *userv1.LockoutState {
+    if throttle == nil {
+        return nil
+    }
+    state := &userv1.LockoutState{
+        Failures:      int32(throttle.Failures),
+        Blocked:       throttle.Blocked(time.Now()),
+        Locked:        throttle.Locked,
+        LastFailureAt: timestamppb.New(throttle.LastFailureAt),
+    }
+    if throttle.BlockedUntil != nil {
+        state.BlockedUntil = timestamppb.New(*throttle.BlockedUntil)
+    }
+    return state
+}
+
+// toProtoUser converts a user to its public representation.
+func toProtoUser(user *model.User) *userv1.UserInfo {
+    info := &userv1.UserInfo{
+        UserId:        user.ID,
+        Email:         user.Email,
+        Nickname:      user.Nickname,
+        Avatar:        user.Avatar,
+        Version:       user.Version,
+        UpdatedAt:     timestamppb.New(user.UpdatedAt),
+        EmailVerified: user.EmailVerified,
+    }
+    if user.InstitutionID != "" {
+        info.Affiliation = &userv1.Affiliation{
+            InstitutionId: user.InstitutionID,
+            Role:          user.Role,
+        }
+    }
+    return info
+}
@@ -3,25 +3,63 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
-	"github.com/Tao-Zzzz/GoCampus/user-service/proto"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
 	"github.com/Tao-Zzzz/GoCampus/user-service/service"
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 // MockUserRepository for testing the service layer.
 type MockUserRepository struct {
-	CreateUserFunc    func(ctx context.Context, user *model.User) (string, error)
-	GetUserByEmailFunc func(ctx context.Context, email string) (*model.User, error)
-	GetUserByIDFunc   func(ctx context.Context, id string) (*model.User, error)
+	CreateUserFunc                      func(ctx context.Context, user *model.User) (string, error)
+	GetUserByEmailFunc                  func(ctx context.Context, email string) (*model.User, error)
+	GetUserByIDFunc                     func(ctx context.Context, id string) (*model.User, error)
+	UpdateUserFunc                      func(ctx context.Context, user *model.User) error
+	CreateRefreshTokenFunc              func(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHashFunc           func(ctx context.Context, hash string) (*model.RefreshToken, error)
+	RotateRefreshTokenFunc              func(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamilyFunc        func(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokensFunc         func(ctx context.Context, userID string) error
+	UpdatePasswordFunc                  func(ctx context.Context, userID, passwordHash string) error
+	RehashPasswordFunc                  func(ctx context.Context, userID, oldHash, newHash string) (bool, error)
+	CreatePasswordResetTokenFunc        func(ctx context.Context, token *model.PasswordResetToken) error
+	GetPasswordResetTokenByHashFunc     func(ctx context.Context, hash string) (*model.PasswordResetToken, error)
+	ResetPasswordFunc                   func(ctx context.Context, tokenID, userID, passwordHash string) (bool, error)
+	CreateEmailVerificationTokenFunc    func(ctx context.Context, token *model.EmailVerificationToken) error
+	GetEmailVerificationTokenByHashFunc func(ctx context.Context, hash string) (*model.EmailVerificationToken, error)
+	VerifyEmailFunc                     func(ctx context.Context, tokenID, userID string) (bool, error)
+	GetUserRolesFunc                    func(ctx context.Context, userID string) ([]string, error)
+	GrantRoleFunc                       func(ctx context.Context, userID, role, grantedBy string) (bool, error)
+	RevokeRoleFunc                      func(ctx context.Context, userID, role string) (bool, error)
+	GetPermissionsForRolesFunc          func(ctx context.Context, roles []string) ([]string, error)
+	GetMFASettingsFunc                  func(ctx context.Context, userID string) (*model.MFASettings, error)
+	SaveMFASecretFunc                   func(ctx context.Context, userID, secret string) (bool, error)
+	EnableMFAFunc                       func(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error)
+	DisableMFAFunc                      func(ctx context.Context, userID string) error
+	UpdateMFALastStepFunc               func(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCodeFunc                 func(ctx context.Context, userID, codeHash string) (bool, error)
+	CreateMFAChallengeFunc              func(ctx context.Context, challenge *model.MFAChallenge) error
+	GetMFAChallengeByHashFunc           func(ctx context.Context, hash string) (*model.MFAChallenge, error)
+	RecordMFAChallengeFailureFunc       func(ctx context.Context, id string) error
+	RedeemMFAChallengeFunc              func(ctx context.Context, id string) (bool, error)
+	GetLoginThrottleFunc                func(ctx context.Context, key string) (*model.LoginThrottle, error)
+	RecordLoginFailureFunc              func(ctx context.Context, key string, now, windowStart time.Time) (int, error)
+	BlockLoginFunc                      func(ctx context.Context, key string, until time.Time, locked bool) error
+	ClearLoginThrottleFunc              func(ctx context.Context, key string) (bool, error)
 }
 
 func (m *MockUserRepository) CreateUser(ctx context.Context, user *model.User) (string, error) {
@@ -36,8 +74,166 @@ func (m *MockUserRepository) GetUserByID(ctx context.Context, id string) (*model
 	return m.GetUserByIDFunc(ctx, id)
 }
 
+func (m *MockUserRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	return m.UpdateUserFunc(ctx, user)
+}
+
+func (m *MockUserRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return m.CreateRefreshTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	return m.GetRefreshTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) RotateRefreshToken(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error) {
+	return m.RotateRefreshTokenFunc(ctx, oldID, next)
+}
+
+func (m *MockUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return m.RevokeRefreshTokenFamilyFunc(ctx, familyID)
+}
+
+func (m *MockUserRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	return m.RevokeUserRefreshTokensFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	return m.UpdatePasswordFunc(ctx, userID, passwordHash)
+}
+
+func (m *MockUserRepository) RehashPassword(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+	return m.RehashPasswordFunc(ctx, userID, oldHash, newHash)
+}
+
+func (m *MockUserRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	return m.CreatePasswordResetTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+	return m.GetPasswordResetTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) ResetPassword(ctx context.Context, tokenID, userID, passwordHash string) (bool, error) {
+	return m.ResetPasswordFunc(ctx, tokenID, userID, passwordHash)
+}
+
+func (m *MockUserRepository) CreateEmailVerificationToken(ctx context.Context, token *model.EmailVerificationToken) error {
+	return m.CreateEmailVerificationTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*model.EmailVerificationToken, error) {
+	return m.GetEmailVerificationTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, tokenID, userID string) (bool, error) {
+	return m.VerifyEmailFunc(ctx, tokenID, userID)
+}
+
+func (m *MockUserRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	return m.GetUserRolesFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) GrantRole(ctx context.Context, userID, role, grantedBy string) (bool, error) {
+	return m.GrantRoleFunc(ctx, userID, role, grantedBy)
+}
+
+func (m *MockUserRepository) RevokeRole(ctx context.Context, userID, role string) (bool, error) {
+	return m.RevokeRoleFunc(ctx, userID, role)
+}
+
+func (m *MockUserRepository) GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	return m.GetPermissionsForRolesFunc(ctx, roles)
+}
+
+func (m *MockUserRepository) GetMFASettings(ctx context.Context, userID string) (*model.MFASettings, error) {
+	return m.GetMFASettingsFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) SaveMFASecret(ctx context.Context, userID, secret string) (bool, error) {
+	return m.SaveMFASecretFunc(ctx, userID, secret)
+}
+
+func (m *MockUserRepository) EnableMFA(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error) {
+	return m.EnableMFAFunc(ctx, userID, step, recoveryCodeHashes)
+}
+
+func (m *MockUserRepository) DisableMFA(ctx context.Context, userID string) error {
+	return m.DisableMFAFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) UpdateMFALastStep(ctx context.Context, userID string, step int64) (bool, error) {
+	return m.UpdateMFALastStepFunc(ctx, userID, step)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	return m.UseRecoveryCodeFunc(ctx, userID, codeHash)
+}
+
+func (m *MockUserRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	return m.CreateMFAChallengeFunc(ctx, challenge)
+}
+
+func (m *MockUserRepository) GetMFAChallengeByHash(ctx context.Context, hash string) (*model.MFAChallenge, error) {
+	return m.GetMFAChallengeByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) RecordMFAChallengeFailure(ctx context.Context, id string) error {
+	return m.RecordMFAChallengeFailureFunc(ctx, id)
+}
+
+func (m *MockUserRepository) RedeemMFAChallenge(ctx context.Context, id string) (bool, error) {
+	return m.RedeemMFAChallengeFunc(ctx, id)
+}
+
+func (m *MockUserRepository) GetLoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	return m.GetLoginThrottleFunc(ctx, key)
+}
+
+func (m *MockUserRepository) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	return m.RecordLoginFailureFunc(ctx, key, now, windowStart)
+}
+
+func (m *MockUserRepository) BlockLogin(ctx context.Context, key string, until time.Time, locked bool) error {
+	return m.BlockLoginFunc(ctx, key, until, locked)
+}
+
+func (m *MockUserRepository) ClearLoginThrottle(ctx context.Context, key string) (bool, error) {
+	return m.ClearLoginThrottleFunc(ctx, key)
+}
+
+// checkStatus fails the test unless err is a status error with code and an
+// ErrorInfo carrying reason.
+func checkStatus(t *testing.T, err error, code codes.Code, reason string) *status.Status {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		t.Fatalf("error = %v, want code %v", err, code)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Reason == reason {
+			return st
+		}
+	}
+	t.Fatalf("error %v has no ErrorInfo with reason %s", err, reason)
+	return nil
+}
+
+// fieldViolations returns the field and reason of each BadRequest field
+// violation of st.
+func fieldViolations(st *status.Status) []string {
+	var out []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				out = append(out, v.Field+":"+v.Reason)
+			}
+		}
+	}
+	return out
+}
+
 func TestUserHandler_RegisterUser(t *testing.T) {
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	mockRepo := &MockUserRepository{
 		CreateUserFunc: func(ctx context.Context, user *model.User) (string, error) {
 			return user.ID, nil
@@ -45,6 +241,9 @@ func TestUserHandler_RegisterUser(t *testing.T) {
 		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
 			return nil, errors.New("user not found")
 		},
+		CreateEmailVerificationTokenFunc: func(ctx context.Context, token *model.EmailVerificationToken) error {
+			return nil
+		},
 	}
 	cfg := &config.Config{
 		JWT: config.JWTConfig{
@@ -56,58 +255,79 @@ func TestUserHandler_RegisterUser(t *testing.T) {
 			Port:     8080,
 			LogLevel: "debug",
 		},
+		PasswordPolicy: config.PasswordPolicyConfig{MinLength: 8, RequireDigit: true},
 	}
 	log := logger.NewLogger(cfg)
 	met := metrics.NewMetrics(cfg)
 	handler := NewUserHandler(mockRepo, cfg, log, met)
 
 	tests := []struct {
-		name     string
-		req      *proto.RegisterRequest
-		wantResp *proto.RegisterResponse
+		name           string
+		req            *userv1.RegisterUserRequest
+		wantResp       *userv1.RegisterUserResponse
+		wantCode       codes.Code
+		wantReason     string
+		wantViolations []string
 	}{
 		{
 			name: "Successful registration",
-			req: &proto.RegisterRequest{
+			req: &userv1.RegisterUserRequest{
 				Email:    "test@example.com",
 				Password: "password123",
 				Nickname: "TestUser",
 				Avatar:   "http://example.com/avatar.png",
 			},
-			wantResp: &proto.RegisterResponse{
+			wantResp: &userv1.RegisterUserResponse{
 				Success: true,
 				Message: "User registered successfully",
 			},
 		},
 		{
 			name: "Invalid input",
-			req: &proto.RegisterRequest{
+			req: &userv1.RegisterUserRequest{
 				Email:    "",
 				Password: "password123",
 				Nickname: "TestUser",
 				Avatar:   "http://example.com/avatar.png",
 			},
-			wantResp: &proto.RegisterResponse{
-				Success: false,
-				Message: "email, password, and nickname are required",
+			wantCode:       codes.InvalidArgument,
+			wantReason:     service.ReasonRequiredFieldMissing,
+			wantViolations: []string{"email:REQUIRED_FIELD_MISSING"},
+		},
+		{
+			name: "Rejected password",
+			req: &userv1.RegisterUserRequest{
+				Email:    "test@example.com",
+				Password: "secret",
+				Nickname: "TestUser",
 			},
+			wantCode:       codes.InvalidArgument,
+			wantReason:     service.ReasonPasswordPolicy,
+			wantViolations: []string{"password:PASSWORD_TOO_SHORT", "password:PASSWORD_MISSING_DIGIT"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			resp, err := handler.RegisterUser(context.Background(), tt.req)
+			if tt.wantResp == nil {
+				st := checkStatus(t, err, tt.wantCode, tt.wantReason)
+				if got := fieldViolations(st); !reflect.DeepEqual(got, tt.wantViolations) {
+					t.Errorf("RegisterUser() violations = %v, want %v", got, tt.wantViolations)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatalf("RegisterUser() error = %v", err)
 			}
 			if resp.Success != tt.wantResp.Success || resp.Message != tt.wantResp.Message {
 				t.Errorf("RegisterUser() = %+v, want %+v", resp, tt.wantResp)
 			}
-			if resp.Success && resp.UserId == "" {
+			if resp.UserId == "" {
 				t.Errorf("RegisterUser() expected non-empty userID")
 			}
 			count := testutil.ToFloat64(requestCounter.WithLabelValues("RegisterUser", "success"))
-			if tt.wantResp.Success && count == 0 {
+			if count == 0 {
 				t.Errorf("Expected RegisterUser success metric to be recorded")
 			}
 		})
@@ -117,10 +337,32 @@ func TestUserHandler_RegisterUser(t *testing.T) {
 func TestUserHandler_Login(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	mockRepo := &MockUserRepository{
+		GetUserRolesFunc: func(ctx context.Context, userID string) ([]string, error) {
+			return nil, nil
+		},
+		GetMFASettingsFunc: func(ctx context.Context, userID string) (*model.MFASettings, error) {
+			if userID == "mfa123" {
+				return &model.MFASettings{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil
+			}
+			return nil, nil
+		},
+		RehashPasswordFunc: func(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+			return true, nil
+		},
+		CreateMFAChallengeFunc: func(ctx context.Context, challenge *model.MFAChallenge) error {
+			return nil
+		},
+		CreateRefreshTokenFunc: func(ctx context.Context, token *model.RefreshToken) error {
+			return nil
+		},
 		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
-			if email == "test@example.com" {
+			if email == "test@example.com" || email == "mfa@example.com" {
+				id := "user123"
+				if email == "mfa@example.com" {
+					id = "mfa123"
+				}
 				return &model.User{
-					ID:        "user123",
+					ID:        id,
 					Email:     email,
 					Password:  string(hashedPassword),
 					Nickname:  "TestUser",
@@ -137,73 +379,141 @@ func TestUserHandler_Login(t *testing.T) {
 			DurationHours: 24,
 		},
 		Service: config.ServiceConfig{
-			Name:      "test-service",
+			Name:     "test-service",
 			Port:     8080,
-			NameLogLevel: "debug",
+			LogLevel: "debug",
 		},
 	}
 	log := logger.NewLogger(cfg)
-	met := metrics.NewMetricsNewMetrics(cfg)
-	handler := NewUserHandler(handler.NewUserHandler(repo, cfg, log, met))
+	met := metrics.NewMetrics(cfg)
+	handler := NewUserHandler(mockRepo, cfg, log, met)
 
 	tests := []struct {
 		name     string
-		req      *proto.LoginRequest
-		wantResp *proto.LoginResponse
+		req      *userv1.LoginRequest
+		wantResp *userv1.LoginResponse
 	}{
 		{
 			name: "Successful login",
-			req: &proto.LoginRequest{
+			req: &userv1.LoginRequest{
 				Email:    "test@example.com",
 				Password: "password123",
 			},
-			wantResp: &proto.LoginResponse{
+			wantResp: &userv1.LoginResponse{
 				Success: true,
 				Message: "Login successful",
 			},
 		},
+		{
+			name: "Second factor required",
+			req: &userv1.LoginRequest{
+				Email:    "mfa@example.com",
+				Password: "password123",
+			},
+			wantResp: &userv1.LoginResponse{
+				Success:     true,
+				Message:     "Two-factor authentication required",
+				MfaRequired: true,
+			},
+		},
 		{
 			name: "Invalid credentials",
-			req: &proto.LoginRequest{
+			req: &userv1.LoginRequest{
 				Email:    "test@example.com",
 				Password: "wrongpassword",
 			},
-			wantResp: &proto.LoginResponse{
-				Success: false,
-				Message: "invalid credentials",
-			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			resp, err := handler.Login(context.Background(), tt.req)
+			if tt.wantResp == nil {
+				checkStatus(t, err, codes.Unauthenticated, service.ReasonInvalidCredentials)
+				return
+			}
 			if err != nil {
 				t.Fatalf("Login() error = %v", err)
 			}
-			if resp.Success != tt.wantResp.Success || resp.Message != tt.wantResp.Message {
+			if resp.Success != tt.wantResp.Success || resp.Message != tt.wantResp.Message || resp.MfaRequired != tt.wantResp.MfaRequired {
 				t.Errorf("Login() = %+v, want %+v", resp, tt.wantResp)
 			}
-			if tt.wantResp.Success && resp.Token == "" {
+			if tt.wantResp.MfaRequired {
+				if resp.MfaToken == "" || resp.Token != "" {
+					t.Errorf("Login() = %+v, want only an MFA token", resp)
+				}
+			} else if resp.Token == "" {
 				t.Errorf("Login() expected non-empty token")
 			}
 		})
 	}
 }
 
+func TestUserHandler_RefreshToken(t *testing.T) {
+	enrolled := false
+	mockRepo := &MockUserRepository{
+		GetRefreshTokenByHashFunc: func(ctx context.Context, hash string) (*model.RefreshToken, error) {
+			return &model.RefreshToken{ID: "rt1", UserID: "user123", FamilyID: "family1", TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		RotateRefreshTokenFunc: func(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error) {
+			return true, nil
+		},
+		GetUserRolesFunc: func(ctx context.Context, userID string) ([]string, error) {
+			return []string{model.RoleStaff}, nil
+		},
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			return &model.User{ID: id, Email: "staff@example.com", Role: model.RoleStaff}, nil
+		},
+		GetMFASettingsFunc: func(ctx context.Context, userID string) (*model.MFASettings, error) {
+			if !enrolled {
+				return nil, nil
+			}
+			return &model.MFASettings{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil
+		},
+	}
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+		MFA: config.MFAConfig{RequiredRoles: []string{model.RoleStaff}},
+	}
+	log := logger.NewLogger(cfg)
+	met := metrics.NewMetrics(cfg)
+	handler := NewUserHandler(mockRepo, cfg, log, met)
+
+	for _, want := range []bool{true, false} {
+		enrolled = !want
+		resp, err := handler.RefreshToken(context.Background(), &userv1.RefreshTokenRequest{RefreshToken: "refresh-token"})
+		if err != nil {
+			t.Fatalf("RefreshToken() error = %v", err)
+		}
+		if !resp.Success || resp.Token == "" || resp.RefreshToken == "" || resp.MfaEnrollmentRequired != want {
+			t.Errorf("RefreshToken() with enrolled = %v = %+v, want MfaEnrollmentRequired %v", enrolled, resp, want)
+		}
+	}
+}
+
 func TestUserHandler_GetUserInfo(t *testing.T) {
 	mockRepo := &MockUserRepository{
 		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
 			if id == "user123" {
 				return &model.User{
-					ID:        "user123",
-					Email:     "test@example.com",
-					Nickname:   "TestUser",
-					Avatar:     "http://example.com/avatar.png",
-					CreatedAt:   time.Now(),
+					ID:            "user123",
+					Email:         "test@example.com",
+					Nickname:      "TestUser",
+					Avatar:        "http://example.com/avatar.png",
+					InstitutionID: "example",
+					Role:          model.RoleStudent,
+					CreatedAt:     time.Now(),
 				}, nil
 			}
-			return nil, errors.New("user not found")
+			return nil, fmt.Errorf("user %w", model.ErrNotFound)
 		},
 	}
 	cfg := &config.Config{
@@ -214,7 +524,7 @@ func TestUserHandler_GetUserInfo(t *testing.T) {
 		Service: config.ServiceConfig{
 			Name:     "test-service",
 			Port:     8080,
-			LogLevel:  "debug",
+			LogLevel: "debug",
 		},
 	}
 	log := logger.NewLogger(cfg)
@@ -223,40 +533,44 @@ func TestUserHandler_GetUserInfo(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		req      *proto.GetUserInfoRequest
-		wantResp *proto.GetUserInfoResponse
+		req      *userv1.GetUserInfoRequest
+		wantResp *userv1.GetUserInfoResponse
 	}{
 		{
 			name: "Successful get user info",
-			req: &proto.GetUserInfoRequest{
+			req: &userv1.GetUserInfoRequest{
 				UserId: "user123",
 			},
-			wantResp: &proto.GetUserInfoResponse{
+			wantResp: &userv1.GetUserInfoResponse{
 				Success: true,
 				Message: "User info retrieved successfully",
-				User: &proto.UserInfo{
+				User: &userv1.UserInfo{
 					UserId:   "user123",
 					Email:    "test@example.com",
 					Nickname: "TestUser",
 					Avatar:   "http://example.com/avatar.png",
+					Affiliation: &userv1.Affiliation{
+						InstitutionId: "example",
+						Role:          model.RoleStudent,
+					},
 				},
 			},
 		},
 		{
 			name: "User not found",
-			req: &proto.GetUserInfoRequest{
+			req: &userv1.GetUserInfoRequest{
 				UserId: "invalid",
 			},
-			wantResp: &proto.GetUserInfoResponse{
-				Success: false,
-				Message: "user not found",
-			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			resp, err := handler.GetUserInfo(context.Background(), tt.req)
+			if tt.wantResp == nil {
+				checkStatus(t, err, codes.NotFound, service.ReasonUserNotFound)
+				return
+			}
 			if err != nil {
 				t.Fatalf("GetUserInfo() error = %v", err)
 			}
@@ -267,10 +581,181 @@ func TestUserHandler_GetUserInfo(t *testing.T) {
 				if resp.User.UserId != tt.wantResp.User.UserId ||
 					resp.User.Email != tt.wantResp.User.Email ||
 					resp.User.Nickname != tt.wantResp.User.Nickname ||
-					resp.User.Avatar != tt.wantResp.User.Avatar {
+					resp.User.Avatar != tt.wantResp.User.Avatar ||
+					resp.User.GetAffiliation().GetInstitutionId() != tt.wantResp.User.GetAffiliation().GetInstitutionId() ||
+					resp.User.GetAffiliation().GetRole() != tt.wantResp.User.GetAffiliation().GetRole() {
 					t.Errorf("GetUserInfo() User = %+v, want %+v", resp.User, tt.wantResp.User)
 				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	stored := &model.User{ID: "user123", Email: "test@example.com", Nickname: "TestUser", Version: 1}
+	mockRepo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			if id != stored.ID {
+				return nil, errors.New("user not found")
+			}
+			u := *stored
+			return &u, nil
+		},
+		UpdateUserFunc: func(ctx context.Context, user *model.User) error {
+			user.Version++
+			user.UpdatedAt = time.Now().UTC()
+			*stored = *user
+			return nil
+		},
+	}
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	handler := NewUserHandler(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+	ctx := jwt.ContextWithUserID(context.Background(), "user123")
+
+	tests := []struct {
+		name        string
+		req         *userv1.UpdateUserRequest
+		wantSuccess bool
+		wantMessage string
+		wantCode    codes.Code
+		wantReason  string
+	}{
+		{
+			name: "Successful update of own profile",
+			req: &userv1.UpdateUserRequest{
+				User:       &userv1.UserInfo{Nickname: "NewName", Version: 1},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}},
+			},
+			wantSuccess: true,
+			wantMessage: "User updated successfully",
+		},
+		{
+			name: "Other user's profile",
+			req: &userv1.UpdateUserRequest{
+				User:       &userv1.UserInfo{UserId: "other", Nickname: "NewName", Version: 1},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}},
+			},
+			wantCode:   codes.PermissionDenied,
+			wantReason: service.ReasonPermissionDenied,
+		},
+		{
+			name:       "Missing user",
+			req:        &userv1.UpdateUserRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}}},
+			wantCode:   codes.InvalidArgument,
+			wantReason: service.ReasonRequiredFieldMissing,
+		},
+		{
+			name: "Missing mask",
+			req: &userv1.UpdateUserRequest{
+				User: &userv1.UserInfo{Nickname: "NewName", Version: 2},
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: service.ReasonRequiredFieldMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := handler.UpdateUser(ctx, tt.req)
+			if !tt.wantSuccess {
+				checkStatus(t, err, tt.wantCode, tt.wantReason)
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if resp.Success != tt.wantSuccess || resp.Message != tt.wantMessage {
+				t.Errorf("UpdateUser() = %+v, want success %v message %q", resp, tt.wantSuccess, tt.wantMessage)
+			}
+			if resp.User.Nickname != "NewName" || resp.User.Version != 2 || resp.User.UpdatedAt == nil {
+				t.Errorf("UpdateUser() User = %+v", resp.User)
+			}
+		})
+	}
+}
+
+func TestUserHandler_RequestPasswordReset(t *testing.T) {
+	mockRepo := &MockUserRepository{
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			if email == "test@example.com" {
+				return &model.User{ID: "user123", Email: email}, nil
+			}
+			return nil, errors.New("user not found")
+		},
+		CreatePasswordResetTokenFunc: func(ctx context.Context, token *model.PasswordResetToken) error {
+			return nil
+		},
+	}
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	handler := NewUserHandler(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+
+	// Registered and unknown emails must be indistinguishable.
+	for _, email := range []string{"test@example.com", "nobody@example.com"} {
+		resp, err := handler.RequestPasswordReset(context.Background(), &userv1.RequestPasswordResetRequest{Email: email})
+		if err != nil {
+			t.Fatalf("RequestPasswordReset() error = %v", err)
+		}
+		if !resp.Success || resp.Message != "If the email is registered, a reset token has been sent" {
+			t.Errorf("RequestPasswordReset(%s) = %+v", email, resp)
+		}
+	}
+
+	_, err := handler.RequestPasswordReset(context.Background(), &userv1.RequestPasswordResetRequest{})
+	checkStatus(t, err, codes.InvalidArgument, service.ReasonRequiredFieldMissing)
+}
+
+func TestUserHandler_VerifyEmail(t *testing.T) {
+	mockRepo := &MockUserRepository{
+		GetEmailVerificationTokenByHashFunc: func(ctx context.Context, hash string) (*model.EmailVerificationToken, error) {
+			return nil, errors.New("email verification token not found")
+		},
+	}
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	handler := NewUserHandler(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+
+	tests := []struct {
+		name       string
+		token      string
+		wantReason string
+	}{
+		{name: "Missing token", token: "", wantReason: service.ReasonRequiredFieldMissing},
+		{name: "Unknown token", token: "bogus", wantReason: service.ReasonInvalidVerifyToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.VerifyEmail(context.Background(), &userv1.VerifyEmailRequest{Token: tt.token})
+			checkStatus(t, err, codes.InvalidArgument, tt.wantReason)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// EmailVerificationToken is a single-use token proving that a user owns
+// their email address. Only the SHA-256 hash of the token is persisted.
+type EmailVerificationToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// Used reports whether the token has already been redeemed or invalidated.
+func (t *EmailVerificationToken) Used() bool {
+	return t.UsedAt != nil
+}
+
+// Expired reports whether the token is past its expiry at the given time.
+func (t *EmailVerificationToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
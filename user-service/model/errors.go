@@ -0,0 +1,13 @@
+package model
+
+import "errors"
+
+// Errors wrapped by the repository so callers can tell expected outcomes
+// from storage failures.
+var (
+	// ErrNotFound is wrapped by errors for records that do not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrVersionConflict is returned when an optimistic update loses to a
+	// concurrent one.
+	ErrVersionConflict = errors.New("user was modified by another request")
+)
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// LoginThrottle counts the failed logins of one account or client IP. Key is
+// "account:<email>" or "ip:<address>".
+type LoginThrottle struct {
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"` // failures within the lockout window
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty"`
+	Locked        bool       `json:"locked"` // BlockedUntil is a lockout rather than a backoff delay
+}
+
+// Blocked reports whether logins for the key are refused at the given time.
+func (t *LoginThrottle) Blocked(now time.Time) bool {
+	return t.BlockedUntil != nil && now.Before(*t.BlockedUntil)
+}
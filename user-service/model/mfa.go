@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// MFASettings holds a user's TOTP second factor. The secret is stored as
+// soon as enrollment starts; the factor is only enforced once Enabled.
+type MFASettings struct {
+	UserID       string     `json:"user_id"`
+	Secret       string     `json:"-"` // base32 TOTP secret
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"` // TOTP time step of the last accepted code, to refuse replays
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// MFAChallenge is the short-lived token returned by the password step of a
+// two-step login. Only the SHA-256 hash of the token is persisted.
+type MFAChallenge struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	TokenHash string     `json:"-"`
+	Attempts  int        `json:"attempts"` // failed codes entered so far
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// Used reports whether the challenge has already been redeemed.
+func (c *MFAChallenge) Used() bool {
+	return c.UsedAt != nil
+}
+
+// Expired reports whether the challenge is past its expiry at the given time.
+func (c *MFAChallenge) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
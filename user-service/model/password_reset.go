@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// PasswordResetToken is a single-use token for resetting a forgotten
+// password. Only the SHA-256 hash of the token is persisted.
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// Used reports whether the token has already been redeemed or invalidated.
+func (t *PasswordResetToken) Used() bool {
+	return t.UsedAt != nil
+}
+
+// Expired reports whether the token is past its expiry at the given time.
+func (t *PasswordResetToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
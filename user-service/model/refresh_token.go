@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// RefreshToken is a stored opaque refresh token. Only the SHA-256 hash of the
+// token is persisted. Tokens rotated from the same login share a FamilyID.
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	FamilyID   string     `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy string     `json:"replaced_by,omitempty"`
+}
+
+// Revoked reports whether the token has been rotated or revoked.
+func (t *RefreshToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// Expired reports whether the token is past its expiry at the given time.
+func (t *RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -2,12 +2,24 @@ package model
 
 import "time"
 
+// Campus roles derived from the domain of a user's email address.
+const (
+	RoleStudent = "student"
+	RoleStaff   = "staff"
+	RoleAlumni  = "alumni"
+)
+
 // User represents the user entity.
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"` // Hashed password
-	Nickname  string    `json:"nickname"`
-	Avatar    string    `json:"avatar"`
-	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	Password      string    `json:"password"` // Hashed password
+	Nickname      string    `json:"nickname"`
+	Avatar        string    `json:"avatar"`
+	EmailVerified bool      `json:"email_verified"` // set once the registration token is redeemed
+	InstitutionID string    `json:"institution_id"` // derived from the email domain at registration
+	Role          string    `json:"role"`           // RoleStudent, RoleStaff or RoleAlumni
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Version       int64     `json:"version"` // incremented on every update, for optimistic concurrency
+}
@@ -2,7 +2,9 @@ package observability
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
@@ -15,6 +17,8 @@ type Observability struct {
 	Logger        *logger.Logger
 	Metrics       *metrics.Metrics
 	TracerShutdown func(context.Context) error
+
+	metricsServer *http.Server
 }
 
 // InitObservability initializes logging, tracing, and metrics.
@@ -33,8 +37,9 @@ func InitObservability(ctx context.Context, cfg *config.Config) (*Observability,
 	met := metrics.NewMetrics(cfg)
 
 	// Start metrics server in a goroutine
+	metricsServer := metrics.NewMetricsServer(cfg)
 	go func() {
-		if err := metrics.StartMetricsServer(cfg); err != nil {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Error(ctx).Err(err).Msg("Failed to start metrics server")
 		}
 	}()
@@ -44,14 +49,25 @@ func InitObservability(ctx context.Context, cfg *config.Config) (*Observability,
 		Logger:        log,
 		Metrics:       met,
 		TracerShutdown: tracerShutdown,
+		metricsServer: metricsServer,
 	}, nil
 }
 
-// Shutdown cleans up observability resources.
+// Shutdown stops the metrics server and flushes pending spans.
 func (o *Observability) Shutdown(ctx context.Context) error {
+	var serverErr error
+	if o.metricsServer != nil {
+		if err := o.metricsServer.Shutdown(ctx); err != nil {
+			o.Logger.Error(ctx).Err(err).Msg("Failed to shutdown metrics server")
+			serverErr = fmt.Errorf("failed to shutdown metrics server: %w", err)
+		}
+	}
 	if err := o.TracerShutdown(ctx); err != nil {
 		o.Logger.Error(ctx).Err(err).Msg("Failed to shutdown tracer")
-		return fmt.Errorf("failed to shutdown tracer: %w", err)
+		return errors.Join(serverErr, fmt.Errorf("failed to shutdown tracer: %w", err))
+	}
+	if serverErr != nil {
+		return serverErr
 	}
 	o.Logger.Info(ctx).Msg("Observability shutdown complete")
 	return nil
@@ -0,0 +1,113 @@
+package campus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+)
+
+// Affiliation is the institution and role a user belongs to.
+type Affiliation struct {
+	InstitutionID   string
+	InstitutionName string
+	Role            string
+}
+
+type domainRule struct {
+	domain      string
+	affiliation Affiliation
+}
+
+// Directory resolves email addresses to campus affiliations using the
+// domains configured under campus.institutions.
+type Directory struct {
+	rules      []domainRule
+	restricted bool
+}
+
+// NewDirectory builds a Directory from cfg.Campus. Invalid entries are
+// reported in the returned error and left out of the directory, which stays
+// restricted so that a broken configuration never opens registration to
+// every domain.
+func NewDirectory(cfg *config.Config) (*Directory, error) {
+	d := &Directory{restricted: len(cfg.Campus.Institutions) > 0}
+	seen := make(map[string]string)
+	var errs []error
+	for i, inst := range cfg.Campus.Institutions {
+		if inst.ID == "" {
+			errs = append(errs, fmt.Errorf("campus.institutions[%d]: id is required", i))
+			continue
+		}
+		if len(inst.Domains) == 0 {
+			errs = append(errs, fmt.Errorf("institution %q: at least one domain is required", inst.ID))
+			continue
+		}
+		for _, dc := range inst.Domains {
+			domain := strings.Trim(strings.ToLower(strings.TrimSpace(dc.Domain)), ".")
+			if domain == "" {
+				errs = append(errs, fmt.Errorf("institution %q: empty domain", inst.ID))
+				continue
+			}
+			if !validRole(dc.Role) {
+				errs = append(errs, fmt.Errorf("institution %q: domain %q has unknown role %q", inst.ID, domain, dc.Role))
+				continue
+			}
+			if owner, ok := seen[domain]; ok {
+				errs = append(errs, fmt.Errorf("institution %q: domain %q is already assigned to %q", inst.ID, domain, owner))
+				continue
+			}
+			seen[domain] = inst.ID
+			d.rules = append(d.rules, domainRule{
+				domain: domain,
+				affiliation: Affiliation{
+					InstitutionID:   inst.ID,
+					InstitutionName: inst.Name,
+					Role:            dc.Role,
+				},
+			})
+		}
+	}
+	return d, errors.Join(errs...)
+}
+
+// Restricted reports whether registration is limited to configured domains.
+func (d *Directory) Restricted() bool {
+	return d.restricted
+}
+
+// Resolve returns the affiliation for email. The longest configured domain
+// equal to, or a parent of, the address's domain wins, so a student
+// subdomain can override the staff rule of its parent domain.
+func (d *Directory) Resolve(email string) (Affiliation, bool) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return Affiliation{}, false
+	}
+	domain := strings.ToLower(email[at+1:])
+
+	var best *domainRule
+	for i := range d.rules {
+		rule := &d.rules[i]
+		if domain != rule.domain && !strings.HasSuffix(domain, "."+rule.domain) {
+			continue
+		}
+		if best == nil || len(rule.domain) > len(best.domain) {
+			best = rule
+		}
+	}
+	if best == nil {
+		return Affiliation{}, false
+	}
+	return best.affiliation, true
+}
+
+func validRole(role string) bool {
+	switch role {
+	case model.RoleStudent, model.RoleStaff, model.RoleAlumni:
+		return true
+	}
+	return false
+}
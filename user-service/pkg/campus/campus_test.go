@@ -0,0 +1,104 @@
+package campus
+
+import (
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+func newTestConfig(institutions ...config.InstitutionConfig) *config.Config {
+	return &config.Config{Campus: config.CampusConfig{Institutions: institutions}}
+}
+
+func TestDirectory_Resolve(t *testing.T) {
+	d, err := NewDirectory(newTestConfig(
+		config.InstitutionConfig{
+			ID:   "example",
+			Name: "Example University",
+			Domains: []config.DomainConfig{
+				{Domain: "example.edu", Role: "staff"},
+				{Domain: "stu.example.edu", Role: "student"},
+				{Domain: "Alumni.Example.edu", Role: "alumni"},
+			},
+		},
+		config.InstitutionConfig{
+			ID:      "other",
+			Domains: []config.DomainConfig{{Domain: "other.ac.uk", Role: "student"}},
+		},
+	))
+	if err != nil {
+		t.Fatalf("NewDirectory() error = %v", err)
+	}
+	if !d.Restricted() {
+		t.Error("Restricted() = false, want true")
+	}
+
+	tests := []struct {
+		email           string
+		wantInstitution string
+		wantRole        string
+		wantOK          bool
+	}{
+		{email: "prof@example.edu", wantInstitution: "example", wantRole: "staff", wantOK: true},
+		{email: "prof@cs.example.edu", wantInstitution: "example", wantRole: "staff", wantOK: true},
+		{email: "alice@stu.example.edu", wantInstitution: "example", wantRole: "student", wantOK: true},
+		{email: "alice@mail.stu.example.edu", wantInstitution: "example", wantRole: "student", wantOK: true},
+		{email: "bob@ALUMNI.example.edu", wantInstitution: "example", wantRole: "alumni", wantOK: true},
+		{email: "carol@other.ac.uk", wantInstitution: "other", wantRole: "student", wantOK: true},
+		{email: "mallory@notexample.edu", wantOK: false},
+		{email: "mallory@example.edu.evil.com", wantOK: false},
+		{email: "no-at-sign", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			got, ok := d.Resolve(tt.email)
+			if ok != tt.wantOK {
+				t.Fatalf("Resolve() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.InstitutionID != tt.wantInstitution || got.Role != tt.wantRole {
+				t.Errorf("Resolve() = %+v, want institution %q role %q", got, tt.wantInstitution, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestNewDirectory_Open(t *testing.T) {
+	d, err := NewDirectory(newTestConfig())
+	if err != nil {
+		t.Fatalf("NewDirectory() error = %v", err)
+	}
+	if d.Restricted() {
+		t.Error("Restricted() = true, want false")
+	}
+	if _, ok := d.Resolve("anyone@example.com"); ok {
+		t.Error("Resolve() ok = true for an unconfigured domain")
+	}
+}
+
+func TestNewDirectory_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		inst config.InstitutionConfig
+	}{
+		{name: "Missing ID", inst: config.InstitutionConfig{Domains: []config.DomainConfig{{Domain: "a.edu", Role: "staff"}}}},
+		{name: "No domains", inst: config.InstitutionConfig{ID: "a"}},
+		{name: "Empty domain", inst: config.InstitutionConfig{ID: "a", Domains: []config.DomainConfig{{Role: "staff"}}}},
+		{name: "Unknown role", inst: config.InstitutionConfig{ID: "a", Domains: []config.DomainConfig{{Domain: "a.edu", Role: "dean"}}}},
+		{name: "Duplicate domain", inst: config.InstitutionConfig{ID: "a", Domains: []config.DomainConfig{
+			{Domain: "a.edu", Role: "staff"},
+			{Domain: "A.edu", Role: "student"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDirectory(newTestConfig(tt.inst))
+			if err == nil {
+				t.Fatal("NewDirectory() error = nil, want error")
+			}
+			// Broken entries must not open registration to every domain.
+			if !d.Restricted() {
+				t.Error("Restricted() = false, want true")
+			}
+		})
+	}
+}
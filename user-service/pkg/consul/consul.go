@@ -3,9 +3,13 @@ package consul
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/registry"
 	"github.com/hashicorp/consul/api"
 )
 
@@ -43,28 +47,48 @@ func (c *ConsulClient) RegisterService(ctx context.Context) error {
 		return nil
 	}
 
-	registration := &api.AgentServiceRegistration{
-		ID:      c.cfg.Consul.ServiceID,
-		Name:    c.cfg.Service.Name,
-		Port:    c.cfg.Service.Port,
-		Address: "localhost",
-		Check: &api.AgentServiceCheck{
-			HTTP:     fmt.Sprintf("http://localhost:%d/health", c.cfg.Service.Port),
-			Interval: "10s",
-			Timeout:  "3s",
-		},
+	address, err := registry.AdvertiseAddress(c.cfg.Registry)
+	if err != nil {
+		c.logger.Error(ctx).Err(err).Msg("Failed to determine advertise address")
+		return fmt.Errorf("failed to determine advertise address: %w", err)
 	}
+	registration := c.registration(address)
 
-	err := c.client.Agent().ServiceRegister(registration)
+	err = c.client.Agent().ServiceRegister(registration)
 	if err != nil {
 		c.logger.Error(ctx).Err(err).Msg("Failed to register service with Consul")
 		return fmt.Errorf("failed to register service: %w", err)
 	}
 
-	c.logger.Info(ctx).Msg("Service registered with Consul")
+	c.logger.Info(ctx).Msgf("Service registered with Consul at %s", net.JoinHostPort(address, strconv.Itoa(registration.Port)))
 	return nil
 }
 
+// registration describes this instance, reachable at address.
+func (c *ConsulClient) registration(address string) *api.AgentServiceRegistration {
+	// The agent calls the grpc.health.v1 service, which reports the result
+	// of the dependency probes, on the advertised address: the agent is not
+	// necessarily on this host.
+	check := &api.AgentServiceCheck{
+		GRPC:     net.JoinHostPort(address, strconv.Itoa(c.cfg.Service.Port)),
+		Interval: "10s",
+		Timeout:  "3s",
+	}
+	if c.cfg.Consul.DeregisterCriticalAfterSeconds > 0 {
+		check.DeregisterCriticalServiceAfter = (time.Duration(c.cfg.Consul.DeregisterCriticalAfterSeconds) * time.Second).String()
+	}
+
+	return &api.AgentServiceRegistration{
+		ID:      c.cfg.Consul.ServiceID,
+		Name:    c.cfg.Service.Name,
+		Port:    c.cfg.Service.Port,
+		Address: address,
+		Tags:    c.cfg.Registry.Tags,
+		Meta:    registry.Metadata(c.cfg),
+		Check:   check,
+	}
+}
+
 // DeregisterService deregisters the service from Consul.
 func (c *ConsulClient) DeregisterService(ctx context.Context) error {
 	if !c.cfg.Consul.Enabled {
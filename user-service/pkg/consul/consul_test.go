@@ -52,4 +52,45 @@ func TestConsulClient_RegisterService(t *testing.T) {
 	if err != nil {
 		t.Errorf("RegisterService() error = %v, want nil", err)
 	}
-}
\ No newline at end of file
+}
+func TestConsulClient_registration(t *testing.T) {
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "user-service",
+			Port:     8080,
+			HTTPPort: 8081,
+			Version:  "1.4.0",
+		},
+		Consul: config.ConsulConfig{
+			ServiceID:                      "user-service-1",
+			DeregisterCriticalAfterSeconds: 90,
+		},
+		Registry: config.RegistryConfig{
+			Tags: []string{"grpc"},
+			Meta: map[string]string{"zone": "a"},
+		},
+	}
+	client, _ := NewConsulClient(cfg, logger.NewLogger(cfg))
+
+	reg := client.registration("10.0.0.7")
+	if reg.Address != "10.0.0.7" || reg.Port != 8080 || reg.ID != "user-service-1" {
+		t.Errorf("registration = %s:%d id %s", reg.Address, reg.Port, reg.ID)
+	}
+	if len(reg.Tags) != 1 || reg.Tags[0] != "grpc" {
+		t.Errorf("Tags = %v", reg.Tags)
+	}
+	if reg.Meta["version"] != "1.4.0" || reg.Meta["zone"] != "a" {
+		t.Errorf("Meta = %v", reg.Meta)
+	}
+	if reg.Check.GRPC != "10.0.0.7:8080" {
+		t.Errorf("Check.GRPC = %q, want the advertised address", reg.Check.GRPC)
+	}
+	if reg.Check.DeregisterCriticalServiceAfter != "1m30s" {
+		t.Errorf("DeregisterCriticalServiceAfter = %q, want 1m30s", reg.Check.DeregisterCriticalServiceAfter)
+	}
+
+	cfg.Consul.DeregisterCriticalAfterSeconds = 0
+	if after := client.registration("10.0.0.7").Check.DeregisterCriticalServiceAfter; after != "" {
+		t.Errorf("DeregisterCriticalServiceAfter = %q, want unset", after)
+	}
+}
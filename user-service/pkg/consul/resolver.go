@@ -0,0 +1,125 @@
+package consul
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC target scheme of services registered in Consul, as in
+// consul:///user-service.
+const Scheme = "consul"
+
+const (
+	// waitTime bounds each blocking query; Consul answers earlier as soon
+	// as the instances change.
+	waitTime = 5 * time.Minute
+	// resolveRetry is how long the resolver waits after a failed query.
+	resolveRetry = time.Second
+)
+
+// NewResolverBuilder returns a gRPC resolver.Builder for consul:///<service>
+// targets. It resolves to the instances whose health checks pass and
+// follows changes of the catalog with blocking queries. A tag parameter, as
+// in consul:///user-service?tag=grpc, keeps only the instances with that
+// tag.
+func NewResolverBuilder(client *api.Client) resolver.Builder {
+	return &resolverBuilder{client: client}
+}
+
+type resolverBuilder struct {
+	client *api.Client
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &consulResolver{
+		health:  b.client.Health(),
+		service: strings.TrimPrefix(target.Endpoint(), "/"),
+		tag:     target.URL.Query().Get("tag"),
+		cc:      cc,
+		cancel:  cancel,
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+// consulResolver keeps the addresses of a ClientConn in sync with the
+// passing instances of one service.
+type consulResolver struct {
+	health  *api.Health
+	service string
+	tag     string
+	cc      resolver.ClientConn
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// watch queries the passing instances until ctx is done, blocking until the
+// result differs from the previous one.
+func (r *consulResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+	var index uint64
+	for ctx.Err() == nil {
+		opts := (&api.QueryOptions{WaitIndex: index, WaitTime: waitTime}).WithContext(ctx)
+		entries, meta, err := r.health.Service(r.service, r.tag, true, opts)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.cc.ReportError(err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(resolveRetry):
+			}
+			continue
+		}
+		if meta.LastIndex == index {
+			continue // the query timed out without changes
+		}
+		// The index can go backwards, e.g. after a Consul restore; start
+		// over rather than block on an index that is never reached.
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+		r.update(entries)
+	}
+}
+
+// update passes the addresses of entries to the ClientConn.
+func (r *consulResolver) update(entries []*api.ServiceEntry) {
+	addrs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+	}
+	sort.Strings(addrs)
+	state := resolver.State{Addresses: make([]resolver.Address, len(addrs))}
+	for i, addr := range addrs {
+		state.Addresses[i] = resolver.Address{Addr: addr}
+	}
+	_ = r.cc.UpdateState(state)
+}
+
+func (r *consulResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *consulResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,149 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeCatalog serves the health endpoint of the Consul HTTP API, answering
+// blocking queries when the entries change.
+type fakeCatalog struct {
+	mu      sync.Mutex
+	index   uint64
+	entries []*api.ServiceEntry
+	changed chan struct{}
+	queries []url.Values
+}
+
+func (c *fakeCatalog) set(entries ...*api.ServiceEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index++
+	c.entries = entries
+	if c.changed != nil {
+		close(c.changed)
+	}
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeCatalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/health/service/user-service") {
+		http.NotFound(w, r)
+		return
+	}
+	wait, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	c.mu.Lock()
+	c.queries = append(c.queries, r.URL.Query())
+	for c.index <= wait {
+		changed := c.changed
+		c.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		c.mu.Lock()
+	}
+	index, entries := c.index, c.entries
+	c.mu.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func entry(node, address string, port int) *api.ServiceEntry {
+	return &api.ServiceEntry{
+		Node:    &api.Node{Address: node},
+		Service: &api.AgentService{Address: address, Port: port},
+	}
+}
+
+// recordingClientConn records the addresses a resolver reports.
+type recordingClientConn struct {
+	resolver.ClientConn
+	states chan []string
+}
+
+func (cc *recordingClientConn) UpdateState(state resolver.State) error {
+	var addrs []string
+	for _, addr := range state.Addresses {
+		addrs = append(addrs, addr.Addr)
+	}
+	cc.states <- addrs
+	return nil
+}
+
+func (cc *recordingClientConn) ReportError(err error) {}
+
+func (cc *recordingClientConn) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case addrs := <-cc.states:
+		return addrs
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resolver update")
+		return nil
+	}
+}
+
+func TestResolver(t *testing.T) {
+	catalog := &fakeCatalog{}
+	catalog.set(entry("10.0.0.1", "10.0.0.7", 8080), entry("10.0.0.2", "", 8080))
+	server := httptest.NewServer(catalog)
+	defer server.Close()
+
+	client, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(server.URL, "http://")})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	builder := NewResolverBuilder(client)
+	if builder.Scheme() != "consul" {
+		t.Errorf("Scheme() = %q", builder.Scheme())
+	}
+	cc := &recordingClientConn{states: make(chan []string, 4)}
+	target := resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/user-service", RawQuery: "tag=grpc"}}
+	r, err := builder.Build(target, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// The node address stands in for an instance without its own address.
+	if addrs := cc.next(t); !reflect.DeepEqual(addrs, []string{"10.0.0.2:8080", "10.0.0.7:8080"}) {
+		t.Errorf("initial addresses = %v", addrs)
+	}
+
+	catalog.set(entry("10.0.0.1", "10.0.0.7", 8080))
+	if addrs := cc.next(t); !reflect.DeepEqual(addrs, []string{"10.0.0.7:8080"}) {
+		t.Errorf("addresses after an instance failed = %v", addrs)
+	}
+
+	catalog.mu.Lock()
+	query := catalog.queries[1]
+	catalog.mu.Unlock()
+	if query.Get("passing") == "" || query.Get("tag") != "grpc" || query.Get("index") != "1" {
+		t.Errorf("query = %v, want a blocking query for passing instances tagged grpc", query)
+	}
+
+	// Close cancels the pending blocking query.
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return")
+	}
+}
@@ -3,6 +3,8 @@ package etcd
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time" 
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
@@ -15,6 +17,10 @@ type EtcdClient struct {
 	client *clientv3.Client
 	logger *logger.Logger
 	cfg    *config.Config
+
+	// registration is the instance registered by RegisterService.
+	mu           sync.Mutex
+	registration *registration
 }
 
 // NewEtcdClient initializes an etcd client.
@@ -55,6 +61,67 @@ func (c *EtcdClient) PutConfig(ctx context.Context, key, value string) error {
 	return nil
 }
 
+// ConfigValues returns the dynamic configuration stored under
+// etcd.config_prefix, keyed by dotted path. With the EtcdClient as the
+// remote layer of a config.Store, <prefix>service/log_level overrides
+// service.log_level.
+func (c *EtcdClient) ConfigValues(ctx context.Context) (map[string]string, error) {
+	if !c.cfg.Etcd.Enabled || c.client == nil {
+		return nil, nil
+	}
+	prefix := c.cfg.Etcd.ConfigPrefix
+	resp, err := c.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config under %s: %w", prefix, err)
+	}
+	values := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if key := configKey(prefix, string(kv.Key)); key != "" {
+			values[key] = string(kv.Value)
+		}
+	}
+	return values, nil
+}
+
+// WatchConfig calls changed after every change under etcd.config_prefix
+// until ctx is done.
+func (c *EtcdClient) WatchConfig(ctx context.Context, changed func()) {
+	if !c.cfg.Etcd.Enabled || c.client == nil {
+		return
+	}
+	prefix := c.cfg.Etcd.ConfigPrefix
+	for resp := range c.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix()) {
+		if err := resp.Err(); err != nil {
+			c.logger.Warn(ctx).Err(err).Msgf("Watch of config under %s interrupted", prefix)
+			continue
+		}
+		changed()
+	}
+}
+
+// configKey converts the etcd key of a config value into its dotted path,
+// e.g. <prefix>lockout/account/lockout_after into
+// lockout.account.lockout_after.
+func configKey(prefix, key string) string {
+	key = strings.Trim(strings.TrimPrefix(key, prefix), "/")
+	return strings.ToLower(strings.ReplaceAll(key, "/", "."))
+}
+
+// Ping checks that at least one etcd endpoint answers. It is used as the
+// readiness probe of the service and returns nil when etcd is disabled.
+func (c *EtcdClient) Ping(ctx context.Context) error {
+	if !c.cfg.Etcd.Enabled || c.client == nil {
+		return nil
+	}
+	var err error
+	for _, endpoint := range c.client.Endpoints() {
+		if _, err = c.client.Status(ctx, endpoint); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("etcd is unreachable: %w", err)
+}
+
 // Close closes the etcd client.
 func (c *EtcdClient) Close() error {
 	if !c.cfg.Etcd.Enabled || c.client == nil {
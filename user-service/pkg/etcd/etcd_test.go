@@ -50,4 +50,62 @@ func TestEtcdClient_PutConfig(t *testing.T) {
 	if err != nil {
 		t.Errorf("PutConfig() error = %v, want nil", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestEtcdClient_Ping(t *testing.T) {
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "info",
+		},
+		Etcd: config.EtcdConfig{
+			Enabled:   false,
+			Endpoints: []string{"localhost:2379"},
+		},
+	}
+	client, _ := NewEtcdClient(cfg, logger.NewLogger(cfg))
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() with etcd disabled error = %v, want nil", err)
+	}
+}
+
+func TestEtcdClient_ConfigValues(t *testing.T) {
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "info",
+		},
+		Etcd: config.EtcdConfig{
+			Enabled:   false,
+			Endpoints: []string{"localhost:2379"},
+		},
+	}
+	client, _ := NewEtcdClient(cfg, logger.NewLogger(cfg))
+
+	values, err := client.ConfigValues(context.Background())
+	if err != nil || len(values) != 0 {
+		t.Errorf("ConfigValues() with etcd disabled = %v, %v, want no values", values, err)
+	}
+	// Returns at once instead of blocking.
+	client.WatchConfig(context.Background(), func() {
+		t.Error("WatchConfig() with etcd disabled reported a change")
+	})
+}
+
+func TestConfigKey(t *testing.T) {
+	prefix := "/gocampus/user-service/config/"
+	tests := map[string]string{
+		prefix + "service/log_level":             "service.log_level",
+		prefix + "lockout/account/lockout_after": "lockout.account.lockout_after",
+		prefix + "MFA/Required_Roles":            "mfa.required_roles",
+		prefix:                                   "",
+	}
+	for key, want := range tests {
+		if got := configKey(prefix, key); got != want {
+			t.Errorf("configKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
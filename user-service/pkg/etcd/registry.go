@@ -0,0 +1,181 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/registry"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Endpoint is the value of a registration key: how to reach one instance
+// of a service.
+type Endpoint struct {
+	Addr     string            `json:"addr"`
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ServiceKey returns the registration key prefix of service, under which
+// every instance has a key.
+func ServiceKey(prefix, service string) string {
+	return prefix + service + "/"
+}
+
+// registration is a registered instance and the keep-alive of its lease.
+type registration struct {
+	key          string
+	value        string
+	deregistered chan struct{} // closed by DeregisterService
+	done         chan struct{} // closed when the keep-alive has stopped
+
+	// lease and stop are guarded by EtcdClient.mu.
+	lease clientv3.LeaseID
+	stop  context.CancelFunc
+}
+
+// RegisterService registers the instance under etcd.registry_prefix with a
+// lease of etcd.lease_ttl_seconds. The lease is renewed in the background
+// until DeregisterService, so the key of a crashed instance expires on its
+// own; if the lease is lost the instance registers again.
+func (c *EtcdClient) RegisterService(ctx context.Context) error {
+	if !c.cfg.Etcd.Enabled || c.client == nil {
+		c.logger.Info(ctx).Msg("etcd is disabled, skipping service registration")
+		return nil
+	}
+
+	address, err := registry.AdvertiseAddress(c.cfg.Registry)
+	if err != nil {
+		c.logger.Error(ctx).Err(err).Msg("Failed to determine advertise address")
+		return fmt.Errorf("failed to determine advertise address: %w", err)
+	}
+	endpoint := Endpoint{
+		Addr:     net.JoinHostPort(address, strconv.Itoa(c.cfg.Service.Port)),
+		Tags:     c.cfg.Registry.Tags,
+		Metadata: registry.Metadata(c.cfg),
+	}
+	value, err := json.Marshal(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode endpoint: %w", err)
+	}
+	reg := &registration{
+		key:          ServiceKey(c.cfg.Etcd.RegistryPrefix, c.cfg.Service.Name) + endpoint.Addr,
+		value:        string(value),
+		deregistered: make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	keepAlive, err := c.putWithLease(ctx, reg)
+	if err != nil {
+		c.logger.Error(ctx).Err(err).Msg("Failed to register service with etcd")
+		return fmt.Errorf("failed to register service: %w", err)
+	}
+
+	c.mu.Lock()
+	c.registration = reg
+	c.mu.Unlock()
+	go c.keepAlive(reg, keepAlive)
+
+	c.logger.Info(ctx).Msgf("Service registered with etcd at %s", reg.key)
+	return nil
+}
+
+// putWithLease grants a lease, puts the registration under it and starts
+// renewing it. The keep-alive stops when the instance is deregistered.
+func (c *EtcdClient) putWithLease(ctx context.Context, reg *registration) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	lease, err := c.client.Grant(ctx, int64(c.cfg.Etcd.LeaseTTLSeconds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant lease: %w", err)
+	}
+	if _, err := c.client.Put(ctx, reg.key, reg.value, clientv3.WithLease(lease.ID)); err != nil {
+		return nil, fmt.Errorf("failed to put %s: %w", reg.key, err)
+	}
+	// The keep-alive outlives ctx, which only bounds registration.
+	keepAliveCtx, stop := context.WithCancel(context.Background())
+	keepAlive, err := c.client.KeepAlive(keepAliveCtx, lease.ID)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("failed to keep lease alive: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-reg.deregistered:
+		stop()
+	default:
+	}
+	reg.lease, reg.stop = lease.ID, stop
+	return keepAlive, nil
+}
+
+// keepAlive drains the keep-alive responses of reg. When the channel
+// closes before DeregisterService, the lease was lost, e.g. because etcd
+// was unreachable for longer than the TTL, and the instance registers
+// again once etcd is back.
+func (c *EtcdClient) keepAlive(reg *registration, keepAlive <-chan *clientv3.LeaseKeepAliveResponse) {
+	defer close(reg.done)
+	ctx := context.Background()
+	retry := time.Duration(c.cfg.Etcd.LeaseTTLSeconds) * time.Second / 2
+	for {
+		for range keepAlive {
+		}
+		select {
+		case <-reg.deregistered:
+			return
+		default:
+		}
+
+		c.logger.Warn(ctx).Msgf("Lost etcd lease of %s, registering again", reg.key)
+		for {
+			select {
+			case <-reg.deregistered:
+				return
+			case <-time.After(retry):
+			}
+			regCtx, cancel := context.WithTimeout(ctx, retry)
+			var err error
+			keepAlive, err = c.putWithLease(regCtx, reg)
+			cancel()
+			if err == nil {
+				break
+			}
+			c.logger.Error(ctx).Err(err).Msg("Failed to register service with etcd")
+		}
+	}
+}
+
+// DeregisterService deletes the registration and revokes its lease.
+func (c *EtcdClient) DeregisterService(ctx context.Context) error {
+	c.mu.Lock()
+	reg := c.registration
+	c.registration = nil
+	var stop context.CancelFunc
+	if reg != nil {
+		close(reg.deregistered)
+		stop = reg.stop
+	}
+	c.mu.Unlock()
+	if reg == nil {
+		return nil
+	}
+
+	stop()
+	<-reg.done
+	// The key is deleted rather than left to the revoked lease so that
+	// watchers see the instance leave even if the revoke fails.
+	if _, err := c.client.Delete(ctx, reg.key); err != nil {
+		c.logger.Error(ctx).Err(err).Msg("Failed to deregister service from etcd")
+		return fmt.Errorf("failed to deregister service: %w", err)
+	}
+	if _, err := c.client.Revoke(ctx, reg.lease); err != nil {
+		c.logger.Warn(ctx).Err(err).Msg("Failed to revoke etcd lease")
+	}
+
+	c.logger.Info(ctx).Msg("Service deregistered from etcd")
+	return nil
+}
@@ -0,0 +1,241 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcd is an in-memory etcd with the KV, Lease and Watcher calls the
+// registry and the resolver use.
+type fakeEtcd struct {
+	clientv3.KV
+	clientv3.Lease
+	clientv3.Watcher
+
+	mu       sync.Mutex
+	rev      int64
+	kvs      map[string]string
+	leases   map[clientv3.LeaseID]chan struct{} // closed when the lease is lost
+	revoked  []clientv3.LeaseID
+	watchers map[chan clientv3.WatchResponse]string
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{
+		kvs:      make(map[string]string),
+		leases:   make(map[clientv3.LeaseID]chan struct{}),
+		watchers: make(map[chan clientv3.WatchResponse]string),
+	}
+}
+
+func (f *fakeEtcd) client() *clientv3.Client {
+	return &clientv3.Client{KV: f, Lease: f, Watcher: f}
+}
+
+func (f *fakeEtcd) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.kvs[key]
+	return value, ok
+}
+
+// notify sends an event to the watchers of key. f.mu must be held.
+func (f *fakeEtcd) notify(typ mvccpb.Event_EventType, key, value string) {
+	f.rev++
+	ev := &clientv3.Event{Type: typ, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: f.rev}}
+	for ch, prefix := range f.watchers {
+		if strings.HasPrefix(key, prefix) {
+			ch <- clientv3.WatchResponse{Events: []*clientv3.Event{ev}}
+		}
+	}
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefix := clientv3.OpGet(key, opts...).IsOptsWithPrefix()
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.rev}}
+	for k, v := range f.kvs {
+		if k == key || prefix && strings.HasPrefix(k, key) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Put(ctx context.Context, key, value string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.kvs[key] = value
+	f.notify(mvccpb.PUT, key, value)
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeEtcd) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.kvs[key]; ok {
+		delete(f.kvs, key)
+		f.notify(mvccpb.DELETE, key, "")
+	}
+	return &clientv3.DeleteResponse{}, nil
+}
+
+func (f *fakeEtcd) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := clientv3.LeaseID(len(f.leases) + 1)
+	f.leases[id] = make(chan struct{})
+	return &clientv3.LeaseGrantResponse{ID: id, TTL: ttl}, nil
+}
+
+func (f *fakeEtcd) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	f.mu.Lock()
+	lost := f.leases[id]
+	f.mu.Unlock()
+	ch := make(chan *clientv3.LeaseKeepAliveResponse)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+		case <-lost:
+		}
+	}()
+	return ch, nil
+}
+
+// expire loses lease id and deletes key, as etcd does when the lease is
+// not renewed in time.
+func (f *fakeEtcd) expire(id clientv3.LeaseID, key string) {
+	f.mu.Lock()
+	close(f.leases[id])
+	f.mu.Unlock()
+	_, _ = f.Delete(context.Background(), key)
+}
+
+func (f *fakeEtcd) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = append(f.revoked, id)
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse, 16)
+	f.mu.Lock()
+	f.watchers[ch] = key
+	f.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.watchers, ch)
+		close(ch)
+		f.mu.Unlock()
+	}()
+	return ch
+}
+
+func (f *fakeEtcd) Close() error {
+	return nil
+}
+
+func newRegistryClient(t *testing.T, fake *fakeEtcd) *EtcdClient {
+	t.Helper()
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "user-service",
+			Port:     8080,
+			LogLevel: "error",
+			Version:  "1.4.0",
+		},
+		Etcd: config.EtcdConfig{
+			Enabled:         true,
+			RegistryPrefix:  "/gocampus/services/",
+			LeaseTTLSeconds: 1,
+		},
+		Registry: config.RegistryConfig{
+			AdvertiseAddress: "10.0.0.7",
+			Tags:             []string{"grpc"},
+		},
+	}
+	return &EtcdClient{client: fake.client(), logger: logger.NewLogger(cfg), cfg: cfg}
+}
+
+func TestEtcdClient_RegisterService(t *testing.T) {
+	fake := newFakeEtcd()
+	client := newRegistryClient(t, fake)
+	key := "/gocampus/services/user-service/10.0.0.7:8080"
+
+	if err := client.RegisterService(context.Background()); err != nil {
+		t.Fatalf("RegisterService() error = %v", err)
+	}
+	value, ok := fake.get(key)
+	if !ok {
+		t.Fatalf("no registration under %s", key)
+	}
+	var endpoint Endpoint
+	if err := json.Unmarshal([]byte(value), &endpoint); err != nil {
+		t.Fatalf("registration %q is not an Endpoint: %v", value, err)
+	}
+	if endpoint.Addr != "10.0.0.7:8080" || endpoint.Metadata["version"] != "1.4.0" || len(endpoint.Tags) != 1 {
+		t.Errorf("Endpoint = %+v", endpoint)
+	}
+
+	// A lost lease is replaced by a new registration.
+	fake.expire(1, key)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := fake.get(key); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("instance did not register again after losing its lease")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := client.DeregisterService(context.Background()); err != nil {
+		t.Fatalf("DeregisterService() error = %v", err)
+	}
+	if _, ok := fake.get(key); ok {
+		t.Error("registration still present after DeregisterService()")
+	}
+	if len(fake.revoked) != 1 || fake.revoked[0] != 2 {
+		t.Errorf("revoked leases = %v, want the second lease", fake.revoked)
+	}
+	if err := client.DeregisterService(context.Background()); err != nil {
+		t.Errorf("second DeregisterService() error = %v", err)
+	}
+}
+
+func TestEtcdClient_RegisterService_Disabled(t *testing.T) {
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "info",
+		},
+		Etcd: config.EtcdConfig{
+			Enabled:   false,
+			Endpoints: []string{"localhost:2379"},
+		},
+	}
+	client, _ := NewEtcdClient(cfg, logger.NewLogger(cfg))
+
+	if err := client.RegisterService(context.Background()); err != nil {
+		t.Errorf("RegisterService() error = %v, want nil", err)
+	}
+	if err := client.DeregisterService(context.Background()); err != nil {
+		t.Errorf("DeregisterService() error = %v, want nil", err)
+	}
+}
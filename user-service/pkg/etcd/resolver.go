@@ -0,0 +1,146 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC target scheme of services registered in etcd, as in
+// etcd:///user-service.
+const Scheme = "etcd"
+
+// NewResolverBuilder returns a gRPC resolver.Builder for etcd:///<service>
+// targets. It resolves to the instances registered under
+// ServiceKey(prefix, service) and follows them as they come and go. Use it
+// with the round_robin balancer to spread calls across instances:
+//
+//	conn, err := grpc.NewClient("etcd:///user-service",
+//		grpc.WithResolvers(etcd.NewResolverBuilder(client, "/gocampus/services/")),
+//		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+//		grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewResolverBuilder(client *clientv3.Client, prefix string) resolver.Builder {
+	return &resolverBuilder{client: client, prefix: prefix}
+}
+
+// ResolverBuilder returns a resolver.Builder for the services registered
+// under etcd.registry_prefix. etcd must be enabled.
+func (c *EtcdClient) ResolverBuilder() resolver.Builder {
+	return NewResolverBuilder(c.client, c.cfg.Etcd.RegistryPrefix)
+}
+
+type resolverBuilder struct {
+	client *clientv3.Client
+	prefix string
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &etcdResolver{
+		client: b.client,
+		key:    ServiceKey(b.prefix, strings.TrimPrefix(target.Endpoint(), "/")),
+		cc:     cc,
+		cancel: cancel,
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+// etcdResolver keeps the addresses of a ClientConn in sync with the keys
+// under one service prefix.
+type etcdResolver struct {
+	client *clientv3.Client
+	key    string
+	cc     resolver.ClientConn
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// resolveRetry is how long the resolver waits after failing to read the
+// registrations.
+const resolveRetry = time.Second
+
+// watch reads the registrations and then applies every change until ctx is
+// done. If the watch breaks, e.g. because the revision it started from was
+// compacted, the registrations are read again.
+func (r *etcdResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+	for ctx.Err() == nil {
+		resp, err := r.client.Get(ctx, r.key, clientv3.WithPrefix())
+		if err != nil {
+			if ctx.Err() == nil {
+				r.cc.ReportError(err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(resolveRetry):
+			}
+			continue
+		}
+		endpoints := make(map[string]string, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			endpoints[string(kv.Key)] = endpointAddr(kv.Value)
+		}
+		r.update(endpoints)
+
+		watch := r.client.Watch(clientv3.WithRequireLeader(ctx), r.key, clientv3.WithPrefix(), clientv3.WithRev(resp.Header.Revision+1))
+		for wresp := range watch {
+			if wresp.Err() != nil {
+				break
+			}
+			for _, ev := range wresp.Events {
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					endpoints[string(ev.Kv.Key)] = endpointAddr(ev.Kv.Value)
+				case clientv3.EventTypeDelete:
+					delete(endpoints, string(ev.Kv.Key))
+				}
+			}
+			r.update(endpoints)
+		}
+	}
+}
+
+// update passes the addresses of endpoints to the ClientConn.
+func (r *etcdResolver) update(endpoints map[string]string) {
+	addrs := make([]string, 0, len(endpoints))
+	for _, addr := range endpoints {
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	state := resolver.State{Addresses: make([]resolver.Address, len(addrs))}
+	for i, addr := range addrs {
+		state.Addresses[i] = resolver.Address{Addr: addr}
+	}
+	_ = r.cc.UpdateState(state)
+}
+
+// endpointAddr returns the address of an encoded Endpoint, or "" if value
+// is not one.
+func endpointAddr(value []byte) string {
+	var endpoint Endpoint
+	if err := json.Unmarshal(value, &endpoint); err != nil {
+		return ""
+	}
+	return endpoint.Addr
+}
+
+func (r *etcdResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *etcdResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
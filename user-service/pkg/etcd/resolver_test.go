@@ -0,0 +1,73 @@
+package etcd
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeClientConn records the states a resolver reports.
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan []string
+}
+
+func (cc *fakeClientConn) UpdateState(state resolver.State) error {
+	var addrs []string
+	for _, addr := range state.Addresses {
+		addrs = append(addrs, addr.Addr)
+	}
+	cc.states <- addrs
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(err error) {}
+
+func (cc *fakeClientConn) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case addrs := <-cc.states:
+		return addrs
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resolver update")
+		return nil
+	}
+}
+
+func TestResolver(t *testing.T) {
+	fake := newFakeEtcd()
+	ctx := context.Background()
+	prefix := "/gocampus/services/"
+	_, _ = fake.Put(ctx, prefix+"user-service/10.0.0.7:8080", `{"addr":"10.0.0.7:8080"}`)
+	_, _ = fake.Put(ctx, prefix+"course-service/10.0.0.9:8080", `{"addr":"10.0.0.9:8080"}`)
+
+	builder := NewResolverBuilder(fake.client(), prefix)
+	if builder.Scheme() != "etcd" {
+		t.Errorf("Scheme() = %q", builder.Scheme())
+	}
+	cc := &fakeClientConn{states: make(chan []string, 4)}
+	target := resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/user-service"}}
+	r, err := builder.Build(target, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	defer r.Close()
+
+	if addrs := cc.next(t); !reflect.DeepEqual(addrs, []string{"10.0.0.7:8080"}) {
+		t.Errorf("initial addresses = %v", addrs)
+	}
+
+	_, _ = fake.Put(ctx, prefix+"user-service/10.0.0.8:8080", `{"addr":"10.0.0.8:8080"}`)
+	if addrs := cc.next(t); !reflect.DeepEqual(addrs, []string{"10.0.0.7:8080", "10.0.0.8:8080"}) {
+		t.Errorf("addresses after registration = %v", addrs)
+	}
+
+	_, _ = fake.Delete(ctx, prefix+"user-service/10.0.0.7:8080")
+	if addrs := cc.next(t); !reflect.DeepEqual(addrs, []string{"10.0.0.8:8080"}) {
+		t.Errorf("addresses after deregistration = %v", addrs)
+	}
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/textproto"
+	"strconv"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/tracing"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// OpenAPIPath is where the gateway publishes the OpenAPI description of the
+// REST/JSON API.
+const OpenAPIPath = "/openapi.json"
+
+// Gateway serves the REST/JSON mapping of UserService declared by the
+// google.api.http annotations in user.proto. Every request is forwarded to
+// the gRPC server, so it passes through the same authentication,
+// authorization and error mapping as a gRPC call. Errors are written as a
+// JSON google.rpc.Status with the HTTP status matching its code.
+type Gateway struct {
+	mux  *runtime.ServeMux
+	conn *grpc.ClientConn
+}
+
+// New returns a Gateway forwarding to the gRPC server at endpoint, e.g.
+// "localhost:8080". The connection is established lazily.
+func New(endpoint string) (*Gateway, error) {
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithErrorHandler(errorHandler),
+		runtime.WithMiddlewares(traceMiddleware),
+	)
+	if err := userv1.RegisterUserServiceHandler(context.Background(), mux, conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := mux.HandlePath(http.MethodGet, OpenAPIPath, serveOpenAPI); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &Gateway{mux: mux, conn: conn}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Close closes the connection to the gRPC server.
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
+
+// headerMatcher forwards Accept-Language, which selects the language of error
+// messages, in addition to the headers runtime.DefaultHeaderMatcher forwards.
+// The Authorization header is always forwarded by the gateway.
+func headerMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == "Accept-Language" {
+		return "accept-language", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// errorHandler writes the status error like runtime.DefaultHTTPErrorHandler
+// and turns its RetryInfo detail into a Retry-After header.
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+// traceMiddleware continues the trace propagated in the HTTP headers and
+// starts a server span for the request. The span is the parent of the spans
+// the handler starts for the forwarded gRPC call.
+func traceMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route, _ := runtime.HTTPPathPattern(ctx)
+		ctx, span := otel.Tracer("user-service").Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+		next(w, r.WithContext(ctx), pathParams)
+	}
+}
+
+// serveOpenAPI writes the OpenAPI description of the API.
+func serveOpenAPI(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(userv1.OpenAPI)
+}
@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/tracing"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeServer answers GetUserInfo with the metadata it received and fails
+// Login like a blocked login.
+type fakeServer struct {
+	userv1.UnimplementedUserServiceServer
+	traceID trace.TraceID
+}
+
+func (s *fakeServer) GetUserInfo(ctx context.Context, req *userv1.GetUserInfoRequest) (*userv1.GetUserInfoResponse, error) {
+	s.traceID = trace.SpanContextFromContext(ctx).TraceID()
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &userv1.GetUserInfoResponse{
+		Success: true,
+		Message: strings.Join(md.Get("accept-language"), ","),
+		User:    &userv1.UserInfo{UserId: req.UserId, Nickname: strings.Join(md.Get("authorization"), ",")},
+	}, nil
+}
+
+func (s *fakeServer) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
+	st, _ := status.New(codes.ResourceExhausted, "too many failed logins").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+	)
+	return nil, st.Err()
+}
+
+// newGateway starts srv on a loopback port and returns a Gateway for it.
+func newGateway(t *testing.T, srv userv1.UserServiceServer) *Gateway {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(tracing.UnaryServerInterceptor()))
+	userv1.RegisterUserServiceServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	gw, err := New(lis.Addr().String())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	return gw
+}
+
+func TestGateway_ForwardsRequest(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	srv := &fakeServer{}
+	gw := newGateway(t, srv)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/user123", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Accept-Language", "zh-CN")
+	req.Header.Set("Traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/users/user123 status = %d, body %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Message string `json:"message"`
+		User    struct {
+			UserID   string `json:"userId"`
+			Nickname string `json:"nickname"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response %s: %v", rec.Body, err)
+	}
+	if resp.User.UserID != "user123" || resp.User.Nickname != "Bearer token" || resp.Message != "zh-CN" {
+		t.Errorf("response = %+v, want the path parameter, authorization and accept-language forwarded", resp)
+	}
+	if got := srv.traceID.String(); got != "0102030405060708090a0b0c0d0e0f10" {
+		t.Errorf("server trace ID = %s, want the one from traceparent", got)
+	}
+}
+
+func TestGateway_Error(t *testing.T) {
+	gw := newGateway(t, &fakeServer{})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"email":"a@example.com","password":"x"}`))
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("POST /v1/auth/login status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+	var body struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %s: %v", rec.Body, err)
+	}
+	if body.Code != int(codes.ResourceExhausted) || body.Message != "too many failed logins" {
+		t.Errorf("error body = %+v", body)
+	}
+}
+
+func TestGateway_OpenAPI(t *testing.T) {
+	gw := newGateway(t, &fakeServer{})
+
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d", OpenAPIPath, rec.Code)
+	}
+	var spec struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec: %v", err)
+	}
+	for _, path := range []string{"/v1/users", "/v1/auth/login", "/v1/me"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("spec has no path %s", path)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HTTP paths of the liveness and readiness endpoints.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Probe reports whether a dependency is usable. It must return once ctx is
+// done.
+type Probe func(ctx context.Context) error
+
+// check is a named probe. Failing critical probes make the service unready;
+// the others are only reported.
+type check struct {
+	name     string
+	probe    Probe
+	critical bool
+}
+
+// Result is the outcome of one probe.
+type Result struct {
+	Status   string `json:"status"` // "ok" or "failing"
+	Error    string `json:"error,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// Report is the outcome of all probes.
+type Report struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker probes the dependencies of the service and publishes the result
+// through the grpc.health.v1 service and the /healthz and /readyz HTTP
+// endpoints.
+type Checker struct {
+	timeout  time.Duration
+	services []string
+	server   *health.Server
+
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown bool
+}
+
+// NewChecker returns a Checker whose probes each get timeout to answer.
+// services are the gRPC service names whose health status it maintains in
+// addition to the overall status (""); they are reported as not serving
+// until the first round of probes has passed.
+func NewChecker(timeout time.Duration, services ...string) *Checker {
+	c := &Checker{
+		timeout:  timeout,
+		services: append([]string{""}, services...),
+		server:   health.NewServer(),
+	}
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return c
+}
+
+// Add registers a probe that must pass for the service to be ready.
+func (c *Checker) Add(name string, probe Probe) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, probe: probe, critical: true})
+}
+
+// AddOptional registers a probe whose failure is reported but does not make
+// the service unready.
+func (c *Checker) AddOptional(name string, probe Probe) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, probe: probe})
+}
+
+// Server returns the grpc.health.v1 implementation to register on the gRPC
+// server.
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// Check runs every probe concurrently and returns the report. The service is
+// not ready once Shutdown has been called.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	shuttingDown := c.shuttingDown
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func(i int, ch check) {
+			defer wg.Done()
+			results[i] = Result{Status: "ok", Critical: ch.critical}
+			if err := ch.probe(ctx); err != nil {
+				results[i].Status = "failing"
+				results[i].Error = err.Error()
+			}
+		}(i, ch)
+	}
+	wg.Wait()
+
+	report := Report{Ready: !shuttingDown, Checks: make(map[string]Result, len(checks))}
+	for i, ch := range checks {
+		report.Checks[ch.name] = results[i]
+		if ch.critical && results[i].Status != "ok" {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// Run probes the dependencies every interval until ctx is done and sets the
+// gRPC serving status accordingly.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update runs the probes once and publishes the result to the gRPC health
+// service.
+func (c *Checker) update(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if c.Check(ctx).Ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.shuttingDown {
+		return
+	}
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+// Shutdown reports the service as not serving from now on, so that load
+// balancers stop sending new requests while in-flight ones drain.
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shuttingDown = true
+	c.server.Shutdown()
+}
+
+// LivenessHandler answers 200 while the process is able to serve HTTP. It
+// does not probe dependencies: an unreachable database is no reason to
+// restart the service.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
+
+// ReadinessHandler runs the probes and answers 200 with the report when the
+// service is ready, 503 otherwise.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
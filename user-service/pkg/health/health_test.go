@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func servingStatus(t *testing.T, c *Checker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := c.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Check(%q) error = %v", service, err)
+	}
+	return resp.Status
+}
+
+func TestChecker_Check(t *testing.T) {
+	healthy := func(ctx context.Context) error { return nil }
+	broken := func(ctx context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name      string
+		critical  []Probe
+		optional  []Probe
+		wantReady bool
+	}{
+		{name: "All passing", critical: []Probe{healthy}, optional: []Probe{healthy}, wantReady: true},
+		{name: "Critical failing", critical: []Probe{healthy, broken}, wantReady: false},
+		{name: "Critical timing out", critical: []Probe{slow}, wantReady: false},
+		{name: "Optional failing", critical: []Probe{healthy}, optional: []Probe{broken}, wantReady: true},
+		{name: "No probes", wantReady: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(50*time.Millisecond, "user.v1.UserService")
+			for i, p := range tt.critical {
+				c.Add(string(rune('a'+i)), p)
+			}
+			for i, p := range tt.optional {
+				c.AddOptional(string(rune('x'+i)), p)
+			}
+			if got := servingStatus(t, c, "user.v1.UserService"); got != healthpb.HealthCheckResponse_NOT_SERVING {
+				t.Errorf("status before the first check = %v, want NOT_SERVING", got)
+			}
+
+			report := c.Check(context.Background())
+			if report.Ready != tt.wantReady {
+				t.Errorf("Check() = %+v, want ready %v", report, tt.wantReady)
+			}
+			if len(report.Checks) != len(tt.critical)+len(tt.optional) {
+				t.Errorf("Check() reported %d probes", len(report.Checks))
+			}
+
+			c.update(context.Background())
+			want := healthpb.HealthCheckResponse_NOT_SERVING
+			if tt.wantReady {
+				want = healthpb.HealthCheckResponse_SERVING
+			}
+			for _, service := range []string{"", "user.v1.UserService"} {
+				if got := servingStatus(t, c, service); got != want {
+					t.Errorf("status of %q = %v, want %v", service, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("db", func(ctx context.Context) error { return nil })
+	c.update(context.Background())
+	if got := servingStatus(t, c, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v, want SERVING", got)
+	}
+
+	c.Shutdown()
+	c.update(context.Background())
+	if got := servingStatus(t, c, ""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status after Shutdown() = %v, want NOT_SERVING", got)
+	}
+	if c.Check(context.Background()).Ready {
+		t.Errorf("Check() after Shutdown() is ready")
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("postgres", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	rec := httptest.NewRecorder()
+	c.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("%s status = %d, want %d", LivenessPath, rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	c.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("%s status = %d, want %d", ReadinessPath, rec.Code, http.StatusServiceUnavailable)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("%s body %s: %v", ReadinessPath, rec.Body, err)
+	}
+	if got := report.Checks["postgres"]; got.Status != "failing" || got.Error != "connection refused" || !got.Critical {
+		t.Errorf("postgres result = %+v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+)
+
+// Denylist records revoked access tokens until they would have expired anyway.
+// Individual tokens are revoked by jti; RevokeUser revokes every token of a
+// user issued at or before a cutoff, which covers tokens whose jti is unknown.
+type Denylist interface {
+	// RevokeToken denies the token with the given jti until expiresAt.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUser denies every token of userID issued at or before issuedBefore.
+	// The entry can be dropped after expiresAt.
+	RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error
+	// IsRevoked reports whether a token with the given claims has been revoked.
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	// PurgeExpired removes entries that expired before now.
+	PurgeExpired(ctx context.Context, now time.Time) error
+}
+
+// MemoryDenylist is an in-process Denylist. It is only suitable for a single
+// instance or for tests, since entries are not shared or persisted.
+type MemoryDenylist struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[string]userRevocation
+}
+
+type userRevocation struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+// NewMemoryDenylist creates an empty MemoryDenylist.
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]userRevocation),
+	}
+}
+
+// RevokeToken implements Denylist.
+func (d *MemoryDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUser implements Denylist.
+func (d *MemoryDenylist) RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users[userID] = userRevocation{issuedBefore: issuedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+// IsRevoked implements Denylist.
+func (d *MemoryDenylist) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if _, ok := d.tokens[jti]; ok {
+		return true, nil
+	}
+	if rev, ok := d.users[userID]; ok && !issuedAt.After(rev.issuedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// PurgeExpired implements Denylist.
+func (d *MemoryDenylist) PurgeExpired(ctx context.Context, now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for jti, exp := range d.tokens {
+		if exp.Before(now) {
+			delete(d.tokens, jti)
+		}
+	}
+	for userID, rev := range d.users {
+		if rev.expiresAt.Before(now) {
+			delete(d.users, userID)
+		}
+	}
+	return nil
+}
+
+// StartDenylistCleanup purges expired denylist entries every interval until
+// ctx is cancelled.
+func StartDenylistCleanup(ctx context.Context, d Denylist, interval time.Duration, log *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := d.PurgeExpired(ctx, now); err != nil {
+					log.Error(ctx).Err(err).Msg("Failed to purge expired denylist entries")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	d := NewMemoryDenylist()
+	now := time.Now()
+
+	_ = d.RevokeToken(ctx, "jti-1", now.Add(time.Hour))
+	_ = d.RevokeToken(ctx, "jti-expired", now.Add(-time.Minute))
+	_ = d.RevokeUser(ctx, "user123", now, now.Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "Revoked jti", jti: "jti-1", userID: "other", issuedAt: now, want: true},
+		{name: "Token issued before user revocation", jti: "jti-2", userID: "user123", issuedAt: now.Add(-time.Minute), want: true},
+		{name: "Token issued after user revocation", jti: "jti-3", userID: "user123", issuedAt: now.Add(time.Minute), want: false},
+		{name: "Unrelated token", jti: "jti-4", userID: "other", issuedAt: now, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.IsRevoked(ctx, tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := d.PurgeExpired(ctx, now); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if _, ok := d.tokens["jti-expired"]; ok {
+		t.Errorf("PurgeExpired() kept expired entry")
+	}
+	if _, ok := d.tokens["jti-1"]; !ok {
+		t.Errorf("PurgeExpired() removed live entry")
+	}
+}
+
+func TestJWTUtil_Revocation(t *testing.T) {
+	ctx := context.Background()
+	jwtUtil := NewJWTUtil("test-secret", WithDenylist(NewMemoryDenylist()))
+
+	token, _ := jwtUtil.GenerateToken("user123")
+	claims, err := jwtUtil.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatalf("GenerateToken() did not set a jti claim")
+	}
+
+	other, _ := jwtUtil.GenerateToken("user123")
+	if err := jwtUtil.RevokeToken(ctx, claims); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := jwtUtil.Verify(ctx, token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Verify() revoked token error = %v, want %v", err, ErrTokenRevoked)
+	}
+	if _, err := jwtUtil.Verify(ctx, other); err != nil {
+		t.Errorf("Verify() other token error = %v, want nil", err)
+	}
+
+	if err := jwtUtil.RevokeUserTokens(ctx, "user123"); err != nil {
+		t.Fatalf("RevokeUserTokens() error = %v", err)
+	}
+	if _, err := jwtUtil.Verify(ctx, other); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Verify() after RevokeUserTokens error = %v, want %v", err, ErrTokenRevoked)
+	}
+
+	if err := NewJWTUtil("test-secret").RevokeToken(ctx, claims); !errors.Is(err, ErrNoDenylist) {
+		t.Errorf("RevokeToken() without denylist error = %v, want %v", err, ErrNoDenylist)
+	}
+}
@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	claimsKey
+)
+
+// ContextWithUserID returns a copy of ctx carrying the authenticated user ID.
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID stored by the interceptor.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok && userID != ""
+}
+
+// ContextWithClaims returns a copy of ctx carrying verified token claims and
+// the user ID they were issued for.
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	ctx = ContextWithUserID(ctx, claims.UserID)
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the verified token claims stored by the interceptor.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// UnaryServerInterceptor authenticates every unary call except the full
+// method names listed in publicMethods (e.g. "/user.v1.UserService/Login").
+func (j *JWTUtil) UnaryServerInterceptor(publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := methodSet(publicMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := j.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates every streaming call except the full
+// method names listed in publicMethods.
+func (j *JWTUtil) StreamServerInterceptor(publicMethods ...string) grpc.StreamServerInterceptor {
+	public := methodSet(publicMethods)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, err := j.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate validates the bearer token and stores the claims in ctx.
+func (j *JWTUtil) authenticate(ctx context.Context) (context.Context, error) {
+	tokenString, err := TokenFromContext(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	claims, err := j.Verify(ctx, tokenString)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTokenExpired), errors.Is(err, ErrTokenRevoked):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, ErrInvalidToken):
+			return nil, status.Error(codes.Unauthenticated, ErrInvalidToken.Error())
+		default:
+			return nil, status.Error(codes.Internal, "failed to verify token")
+		}
+	}
+	return ContextWithClaims(ctx, claims), nil
+}
+
+func methodSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m] = true
+	}
+	return set
+}
+
+// authenticatedStream overrides the context of a grpc.ServerStream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
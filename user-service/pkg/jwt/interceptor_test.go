@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestJWTUtil_UnaryServerInterceptor(t *testing.T) {
+	jwtUtil := NewJWTUtil("test-secret")
+	token, _ := jwtUtil.GenerateToken("user123")
+	interceptor := jwtUtil.UnaryServerInterceptor("/user.v1.UserService/Login")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		wantCode codes.Code
+		wantID   string
+	}{
+		{
+			name:     "Authenticated call",
+			ctx:      metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token)),
+			method:   "/user.v1.UserService/GetUserInfo",
+			wantCode: codes.OK,
+			wantID:   "user123",
+		},
+		{
+			name:     "Missing token",
+			ctx:      context.Background(),
+			method:   "/user.v1.UserService/GetUserInfo",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Invalid token",
+			ctx:      metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid")),
+			method:   "/user.v1.UserService/GetUserInfo",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Public method",
+			ctx:      context.Background(),
+			method:   "/user.v1.UserService/Login",
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				gotID, _ = UserIDFromContext(ctx)
+				return "ok", nil
+			}
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("interceptor code = %v, want %v", code, tt.wantCode)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("UserIDFromContext() = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestJWTUtil_StreamServerInterceptor(t *testing.T) {
+	jwtUtil := NewJWTUtil("test-secret")
+	token, _ := jwtUtil.GenerateToken("user123")
+	interceptor := jwtUtil.StreamServerInterceptor()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	var gotID string
+	err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/user.v1.UserService/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			gotID, _ = UserIDFromContext(ss.Context())
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if gotID != "user123" {
+		t.Errorf("UserIDFromContext() = %v, want user123", gotID)
+	}
+
+	err = interceptor(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/user.v1.UserService/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("interceptor code = %v, want %v", status.Code(err), codes.Unauthenticated)
+	}
+}
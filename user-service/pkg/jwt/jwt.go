@@ -1,17 +1,299 @@
 package jwt
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
 )
 
+// defaultDuration is used when no token lifetime is configured.
+const defaultDuration = 24 * time.Hour
+
+var (
+	// ErrMissingMetadata is returned when the incoming context carries no gRPC metadata.
+	ErrMissingMetadata = errors.New("missing metadata")
+	// ErrMissingToken is returned when no bearer token is present in the authorization header.
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned when a token fails signature or claim validation.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned when a token is past its expiry time.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenRevoked is returned when a token has been put on the denylist.
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrNoDenylist is returned when revoking tokens without a configured denylist.
+	ErrNoDenylist = errors.New("token denylist is not configured")
+)
+
+// Claims are the JWT claims issued by the user service.
+type Claims struct {
+	UserID string   `json:"user_id"`
+	Roles  []string `json:"roles,omitempty"` // RBAC roles held when the token was issued
+	// MFAEnrollmentRequired restricts the token to enrolling a second factor,
+	// for accounts that must have one but do not yet.
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the token carries role.
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTUtil issues and verifies tokens. It signs with the active key of its
+// KeySet (RS256 or EdDSA) when one is configured, and with the HS256 shared
+// secret otherwise.
+type JWTUtil struct {
+	secret   []byte
+	keys     *KeySet
+	duration time.Duration
+	issuer   string
+	audience string
+	denylist Denylist
+}
+
+// Option configures a JWTUtil.
+type Option func(*JWTUtil)
+
+// WithDuration sets the lifetime of generated tokens.
+func WithDuration(d time.Duration) Option {
+	return func(j *JWTUtil) {
+		if d > 0 {
+			j.duration = d
+		}
+	}
+}
+
+// WithIssuer sets the issuer written into, and required on, every token.
+func WithIssuer(issuer string) Option {
+	return func(j *JWTUtil) { j.issuer = issuer }
+}
+
+// WithAudience sets the audience written into, and required on, every token.
+func WithAudience(audience string) Option {
+	return func(j *JWTUtil) { j.audience = audience }
+}
+
+// WithDenylist makes Verify reject revoked tokens and enables revocation.
+func WithDenylist(d Denylist) Option {
+	return func(j *JWTUtil) { j.denylist = d }
+}
+
+// WithKeySet switches signing and verification to the asymmetric keys in ks.
+// HS256 tokens are no longer accepted once a key set is configured.
+func WithKeySet(ks *KeySet) Option {
+	return func(j *JWTUtil) { j.keys = ks }
+}
+
+// NewJWTUtil creates a JWTUtil signing with the given secret.
+func NewJWTUtil(secret string, opts ...Option) *JWTUtil {
+	j := &JWTUtil{
+		secret:   []byte(secret),
+		duration: defaultDuration,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// NewJWTUtilFromConfig creates a JWTUtil from the JWT section of the config.
+// Extra options are applied after the configured ones.
+func NewJWTUtilFromConfig(cfg *config.Config, opts ...Option) *JWTUtil {
+	return NewJWTUtil(cfg.JWT.Secret, append([]Option{
+		WithDuration(cfg.JWT.AccessTokenDuration()),
+		WithIssuer(cfg.JWT.Issuer),
+		WithAudience(cfg.JWT.Audience),
+	}, opts...)...)
+}
+
+// Duration returns the lifetime of generated tokens.
+func (j *JWTUtil) Duration() time.Duration {
+	return j.duration
+}
+
+// GenerateToken creates a signed JWT token for a user holding the given
+// RBAC roles.
+func (j *JWTUtil) GenerateToken(userID string, roles ...string) (string, error) {
+	return j.GenerateTokenFromClaims(Claims{UserID: userID, Roles: roles})
+}
+
+// GenerateTokenFromClaims signs the private claims of claims. The registered
+// claims (jti, sub, iss, aud, iat, exp) are always set by the JWTUtil.
+func (j *JWTUtil) GenerateTokenFromClaims(claims Claims) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   claims.UserID,
+		Issuer:    j.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(j.duration)),
+	}
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+
+	if j.keys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	}
+	key, err := j.keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// ParseClaims verifies the token signature, expiry, issuer and audience and
+// returns its claims.
+func (j *JWTUtil) ParseClaims(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if j.keys == nil {
+		opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	} else {
+		opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+	}
+	if j.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.issuer))
+	}
+	if j.audience != "" {
+		opts = append(opts, jwt.WithAudience(j.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("%w: missing user_id claim", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// keyFunc returns the verification key for a parsed token.
+func (j *JWTUtil) keyFunc(token *jwt.Token) (interface{}, error) {
+	if j.keys == nil {
+		return j.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys.verificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if key.method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// Verify parses the token like ParseClaims and additionally rejects tokens
+// on the denylist.
+func (j *JWTUtil) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := j.ParseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if j.denylist == nil {
+		return claims, nil
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := j.denylist.IsRevoked(ctx, claims.ID, claims.UserID, issuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token denylist: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// ValidateToken verifies the token and returns the user ID it was issued for.
+func (j *JWTUtil) ValidateToken(tokenString string) (string, error) {
+	claims, err := j.Verify(context.Background(), tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// RevokeToken puts a single token on the denylist until it expires.
+func (j *JWTUtil) RevokeToken(ctx context.Context, claims *Claims) error {
+	if j.denylist == nil {
+		return ErrNoDenylist
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing jti or exp claim", ErrInvalidToken)
+	}
+	return j.denylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// RevokeUserTokens revokes every token issued to userID up to now. Tokens
+// issued within the same second are revoked too, since iat has second precision.
+func (j *JWTUtil) RevokeUserTokens(ctx context.Context, userID string) error {
+	if j.denylist == nil {
+		return ErrNoDenylist
+	}
+	now := time.Now().Truncate(time.Second)
+	return j.denylist.RevokeUser(ctx, userID, now, now.Add(j.duration+time.Second))
+}
+
+// ValidateTokenFromContext verifies the bearer token carried in the incoming
+// gRPC metadata and returns the user ID.
+func (j *JWTUtil) ValidateTokenFromContext(ctx context.Context) (string, error) {
+	tokenString, err := TokenFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	claims, err := j.Verify(ctx, tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// TokenFromContext extracts the token from the "authorization: Bearer <token>"
+// incoming gRPC metadata.
+func TokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ErrMissingMetadata
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", ErrMissingToken
+	}
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
+
 // GenerateToken creates a JWT token for a user.
 func GenerateToken(userID, key string, duration time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(duration).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(key))
-}
\ No newline at end of file
+	return NewJWTUtil(key, WithDuration(duration)).GenerateToken(userID)
+}
+
+// ValidateToken verifies a token signed with key and returns its user ID.
+func ValidateToken(tokenString, key string) (string, error) {
+	return NewJWTUtil(key).ValidateToken(tokenString)
+}
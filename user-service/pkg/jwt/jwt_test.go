@@ -2,9 +2,10 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -85,4 +86,75 @@ func TestJWTUtil_ValidateTokenFromContext(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestJWTUtil_ParseClaims(t *testing.T) {
+	jwtUtil := NewJWTUtil("test-secret", WithIssuer("gocampus-user-service"), WithAudience("gocampus"))
+
+	valid, _ := jwtUtil.GenerateToken("user123")
+	expired, _ := NewJWTUtil("test-secret", WithIssuer("gocampus-user-service"), WithAudience("gocampus"),
+		WithDuration(time.Nanosecond)).GenerateToken("user123")
+	wrongSecret, _ := NewJWTUtil("other-secret", WithIssuer("gocampus-user-service"), WithAudience("gocampus")).GenerateToken("user123")
+	wrongIssuer, _ := NewJWTUtil("test-secret", WithIssuer("someone-else"), WithAudience("gocampus")).GenerateToken("user123")
+	wrongAudience, _ := NewJWTUtil("test-secret", WithIssuer("gocampus-user-service"), WithAudience("other")).GenerateToken("user123")
+	noExp, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user123",
+		"iss":     "gocampus-user-service",
+		"aud":     "gocampus",
+	}).SignedString([]byte("test-secret"))
+	time.Sleep(time.Second)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "Valid token", token: valid},
+		{name: "Expired token", token: expired, wantErr: ErrTokenExpired},
+		{name: "Wrong secret", token: wrongSecret, wantErr: ErrInvalidToken},
+		{name: "Wrong issuer", token: wrongIssuer, wantErr: ErrInvalidToken},
+		{name: "Wrong audience", token: wrongAudience, wantErr: ErrInvalidToken},
+		{name: "Missing exp", token: noExp, wantErr: ErrInvalidToken},
+		{name: "Malformed token", token: "not-a-token", wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := jwtUtil.ParseClaims(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseClaims() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseClaims() error = %v", err)
+			}
+			if claims.UserID != "user123" {
+				t.Errorf("ParseClaims() user_id = %v, want user123", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestJWTUtil_GenerateToken_Roles(t *testing.T) {
+	jwtUtil := NewJWTUtil("test-secret")
+
+	token, err := jwtUtil.GenerateToken("user123", "admin", "moderator")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	claims, err := jwtUtil.ParseClaims(token)
+	if err != nil {
+		t.Fatalf("ParseClaims() error = %v", err)
+	}
+	if len(claims.Roles) != 2 || !claims.HasRole("admin") || !claims.HasRole("moderator") || claims.HasRole("staff") {
+		t.Errorf("ParseClaims() roles = %v, want [admin moderator]", claims.Roles)
+	}
+
+	token, _ = jwtUtil.GenerateToken("user123")
+	claims, _ = jwtUtil.ParseClaims(token)
+	if len(claims.Roles) != 0 {
+		t.Errorf("ParseClaims() roles = %v, want none", claims.Roles)
+	}
+}
@@ -0,0 +1,308 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKSPath is the well-known path the JWKS document is served on.
+const JWKSPath = "/.well-known/jwks.json"
+
+// ErrNoSigningKey is returned when no key with a private key is active.
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// signingKey is a loaded RSA or Ed25519 key.
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	private    crypto.Signer // nil for verify-only keys
+	public     crypto.PublicKey
+	activateAt time.Time
+}
+
+// KeySet holds the asymmetric keys used to sign and verify tokens. Every key
+// verifies; the most recently activated key with a private key signs.
+type KeySet struct {
+	cfgs []config.JWTKeyConfig
+
+	mu      sync.RWMutex
+	keys    map[string]*signingKey
+	ordered []*signingKey // by activateAt, oldest first
+	current *signingKey
+}
+
+// NewKeySet loads the PEM files described by cfgs.
+func NewKeySet(cfgs []config.JWTKeyConfig) (*KeySet, error) {
+	ks := &KeySet{cfgs: cfgs}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload re-reads every key file and re-selects the signing key. On error
+// the previously loaded keys stay in use.
+func (ks *KeySet) Reload() error {
+	keys := make(map[string]*signingKey, len(ks.cfgs))
+	ordered := make([]*signingKey, 0, len(ks.cfgs))
+	for _, kc := range ks.cfgs {
+		if kc.ID == "" {
+			return errors.New("jwt key without id")
+		}
+		if _, dup := keys[kc.ID]; dup {
+			return fmt.Errorf("duplicate jwt key id %q", kc.ID)
+		}
+		key, err := loadKey(kc)
+		if err != nil {
+			return fmt.Errorf("failed to load jwt key %q: %w", kc.ID, err)
+		}
+		keys[kc.ID] = key
+		ordered = append(ordered, key)
+	}
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return ordered[a].activateAt.Before(ordered[b].activateAt)
+	})
+	current := selectSigningKey(ordered, time.Now())
+	if current == nil {
+		return ErrNoSigningKey
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	ks.ordered = ordered
+	ks.current = current
+	return nil
+}
+
+// Rotate selects the newest key with a private key that is active at now,
+// and returns its id, or "" if there is none. The current key is kept when
+// nothing is active.
+func (ks *KeySet) Rotate(now time.Time) string {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if current := selectSigningKey(ks.ordered, now); current != nil {
+		ks.current = current
+	}
+	if ks.current == nil {
+		return ""
+	}
+	return ks.current.id
+}
+
+// selectSigningKey returns the last key in ordered that can sign at now.
+func selectSigningKey(ordered []*signingKey, now time.Time) *signingKey {
+	var current *signingKey
+	for _, key := range ordered {
+		if key.private != nil && !key.activateAt.After(now) {
+			current = key
+		}
+	}
+	return current
+}
+
+// CurrentKeyID returns the id of the key currently used for signing.
+func (ks *KeySet) CurrentKeyID() string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.current == nil {
+		return ""
+	}
+	return ks.current.id
+}
+
+func (ks *KeySet) signingKey() (*signingKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return ks.current, nil
+}
+
+func (ks *KeySet) verificationKey(kid string) (*signingKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// StartKeyRotation reloads key files and re-selects the signing key every
+// interval until ctx is cancelled. If the files cannot be reloaded, the
+// keys already loaded are still activated on schedule.
+func StartKeyRotation(ctx context.Context, ks *KeySet, interval time.Duration, log *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				previous := ks.CurrentKeyID()
+				if err := ks.Reload(); err != nil {
+					log.Error(ctx).Err(err).Msg("Failed to reload JWT signing keys, keeping the loaded keys")
+				}
+				if current := ks.Rotate(now); current != previous {
+					log.Info(ctx).Msgf("Rotated JWT signing key from %q to %q", previous, current)
+				}
+			}
+		}
+	}()
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every loaded key, including keys that are
+// not yet active, so verifiers can cache them before they are used.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	doc := JWKS{Keys: make([]JWK, 0, len(ks.ordered))}
+	for _, key := range ks.ordered {
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	return doc
+}
+
+// ServeHTTP serves the JWKS document.
+func (ks *KeySet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(ks.JWKS())
+}
+
+// loadKey reads the PEM files of one key.
+func loadKey(kc config.JWTKeyConfig) (*signingKey, error) {
+	key := &signingKey{id: kc.ID}
+	if kc.ActivateAt != "" {
+		t, err := time.Parse(time.RFC3339, kc.ActivateAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid activate_at: %w", err)
+		}
+		key.activateAt = t
+	}
+
+	var public crypto.PublicKey
+	switch {
+	case kc.PrivateKeyFile != "":
+		block, err := readPEM(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+		public = private.Public()
+	case kc.PublicKeyFile != "":
+		block, err := readPEM(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		public, err = parsePublicKey(block)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+	key.public = public
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
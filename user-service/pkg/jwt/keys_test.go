@@ -0,0 +1,262 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeRSAKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return writePEM(t, dir, name, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func writeEd25519Key(t *testing.T, dir, name string) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return writePEM(t, dir, name, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func writePEM(t *testing.T, dir, name string, block *pem.Block) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		alg  string
+	}{
+		{name: "RS256", file: writeRSAKey(t, dir, "rsa.pem"), alg: "RS256"},
+		{name: "EdDSA", file: writeEd25519Key(t, dir, "ed25519.pem"), alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := NewKeySet([]config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: tt.file}})
+			if err != nil {
+				t.Fatalf("NewKeySet() error = %v", err)
+			}
+			j := NewJWTUtil("", WithKeySet(ks))
+
+			tokenString, err := j.GenerateToken("user123")
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if token.Method.Alg() != tt.alg || token.Header["kid"] != "k1" {
+				t.Errorf("header = %v, want alg %s and kid k1", token.Header, tt.alg)
+			}
+
+			userID, err := j.ValidateToken(tokenString)
+			if err != nil || userID != "user123" {
+				t.Errorf("ValidateToken() = %q, %v, want user123", userID, err)
+			}
+		})
+	}
+}
+
+func TestKeySet_RejectsHS256(t *testing.T) {
+	ks, err := NewKeySet([]config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: writeEd25519Key(t, t.TempDir(), "k1.pem")}})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	hs, err := NewJWTUtil("test-secret").GenerateToken("user123")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	if _, err := NewJWTUtil("test-secret", WithKeySet(ks)).ValidateToken(hs); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestKeySet_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	next := time.Now().Add(time.Hour)
+	ks, err := NewKeySet([]config.JWTKeyConfig{
+		{ID: "old", PrivateKeyFile: writeRSAKey(t, dir, "old.pem")},
+		{ID: "new", PrivateKeyFile: writeEd25519Key(t, dir, "new.pem"), ActivateAt: next.Format(time.RFC3339)},
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	if got := ks.CurrentKeyID(); got != "old" {
+		t.Fatalf("CurrentKeyID() = %q, want old", got)
+	}
+
+	j := NewJWTUtil("", WithKeySet(ks))
+	oldToken, err := j.GenerateToken("user123")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	if got := ks.Rotate(next.Add(time.Second)); got != "new" {
+		t.Fatalf("Rotate() = %q, want new", got)
+	}
+	newToken, err := j.GenerateToken("user123")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	// Tokens signed with the retired key must still verify.
+	for _, tokenString := range []string{oldToken, newToken} {
+		if _, err := j.ValidateToken(tokenString); err != nil {
+			t.Errorf("ValidateToken() error = %v", err)
+		}
+	}
+}
+
+func TestStartKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := writeRSAKey(t, dir, "old.pem")
+	next := time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+	ks, err := NewKeySet([]config.JWTKeyConfig{
+		{ID: "old", PrivateKeyFile: oldFile},
+		{ID: "new", PrivateKeyFile: writeEd25519Key(t, dir, "new.pem"), ActivateAt: next.Format(time.RFC3339)},
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	// The staged key is activated even though the files can no longer be
+	// reloaded.
+	if err := os.Remove(oldFile); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := logger.NewLogger(&config.Config{Service: config.ServiceConfig{LogLevel: "error"}})
+	StartKeyRotation(ctx, ks, 50*time.Millisecond, log)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ks.CurrentKeyID() != "new" {
+		if time.Now().After(deadline) {
+			t.Fatalf("CurrentKeyID() = %q, want new", ks.CurrentKeyID())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestKeySet_UnknownKid(t *testing.T) {
+	dir := t.TempDir()
+	signer, err := NewKeySet([]config.JWTKeyConfig{{ID: "other", PrivateKeyFile: writeEd25519Key(t, dir, "other.pem")}})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	verifier, err := NewKeySet([]config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: writeEd25519Key(t, dir, "k1.pem")}})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	tokenString, err := NewJWTUtil("", WithKeySet(signer)).GenerateToken("user123")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	if _, err := NewJWTUtil("", WithKeySet(verifier)).ValidateToken(tokenString); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestNewKeySet_Errors(t *testing.T) {
+	dir := t.TempDir()
+	rsaFile := writeRSAKey(t, dir, "rsa.pem")
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name string
+		cfgs []config.JWTKeyConfig
+	}{
+		{name: "No keys", cfgs: nil},
+		{name: "Missing id", cfgs: []config.JWTKeyConfig{{PrivateKeyFile: rsaFile}}},
+		{name: "Duplicate id", cfgs: []config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: rsaFile}, {ID: "k1", PrivateKeyFile: rsaFile}}},
+		{name: "Missing file", cfgs: []config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: filepath.Join(dir, "missing.pem")}}},
+		{name: "Bad activate_at", cfgs: []config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: rsaFile, ActivateAt: "tomorrow"}}},
+		{name: "Not yet active", cfgs: []config.JWTKeyConfig{{ID: "k1", PrivateKeyFile: rsaFile, ActivateAt: future}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeySet(tt.cfgs); err == nil {
+				t.Error("NewKeySet() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestKeySet_ServeHTTP(t *testing.T) {
+	dir := t.TempDir()
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	pubDER, err := x509.MarshalPKIXPublicKey(edPrivate.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	ks, err := NewKeySet([]config.JWTKeyConfig{
+		{ID: "rsa", PrivateKeyFile: writeRSAKey(t, dir, "rsa.pem")},
+		{ID: "retired", PublicKeyFile: writePEM(t, dir, "retired.pub", &pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})},
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ks.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var doc JWKS
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	keys := make(map[string]JWK, len(doc.Keys))
+	for _, k := range doc.Keys {
+		keys[k.Kid] = k
+	}
+	if k := keys["rsa"]; k.Kty != "RSA" || k.Alg != "RS256" || k.Use != "sig" || k.N == "" || k.E != "AQAB" {
+		t.Errorf("rsa JWK = %+v", k)
+	}
+	if k := keys["retired"]; k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+		t.Errorf("retired JWK = %+v", k)
+	}
+
+	rec = httptest.NewRecorder()
+	ks.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, JWKSPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
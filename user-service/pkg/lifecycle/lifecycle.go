@@ -0,0 +1,135 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"google.golang.org/grpc"
+)
+
+// Component is a part of the service started and stopped by a Manager.
+// Start must not block: long-running work such as serving requests runs in
+// a goroutine and reports a premature exit with Manager.Fail. Either
+// function may be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were added and stops them in
+// reverse order, logging how long each step took. Components are added in
+// dependency order, so each one is started after and stopped before the
+// components it uses.
+type Manager struct {
+	logger *logger.Logger
+	failed chan error
+
+	mu         sync.Mutex
+	components []Component
+	running    []bool // running[i] is whether components[i] must be stopped
+	next       int    // index of the next component to start
+}
+
+// New returns an empty Manager.
+func New(log *logger.Logger) *Manager {
+	return &Manager{logger: log, failed: make(chan error, 1)}
+}
+
+// Add appends c to the components. A component without Start counts as
+// running when it is added, wherever it is in the order, so that a resource
+// opened during wiring is released if a later step fails.
+func (m *Manager) Add(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, c)
+	m.running = append(m.running, c.Start == nil)
+}
+
+// Start starts the components that are not running yet, in order. It stops
+// at the first failure; the caller is expected to Stop what was started.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ; m.next < len(m.components); m.next++ {
+		c := m.components[m.next]
+		if c.Start != nil {
+			begin := time.Now()
+			if err := c.Start(ctx); err != nil {
+				m.logger.Error(ctx).Err(err).Dur("duration", time.Since(begin)).Msgf("Failed to start %s", c.Name)
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+			m.logger.Info(ctx).Dur("duration", time.Since(begin)).Msgf("Started %s", c.Name)
+		}
+		m.running[m.next] = true
+	}
+	return nil
+}
+
+// Fail reports that a running component stopped unexpectedly. Only the
+// first failure is kept.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Wait blocks until ctx is done, returning nil, or until a component fails,
+// returning its error.
+func (m *Manager) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-m.failed:
+		return err
+	}
+}
+
+// Stop stops the running components in reverse order. Every component is
+// stopped even if an earlier one fails or ctx expires, so that resources
+// are released; the errors are joined. ctx bounds the whole shutdown.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	begin := time.Now()
+	var errs []error
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if !m.running[i] || c.Stop == nil {
+			continue
+		}
+		m.running[i] = false
+		stepBegin := time.Now()
+		if err := c.Stop(ctx); err != nil {
+			m.logger.Error(ctx).Err(err).Dur("duration", time.Since(stepBegin)).Msgf("Failed to stop %s", c.Name)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name, err))
+			continue
+		}
+		m.logger.Info(ctx).Dur("duration", time.Since(stepBegin)).Msgf("Stopped %s", c.Name)
+	}
+	m.logger.Info(ctx).Dur("duration", time.Since(begin)).Msg("Shutdown complete")
+	return errors.Join(errs...)
+}
+
+// GracefulStop drains the in-flight calls of server until ctx is done and
+// then closes the remaining connections, returning ctx's error.
+func GracefulStop(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+		return fmt.Errorf("drain deadline exceeded, closed remaining connections: %w", ctx.Err())
+	}
+}
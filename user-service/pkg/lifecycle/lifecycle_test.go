@@ -0,0 +1,155 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// recorder returns a component named name that appends its start and stop
+// to events, failing with the given errors.
+func recorder(events *[]string, name string, startErr, stopErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func newManager() *Manager {
+	return New(logger.NewLogger(&config.Config{Service: config.ServiceConfig{LogLevel: "error"}}))
+}
+
+func TestManager_StartStop(t *testing.T) {
+	var events []string
+	m := newManager()
+	m.Add(Component{Name: "db", Stop: func(ctx context.Context) error {
+		events = append(events, "stop db")
+		return nil
+	}})
+	m.Add(recorder(&events, "grpc", nil, nil))
+	m.Add(recorder(&events, "consul", nil, errors.New("agent unreachable")))
+	m.Add(recorder(&events, "health", nil, nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	err := m.Stop(context.Background())
+	if err == nil || err.Error() != "failed to stop consul: agent unreachable" {
+		t.Errorf("Stop() error = %v", err)
+	}
+	want := []string{"start grpc", "start consul", "start health", "stop health", "stop consul", "stop grpc", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	// Stopping twice does nothing.
+	events = nil
+	if err := m.Stop(context.Background()); err != nil || len(events) != 0 {
+		t.Errorf("second Stop() = %v, events %v", err, events)
+	}
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	var events []string
+	m := newManager()
+	m.Add(recorder(&events, "db", nil, nil))
+	m.Add(recorder(&events, "grpc", errors.New("address in use"), nil))
+	m.Add(recorder(&events, "http", nil, nil))
+
+	if err := m.Start(context.Background()); err == nil {
+		t.Fatal("Start() error = nil")
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	want := []string{"start db", "start grpc", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestManager_StopWithoutStart(t *testing.T) {
+	var events []string
+	stopOnly := func(name string) Component {
+		return Component{Name: name, Stop: func(ctx context.Context) error {
+			events = append(events, "stop "+name)
+			return nil
+		}}
+	}
+	m := newManager()
+	m.Add(stopOnly("etcd"))
+	m.Add(recorder(&events, "watcher", nil, nil))
+	m.Add(stopOnly("db"))
+
+	// Wiring failed after the database was opened.
+	if err := m.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	want := []string{"stop db", "stop etcd"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestManager_Wait(t *testing.T) {
+	m := newManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Wait(ctx); err != nil {
+		t.Errorf("Wait() after cancel = %v, want nil", err)
+	}
+
+	failure := errors.New("listener closed")
+	m.Fail(failure)
+	m.Fail(errors.New("ignored"))
+	if err := m.Wait(context.Background()); err != failure {
+		t.Errorf("Wait() = %v, want %v", err, failure)
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+
+	// An open Watch stream keeps the server from draining.
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer conn.Close()
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := GracefulStop(ctx, server); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GracefulStop() error = %v, want the deadline exceeded", err)
+	}
+}
@@ -0,0 +1,110 @@
+package lockout
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Policy is the backoff and lockout schedule applied to one kind of key.
+type Policy struct {
+	// BackoffAfter is the number of failures allowed without delay.
+	BackoffAfter int
+	// BackoffBase is the delay after the first failure past BackoffAfter;
+	// it doubles with each further failure up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// LockoutAfter is the number of failures that locks the key for
+	// LockoutDuration. Zero disables lockouts.
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+// AccountPolicy returns the configured policy for accounts.
+func AccountPolicy(cfg *config.LockoutConfig) Policy {
+	return newPolicy(cfg, cfg.Account)
+}
+
+// IPPolicy returns the configured policy for client IPs.
+func IPPolicy(cfg *config.LockoutConfig) Policy {
+	return newPolicy(cfg, cfg.IP)
+}
+
+func newPolicy(cfg *config.LockoutConfig, t config.LockoutThresholds) Policy {
+	return Policy{
+		BackoffAfter:    t.BackoffAfter,
+		BackoffBase:     cfg.BackoffBase(),
+		BackoffMax:      cfg.BackoffMax(),
+		LockoutAfter:    t.LockoutAfter,
+		LockoutDuration: cfg.LockoutDuration(),
+	}
+}
+
+// Block returns how long to refuse logins after the given number of
+// consecutive failures, and whether that is a lockout rather than a backoff
+// delay.
+func (p Policy) Block(failures int) (time.Duration, bool) {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration, true
+	}
+	if p.BackoffBase <= 0 || failures <= p.BackoffAfter {
+		return 0, false
+	}
+	delay := p.BackoffBase
+	for i := p.BackoffAfter + 1; i < failures; i++ {
+		delay *= 2
+		if p.BackoffMax > 0 && delay >= p.BackoffMax {
+			return p.BackoffMax, false
+		}
+	}
+	if p.BackoffMax > 0 && delay > p.BackoffMax {
+		return p.BackoffMax, false
+	}
+	return delay, false
+}
+
+// ClientIP returns the IP address of the gRPC peer in ctx. Behind a proxy
+// this is the proxy's address, except for calls from the loopback interface:
+// those come from the REST gateway, which appends the address of its HTTP
+// client to the x-forwarded-for metadata.
+func ClientIP(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+	var ip net.IP
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		ip = addr.IP
+	} else if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return "", false
+	}
+	if ip.IsLoopback() {
+		if forwarded, ok := forwardedFor(ctx); ok {
+			return forwarded, true
+		}
+	}
+	return ip.String(), true
+}
+
+// forwardedFor returns the last address in the x-forwarded-for metadata of
+// ctx. Earlier entries are supplied by the client and cannot be trusted.
+func forwardedFor(ctx context.Context) (string, bool) {
+	values := metadata.ValueFromIncomingContext(ctx, "x-forwarded-for")
+	if len(values) == 0 {
+		return "", false
+	}
+	hops := strings.Split(values[len(values)-1], ",")
+	ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1]))
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
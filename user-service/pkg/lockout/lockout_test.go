@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestPolicy_Block(t *testing.T) {
+	p := Policy{
+		BackoffAfter:    3,
+		BackoffBase:     time.Second,
+		BackoffMax:      10 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+	}
+	tests := []struct {
+		failures   int
+		wantDelay  time.Duration
+		wantLocked bool
+	}{
+		{failures: 0},
+		{failures: 3},
+		{failures: 4, wantDelay: time.Second},
+		{failures: 5, wantDelay: 2 * time.Second},
+		{failures: 7, wantDelay: 8 * time.Second},
+		{failures: 8, wantDelay: 10 * time.Second},
+		{failures: 9, wantDelay: 10 * time.Second},
+		{failures: 10, wantDelay: 15 * time.Minute, wantLocked: true},
+		{failures: 12, wantDelay: 15 * time.Minute, wantLocked: true},
+	}
+	for _, tt := range tests {
+		delay, locked := p.Block(tt.failures)
+		if delay != tt.wantDelay || locked != tt.wantLocked {
+			t.Errorf("Block(%d) = %v, %v, want %v, %v", tt.failures, delay, locked, tt.wantDelay, tt.wantLocked)
+		}
+	}
+
+	if delay, locked := (Policy{}).Block(100); delay != 0 || locked {
+		t.Errorf("zero Policy Block() = %v, %v", delay, locked)
+	}
+}
+
+// forwarded returns a context for a call from peerIP carrying the given
+// x-forwarded-for metadata.
+func forwarded(peerIP, xff string) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-forwarded-for", xff))
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(peerIP), Port: 5123}})
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   string
+		wantOK bool
+	}{
+		{name: "No peer", ctx: context.Background()},
+		{
+			name:   "TCP peer",
+			ctx:    peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 5123}}),
+			want:   "10.0.0.7",
+			wantOK: true,
+		},
+		{
+			name:   "IPv6 peer",
+			ctx:    peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 5123}}),
+			want:   "2001:db8::1",
+			wantOK: true,
+		},
+		{
+			name:   "Gateway on loopback",
+			ctx:    forwarded("127.0.0.1", "203.0.113.9, 198.51.100.4"),
+			want:   "198.51.100.4",
+			wantOK: true,
+		},
+		{
+			name:   "Forwarded from remote peer",
+			ctx:    forwarded("10.0.0.7", "198.51.100.4"),
+			want:   "10.0.0.7",
+			wantOK: true,
+		},
+		{
+			name:   "Loopback without forwarding",
+			ctx:    peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv6loopback, Port: 5123}}),
+			want:   "::1",
+			wantOK: true,
+		},
+		{
+			name: "Unix socket peer",
+			ctx:  peer.NewContext(context.Background(), &peer.Peer{Addr: &net.UnixAddr{Name: "/tmp/grpc.sock", Net: "unix"}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ClientIP(tt.ctx)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ClientIP() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -16,22 +16,9 @@ type Logger struct {
 
 // NewLogger creates a new Logger instance.
 func NewLogger(cfg *config.Config) *Logger {
-    var level zerolog.Level
-    switch cfg.Service.LogLevel {
-    case "debug":
-        level = zerolog.DebugLevel
-    case "info":
-        level = zerolog.InfoLevel
-    case "warn":
-        level = zerolog.WarnLevel
-    case "error":
-        level = zerolog.ErrorLevel
-    default:
-        level = zerolog.InfoLevel
-    }
-    zerolog.SetGlobalLevel(level)
+    SetLevel(cfg.Service.LogLevel)
     logger := zerolog.New(os.Stdout).
-        Level(level). // 这里设置实例级别
+        Level(zerolog.DebugLevel). // 实例不过滤，由全局级别控制，便于运行时调整
         With().
         Timestamp().
         Str("service", cfg.Service.Name).
@@ -39,6 +26,21 @@ func NewLogger(cfg *config.Config) *Logger {
     return &Logger{logger: logger}
 }
 
+// SetLevel changes the level of every logger, e.g. after service.log_level
+// was changed at runtime. Unknown levels mean info.
+func SetLevel(level string) {
+    switch level {
+    case "debug":
+        zerolog.SetGlobalLevel(zerolog.DebugLevel)
+    case "warn":
+        zerolog.SetGlobalLevel(zerolog.WarnLevel)
+    case "error":
+        zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+    default:
+        zerolog.SetGlobalLevel(zerolog.InfoLevel)
+    }
+}
+
 // WithContext adds trace ID and span ID from the context to the logger.
 func (l *Logger) WithContext(ctx context.Context) *zerolog.Logger {
     log := l.logger
@@ -82,4 +82,24 @@ func TestLogger_WithContext(t *testing.T) {
 	if logOutput["span_id"] != spanID.String() {
 		t.Errorf("Expected span_id %v, got %v", spanID.String(), logOutput["span_id"])
 	}
-}
\ No newline at end of file
+}
+
+func TestSetLevel(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	cfg := &config.Config{Service: config.ServiceConfig{Name: "test-service", LogLevel: "warn"}}
+	logger := NewLogger(cfg)
+	var buf bytes.Buffer
+	logger.logger = logger.logger.Output(&buf)
+
+	logger.Info(context.Background()).Msg("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("info message logged at warn level: %s", buf.String())
+	}
+
+	SetLevel("debug")
+	logger.Debug(context.Background()).Msg("shown")
+	if !bytes.Contains(buf.Bytes(), []byte("shown")) {
+		t.Errorf("debug message not logged after SetLevel(debug): %q", buf.String())
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,20 +12,41 @@ import (
 // Metrics holds Prometheus metrics collectors.
 type Metrics struct {
 	requestDuration *prometheus.HistogramVec
+	lockouts        *prometheus.CounterVec
 }
 
 // NewMetrics initializes Prometheus metrics.
 func NewMetrics(cfg *config.Config) *Metrics {
-	requestDuration := prometheus.NewHistogramVec(
+	requestDuration := register(prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "user_service_request_duration_seconds",
 			Help:    "Duration of user service requests in seconds",
 			Buckets: prometheus.DefBuckets,
 		},
 		[]string{"method", "status"},
-	)
-	prometheus.MustRegister(requestDuration)
-	return &Metrics{requestDuration: requestDuration}
+	))
+	lockouts := register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_service_login_lockouts_total",
+			Help: "Number of login lockouts, by scope (account or ip)",
+		},
+		[]string{"scope"},
+	))
+	return &Metrics{requestDuration: requestDuration, lockouts: lockouts}
+}
+
+// register registers c, or returns the existing collector if NewMetrics is
+// called more than once in the same process, e.g. from several tests in one
+// package.
+func register[C prometheus.Collector](c C) C {
+	if err := prometheus.Register(c); err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			panic(err)
+		}
+		return are.ExistingCollector.(C)
+	}
+	return c
 }
 
 // RequestDuration returns the request duration histogram.
@@ -32,10 +54,24 @@ func (m *Metrics) RequestDuration() *prometheus.HistogramVec {
 	return m.requestDuration
 }
 
-// StartMetricsServer starts an HTTP server for Prometheus metrics.
-func StartMetricsServer(cfg *config.Config) error {
+// Lockouts returns the counter of login lockouts.
+func (m *Metrics) Lockouts() *prometheus.CounterVec {
+	return m.lockouts
+}
+
+// NewMetricsServer returns an HTTP server for Prometheus metrics. The
+// caller starts it and shuts it down.
+func NewMetricsServer(cfg *config.Config) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
-	addr := ":" + cfg.Metrics.Port
-	return http.ListenAndServe(addr, mux)
+	return &http.Server{
+		Addr:              ":" + cfg.Metrics.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// StartMetricsServer starts an HTTP server for Prometheus metrics.
+func StartMetricsServer(cfg *config.Config) error {
+	return NewMetricsServer(cfg).ListenAndServe()
 }
\ No newline at end of file
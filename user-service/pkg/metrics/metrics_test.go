@@ -27,4 +27,15 @@ func TestNewMetrics(t *testing.T) {
     if count == 0 {
         t.Errorf("Expected request duration metric to be recorded")
     }
-}
\ No newline at end of file
+}
+
+func TestNewMetrics_Lockouts(t *testing.T) {
+    cfg := &config.Config{}
+    first := NewMetrics(cfg)
+    second := NewMetrics(cfg)
+
+    first.Lockouts().WithLabelValues("account").Inc()
+    if got := testutil.ToFloat64(second.Lockouts().WithLabelValues("account")); got < 1 {
+        t.Errorf("Lockouts() across instances = %v, want the shared counter", got)
+    }
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFileNotifier(path)
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		msg := Message{Kind: KindPasswordReset, To: to, Subject: "Reset", Body: "body", Data: map[string]string{"token": "t-" + to}}
+		if err := n.Notify(context.Background(), msg); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	var got []Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		got = append(got, msg)
+	}
+	if len(got) != 2 || got[1].To != "b@example.com" || got[1].Data["token"] != "t-b@example.com" || got[0].SentAt.IsZero() {
+		t.Errorf("file contents = %+v", got)
+	}
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	// The logger writes to the os.Stdout it was created with.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	n := NewLogNotifier(logger.NewLogger(&config.Config{}))
+	os.Stdout = stdout
+
+	const token = "s3cr3t-token"
+	msg := Message{Kind: KindPasswordReset, To: "a@example.com", Subject: "Reset", Body: "Use this code: " + token, Data: map[string]string{"token": token, "expires_at": "soon"}}
+	if err := n.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	if strings.Contains(string(out), token) {
+		t.Errorf("log contains the token: %s", out)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(out, &entry); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if entry["token_sha256"] == nil || entry["expires_at"] != "soon" || entry["message"] != "Use this code: [REDACTED]" {
+		t.Errorf("log entry = %v", entry)
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.NotifierConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "Default", want: "*notify.LogNotifier"},
+		{name: "Log", cfg: config.NotifierConfig{Type: "log"}, want: "*notify.LogNotifier"},
+		{name: "File", cfg: config.NotifierConfig{Type: "file", FilePath: "out.jsonl"}, want: "*notify.FileNotifier"},
+		{name: "File without path", cfg: config.NotifierConfig{Type: "file"}, wantErr: true},
+		{name: "Unknown", cfg: config.NotifierConfig{Type: "smtp"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Notifier: tt.cfg}
+			n, err := NewNotifier(cfg, logger.NewLogger(cfg))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewNotifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if got := fmt.Sprintf("%T", n); got != tt.want {
+					t.Errorf("NewNotifier() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PHC identifiers of the supported algorithms.
+const (
+	IDBcrypt   = "bcrypt"
+	IDArgon2id = "argon2id"
+)
+
+// bcryptSaltLength is the length of the encoded bcrypt salt.
+const bcryptSaltLength = 22
+
+// Bcrypt hashes passwords with bcrypt at Cost. Hashes are stored as
+// $bcrypt$r=<cost>$<salt>$<hash>, with bcrypt's own base64 encoding.
+type Bcrypt struct {
+	Cost int
+}
+
+// ID implements Algorithm.
+func (b Bcrypt) ID() string { return IDBcrypt }
+
+// Hash implements Algorithm.
+func (b Bcrypt) Hash(password string) (PHC, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return PHC{}, err
+	}
+	// hash is $2a$<cost>$<salt><hash>
+	payload := string(hash[len(hash)-53:])
+	return PHC{
+		ID:     IDBcrypt,
+		Params: []Param{{Key: "r", Value: strconv.Itoa(b.Cost)}},
+		Salt:   payload[:bcryptSaltLength],
+		Hash:   payload[bcryptSaltLength:],
+	}, nil
+}
+
+// Verify implements Algorithm.
+func (b Bcrypt) Verify(password string, hash PHC) (bool, error) {
+	cost, err := hash.IntParam("r")
+	if err != nil {
+		return false, err
+	}
+	encoded := fmt.Sprintf("$2a$%02d$%s%s", cost, hash.Salt, hash.Hash)
+	return verifyBcrypt(password, encoded)
+}
+
+// Current implements Algorithm.
+func (b Bcrypt) Current(hash PHC) bool {
+	cost, err := hash.IntParam("r")
+	return err == nil && cost >= b.Cost
+}
+
+// verifyBcrypt compares password with a hash in bcrypt's own format.
+func verifyBcrypt(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Argon2id hashes passwords with Argon2id. Hashes are stored as
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>,
+// with unpadded standard base64.
+type Argon2id struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// ID implements Algorithm.
+func (a Argon2id) ID() string { return IDArgon2id }
+
+// Hash implements Algorithm.
+func (a Argon2id) Hash(password string) (PHC, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return PHC{}, err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return PHC{
+		ID:      IDArgon2id,
+		Version: argon2.Version,
+		Params: []Param{
+			{Key: "m", Value: strconv.FormatUint(uint64(a.Memory), 10)},
+			{Key: "t", Value: strconv.FormatUint(uint64(a.Iterations), 10)},
+			{Key: "p", Value: strconv.FormatUint(uint64(a.Parallelism), 10)},
+		},
+		Salt: base64.RawStdEncoding.EncodeToString(salt),
+		Hash: base64.RawStdEncoding.EncodeToString(key),
+	}, nil
+}
+
+// Verify implements Algorithm.
+func (a Argon2id) Verify(password string, hash PHC) (bool, error) {
+	if hash.Version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2id version %d", hash.Version)
+	}
+	params, err := argon2idParams(hash)
+	if err != nil {
+		return false, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(hash.Salt)
+	if err != nil {
+		return false, errors.New("malformed argon2id salt")
+	}
+	want, err := base64.RawStdEncoding.DecodeString(hash.Hash)
+	if err != nil || len(want) == 0 {
+		return false, errors.New("malformed argon2id hash")
+	}
+	got := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// Current implements Algorithm.
+func (a Argon2id) Current(hash PHC) bool {
+	params, err := argon2idParams(hash)
+	if err != nil || hash.Version != argon2.Version {
+		return false
+	}
+	salt, _ := base64.RawStdEncoding.DecodeString(hash.Salt)
+	key, _ := base64.RawStdEncoding.DecodeString(hash.Hash)
+	return params.Memory >= a.Memory && params.Iterations >= a.Iterations &&
+		params.Parallelism >= a.Parallelism && uint32(len(salt)) >= a.SaltLength &&
+		uint32(len(key)) >= a.KeyLength
+}
+
+// argon2idParams reads the cost parameters of hash.
+func argon2idParams(hash PHC) (Argon2id, error) {
+	m, err := hash.IntParam("m")
+	if err != nil {
+		return Argon2id{}, err
+	}
+	t, err := hash.IntParam("t")
+	if err != nil {
+		return Argon2id{}, err
+	}
+	p, err := hash.IntParam("p")
+	if err != nil {
+		return Argon2id{}, err
+	}
+	if m <= 0 || m > 1<<32-1 || t <= 0 || t > 1<<32-1 || p <= 0 || p > 255 {
+		return Argon2id{}, errors.New("argon2id parameters out of range")
+	}
+	return Argon2id{Memory: uint32(m), Iterations: uint32(t), Parallelism: uint8(p)}, nil
+}
@@ -0,0 +1,146 @@
+// Package passhash hashes passwords in PHC string format and tells when a
+// stored hash should be replaced by one with the configured algorithm and
+// parameters.
+package passhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithm is one password hashing scheme with its parameters.
+type Algorithm interface {
+	// ID is the PHC identifier of the algorithm.
+	ID() string
+	Hash(password string) (PHC, error)
+	// Verify reports whether password matches hash, which has this
+	// algorithm's ID but may have other parameters.
+	Verify(password string, hash PHC) (bool, error)
+	// Current reports whether hash is at least as strong as the hashes this
+	// Algorithm makes.
+	Current(hash PHC) bool
+}
+
+// Hasher makes new hashes with one Algorithm and verifies hashes made by any
+// of the supported ones.
+type Hasher struct {
+	current Algorithm
+	known   map[string]Algorithm
+}
+
+// NewHasher returns a Hasher that hashes with current and also verifies
+// hashes made by others.
+func NewHasher(current Algorithm, others ...Algorithm) *Hasher {
+	h := &Hasher{current: current, known: map[string]Algorithm{}}
+	for _, a := range others {
+		h.known[a.ID()] = a
+	}
+	h.known[current.ID()] = current
+	return h
+}
+
+// New creates a Hasher from cfg. Unset parameters take the defaults of the
+// algorithm, and an empty algorithm selects bcrypt. If cfg is invalid the
+// returned Hasher is still usable, hashing with bcrypt at its default cost,
+// alongside the error.
+func New(cfg config.PasswordHashingConfig) (*Hasher, error) {
+	b := Bcrypt{Cost: cfg.Bcrypt.Cost}
+	if b.Cost == 0 {
+		b.Cost = bcrypt.DefaultCost
+	}
+	a := Argon2id{
+		Memory:      uint32(orDefault(cfg.Argon2id.MemoryKiB, 19456)),
+		Iterations:  uint32(orDefault(cfg.Argon2id.Iterations, 2)),
+		Parallelism: uint8(orDefault(cfg.Argon2id.Parallelism, 1)),
+		SaltLength:  uint32(orDefault(cfg.Argon2id.SaltLength, 16)),
+		KeyLength:   uint32(orDefault(cfg.Argon2id.KeyLength, 32)),
+	}
+	fallback := NewHasher(Bcrypt{Cost: bcrypt.DefaultCost}, a)
+
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", IDBcrypt:
+		if b.Cost < bcrypt.MinCost || b.Cost > bcrypt.MaxCost {
+			return fallback, fmt.Errorf("bcrypt cost %d is outside [%d, %d]", b.Cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return NewHasher(b, a), nil
+	case IDArgon2id:
+		if cfg.Argon2id.MemoryKiB < 0 || cfg.Argon2id.Iterations < 0 || cfg.Argon2id.SaltLength < 0 || cfg.Argon2id.KeyLength < 0 {
+			return fallback, errors.New("argon2id parameters must not be negative")
+		}
+		if cfg.Argon2id.Parallelism < 0 || cfg.Argon2id.Parallelism > 255 {
+			return fallback, fmt.Errorf("argon2id parallelism %d is outside [1, 255]", cfg.Argon2id.Parallelism)
+		}
+		if a.Memory < 8*uint32(a.Parallelism) {
+			return fallback, fmt.Errorf("argon2id memory must be at least %d KiB", 8*uint32(a.Parallelism))
+		}
+		if a.SaltLength < 8 || a.KeyLength < 16 {
+			return fallback, errors.New("argon2id salt must be at least 8 bytes and key at least 16 bytes")
+		}
+		return NewHasher(a, Bcrypt{Cost: bcrypt.DefaultCost}), nil
+	default:
+		return fallback, fmt.Errorf("unknown password hashing algorithm %q", cfg.Algorithm)
+	}
+}
+
+func orDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// Hash hashes password with the current algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	hash, err := h.current.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// Verify reports whether password matches encoded. Besides PHC strings it
+// accepts hashes in bcrypt's own $2a$ format, as stored before hashes were
+// kept in PHC format. A mismatch is not an error; an unreadable or
+// unsupported hash is.
+func (h *Hasher) Verify(password, encoded string) (bool, error) {
+	if isLegacyBcrypt(encoded) {
+		return verifyBcrypt(password, encoded)
+	}
+	hash, err := ParsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	a, ok := h.known[hash.ID]
+	if !ok {
+		return false, fmt.Errorf("unsupported password hash algorithm %q", hash.ID)
+	}
+	return a.Verify(password, hash)
+}
+
+// NeedsRehash reports whether encoded should be replaced by a new hash of the
+// same password: it is not in PHC format, uses another algorithm, or uses
+// weaker parameters than the current ones.
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if isLegacyBcrypt(encoded) {
+		return true
+	}
+	hash, err := ParsePHC(encoded)
+	if err != nil || hash.ID != h.current.ID() {
+		return true
+	}
+	return !h.current.Current(hash)
+}
+
+// isLegacyBcrypt reports whether encoded is in bcrypt's own format.
+func isLegacyBcrypt(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
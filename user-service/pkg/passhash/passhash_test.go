@@ -0,0 +1,145 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id keeps the tests fast; production parameters come from config.
+var testArgon2id = config.Argon2idConfig{MemoryKiB: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestParsePHC(t *testing.T) {
+	tests := []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$bcrypt$r=10$abcdefghijklmnopqrstuv$hash",
+		"$plain",
+		"$scheme$salt",
+	}
+	for _, s := range tests {
+		p, err := ParsePHC(s)
+		if err != nil {
+			t.Errorf("ParsePHC(%q) error = %v", s, err)
+			continue
+		}
+		if got := p.String(); got != s {
+			t.Errorf("ParsePHC(%q).String() = %q", s, got)
+		}
+	}
+
+	p, _ := ParsePHC(tests[0])
+	if p.ID != "argon2id" || p.Version != 19 || p.Salt != "c2FsdHNhbHQ" || p.Hash != "aGFzaA" {
+		t.Errorf("ParsePHC() = %+v", p)
+	}
+	if m, err := p.IntParam("m"); err != nil || m != 64 {
+		t.Errorf("IntParam(m) = %d, %v", m, err)
+	}
+	if _, err := p.IntParam("x"); err == nil {
+		t.Error("IntParam() of a missing parameter expected error")
+	}
+
+	for _, s := range []string{"", "plain", "$", "$id$v=x", "$id$m=1,t$salt", "$id$salt$hash$extra"} {
+		if _, err := ParsePHC(s); err == nil {
+			t.Errorf("ParsePHC(%q) expected error", s)
+		}
+	}
+}
+
+func TestHasher(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.PasswordHashingConfig
+		prefix string
+	}{
+		{"bcrypt", config.PasswordHashingConfig{Algorithm: "bcrypt", Bcrypt: config.BcryptConfig{Cost: bcrypt.MinCost}}, "$bcrypt$r=4$"},
+		{"argon2id", config.PasswordHashingConfig{Algorithm: "argon2id", Argon2id: testArgon2id}, "$argon2id$v=19$m=64,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(tt.cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			hash, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("Hash() = %q, want prefix %q", hash, tt.prefix)
+			}
+			if ok, err := h.Verify("correct horse", hash); err != nil || !ok {
+				t.Errorf("Verify() = %v, %v, want true", ok, err)
+			}
+			if ok, err := h.Verify("wrong horse", hash); err != nil || ok {
+				t.Errorf("Verify() with a wrong password = %v, %v, want false", ok, err)
+			}
+			if h.NeedsRehash(hash) {
+				t.Error("NeedsRehash() of a fresh hash = true")
+			}
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	weak, _ := New(config.PasswordHashingConfig{Algorithm: "bcrypt", Bcrypt: config.BcryptConfig{Cost: bcrypt.MinCost}})
+	weakHash, _ := weak.Hash("correct horse")
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+
+	stronger, _ := New(config.PasswordHashingConfig{Algorithm: "bcrypt", Bcrypt: config.BcryptConfig{Cost: bcrypt.MinCost + 1}})
+	if !stronger.NeedsRehash(weakHash) {
+		t.Error("NeedsRehash() of a lower bcrypt cost = false")
+	}
+	if !weak.NeedsRehash(string(legacy)) {
+		t.Error("NeedsRehash() of a hash in bcrypt's own format = false")
+	}
+	if ok, err := weak.Verify("correct horse", string(legacy)); err != nil || !ok {
+		t.Errorf("Verify() of a hash in bcrypt's own format = %v, %v", ok, err)
+	}
+
+	// Switching algorithms keeps old hashes verifiable until they are replaced
+	argon, _ := New(config.PasswordHashingConfig{Algorithm: "argon2id", Argon2id: testArgon2id})
+	if !argon.NeedsRehash(weakHash) {
+		t.Error("NeedsRehash() of a bcrypt hash with argon2id configured = false")
+	}
+	if ok, err := argon.Verify("correct horse", weakHash); err != nil || !ok {
+		t.Errorf("Verify() of a bcrypt hash with argon2id configured = %v, %v", ok, err)
+	}
+	argonHash, _ := argon.Hash("correct horse")
+	moreMemory := testArgon2id
+	moreMemory.MemoryKiB = 128
+	tuned, _ := New(config.PasswordHashingConfig{Algorithm: "argon2id", Argon2id: moreMemory})
+	if !tuned.NeedsRehash(argonHash) {
+		t.Error("NeedsRehash() of a lower argon2id memory = false")
+	}
+	if ok, err := tuned.Verify("correct horse", argonHash); err != nil || !ok {
+		t.Errorf("Verify() of an argon2id hash with other parameters = %v, %v", ok, err)
+	}
+
+	if _, err := argon.Verify("correct horse", "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA"); err == nil {
+		t.Error("Verify() of an unsupported algorithm expected error")
+	}
+	if _, err := argon.Verify("correct horse", "not a hash"); err == nil {
+		t.Error("Verify() of a malformed hash expected error")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []config.PasswordHashingConfig{
+		{Algorithm: "md5"},
+		{Algorithm: "bcrypt", Bcrypt: config.BcryptConfig{Cost: 40}},
+		{Algorithm: "argon2id", Argon2id: config.Argon2idConfig{Parallelism: 300}},
+		{Algorithm: "argon2id", Argon2id: config.Argon2idConfig{SaltLength: 4}},
+	}
+	for _, cfg := range tests {
+		h, err := New(cfg)
+		if err == nil {
+			t.Errorf("New(%+v) expected error", cfg)
+		}
+		// The fallback still hashes, with bcrypt
+		if hash, err := h.Hash("correct horse"); err != nil || !strings.HasPrefix(hash, "$bcrypt$") {
+			t.Errorf("fallback Hash() = %q, %v", hash, err)
+		}
+	}
+}
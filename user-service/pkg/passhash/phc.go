@@ -0,0 +1,106 @@
+package passhash
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// PHC is a hash in PHC string format:
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+//
+// Salt and Hash are kept in their encoded form, since bcrypt uses its own
+// base64 alphabet.
+type PHC struct {
+	ID      string
+	Version int // zero if absent
+	Params  []Param
+	Salt    string
+	Hash    string
+}
+
+// Param is one parameter of a PHC string.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// ParsePHC parses s in PHC string format.
+func ParsePHC(s string) (PHC, error) {
+	fields := strings.Split(s, "$")
+	if len(fields) < 2 || fields[0] != "" || fields[1] == "" {
+		return PHC{}, errors.New("malformed password hash")
+	}
+	p := PHC{ID: fields[1]}
+	fields = fields[2:]
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "v=") {
+		v, err := strconv.Atoi(strings.TrimPrefix(fields[0], "v="))
+		if err != nil {
+			return PHC{}, errors.New("malformed password hash version")
+		}
+		p.Version = v
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && strings.Contains(fields[0], "=") {
+		for _, kv := range strings.Split(fields[0], ",") {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || key == "" {
+				return PHC{}, errors.New("malformed password hash parameters")
+			}
+			p.Params = append(p.Params, Param{Key: key, Value: value})
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		p.Salt = fields[0]
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		p.Hash = fields[0]
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		return PHC{}, errors.New("malformed password hash")
+	}
+	return p, nil
+}
+
+// String formats p in PHC string format.
+func (p PHC) String() string {
+	var b strings.Builder
+	b.WriteString("$" + p.ID)
+	if p.Version != 0 {
+		b.WriteString("$v=" + strconv.Itoa(p.Version))
+	}
+	if len(p.Params) > 0 {
+		b.WriteString("$")
+		for i, param := range p.Params {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(param.Key + "=" + param.Value)
+		}
+	}
+	if p.Salt != "" {
+		b.WriteString("$" + p.Salt)
+		if p.Hash != "" {
+			b.WriteString("$" + p.Hash)
+		}
+	}
+	return b.String()
+}
+
+// IntParam returns the integer value of parameter key.
+func (p PHC) IntParam(key string) (int, error) {
+	for _, param := range p.Params {
+		if param.Key == key {
+			n, err := strconv.Atoi(param.Value)
+			if err != nil {
+				return 0, errors.New("malformed password hash parameter " + key)
+			}
+			return n, nil
+		}
+	}
+	return 0, errors.New("password hash parameter " + key + " is missing")
+}
@@ -0,0 +1,78 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixLength is the hash prefix length of the Pwned Passwords range API.
+const prefixLength = 5
+
+// BreachedList is a local set of breached password hashes. Like the Pwned
+// Passwords range API it is bucketed by the first five hex characters of the
+// SHA-1 hash (k-anonymity), and a lookup only compares suffixes within one
+// bucket, so it can be swapped for the remote API without changing callers.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedList reads a file of uppercase or lowercase SHA-1 hex hashes,
+// one per line, optionally followed by ":COUNT" as in the Pwned Passwords
+// downloads. Blank lines and lines starting with "#" are ignored.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedList{ranges: make(map[string]map[string]struct{})}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of %s", n, path)
+		}
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password file: %w", err)
+	}
+	return list, nil
+}
+
+func (b *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+	bucket, ok := b.ranges[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		b.ranges[prefix] = bucket
+	}
+	bucket[suffix] = struct{}{}
+}
+
+// Contains reports whether password is in the list.
+func (b *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := b.ranges[hash[:prefixLength]][hash[prefixLength:]]
+	return ok
+}
+
+// Len returns the number of hashes in the list.
+func (b *BreachedList) Len() int {
+	n := 0
+	for _, bucket := range b.ranges {
+		n += len(bucket)
+	}
+	return n
+}
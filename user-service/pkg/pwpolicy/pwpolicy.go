@@ -0,0 +1,143 @@
+package pwpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+// Violation codes. They are stable so clients can localize the messages.
+const (
+	CodeTooShort         = "too_short"
+	CodeTooLong          = "too_long"
+	CodeMissingLowercase = "missing_lowercase"
+	CodeMissingUppercase = "missing_uppercase"
+	CodeMissingDigit     = "missing_digit"
+	CodeMissingSymbol    = "missing_symbol"
+	CodeContainsEmail    = "contains_email"
+	CodeContainsNickname = "contains_nickname"
+	CodeBreached         = "breached"
+)
+
+// minPersonalInfoLength keeps very short nicknames or local parts from
+// ruling out common passwords.
+const minPersonalInfoLength = 3
+
+// Violation is one rule a password breaks.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Error lists every rule a rejected password breaks.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Subject is the account a password is checked for.
+type Subject struct {
+	Email    string
+	Nickname string
+}
+
+// Policy checks passwords against the configured rules.
+type Policy struct {
+	cfg      config.PasswordPolicyConfig
+	breached *BreachedList
+}
+
+// New creates a Policy from cfg, loading the breached-password file if one
+// is configured. If the file cannot be loaded the returned Policy is still
+// usable, without the breached-password check, alongside the error.
+func New(cfg config.PasswordPolicyConfig) (*Policy, error) {
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedFile == "" {
+		return p, nil
+	}
+	list, err := LoadBreachedList(cfg.BreachedFile)
+	if err != nil {
+		return p, err
+	}
+	p.breached = list
+	return p, nil
+}
+
+// Check returns an *Error listing every rule password breaks for subject, or
+// nil if it satisfies the policy.
+func (p *Policy) Check(password string, subject Subject) error {
+	var violations []Violation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.cfg.MinLength {
+		add(CodeTooShort, "must be at least %d characters long", p.cfg.MinLength)
+	}
+	if p.cfg.MaxLength > 0 && len(password) > p.cfg.MaxLength {
+		add(CodeTooLong, "must be at most %d bytes long", p.cfg.MaxLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireLowercase && !lower {
+		add(CodeMissingLowercase, "must contain a lowercase letter")
+	}
+	if p.cfg.RequireUppercase && !upper {
+		add(CodeMissingUppercase, "must contain an uppercase letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		add(CodeMissingDigit, "must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		add(CodeMissingSymbol, "must contain a symbol")
+	}
+
+	if p.cfg.DisallowPersonalInfo {
+		lowered := strings.ToLower(password)
+		email := strings.ToLower(strings.TrimSpace(subject.Email))
+		local, _, _ := strings.Cut(email, "@")
+		if contains(lowered, email) || contains(lowered, local) {
+			add(CodeContainsEmail, "must not contain your email address")
+		}
+		if contains(lowered, strings.ToLower(strings.TrimSpace(subject.Nickname))) {
+			add(CodeContainsNickname, "must not contain your nickname")
+		}
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		add(CodeBreached, "appears in a known data breach")
+	}
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// contains reports whether s contains part, ignoring parts too short to be
+// meaningful.
+func contains(s, part string) bool {
+	return utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(s, part)
+}
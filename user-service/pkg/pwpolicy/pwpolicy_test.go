@@ -0,0 +1,135 @@
+package pwpolicy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+// breachedFixture holds the hashes of "password" (upper case, with a count)
+// and "Password123" (lower case).
+const breachedFixture = `# test fixture
+5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824
+
+b2e98ad6f6eb8508dd6a14cfa704bad7f05f6fb1
+`
+
+func writeBreached(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write breached file: %v", err)
+	}
+	return path
+}
+
+func codes(err error) []string {
+	var perr *Error
+	if !errors.As(err, &perr) {
+		return nil
+	}
+	var out []string
+	for _, v := range perr.Violations {
+		out = append(out, v.Code)
+	}
+	return out
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := New(config.PasswordPolicyConfig{
+		MinLength:            8,
+		MaxLength:            72,
+		RequireLowercase:     true,
+		RequireUppercase:     true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+		BreachedFile:         writeBreached(t, breachedFixture),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	subject := Subject{Email: "Alice.Smith@example.edu", Nickname: "Wombat"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "Valid", password: "Tr0ub4dor&3x", want: nil},
+		{name: "Unicode classes", password: "Пароль-2024x", want: nil},
+		{name: "Short", password: "aB3!", want: []string{CodeTooShort}},
+		{name: "Long", password: "aB3!" + strings.Repeat("x", 70), want: []string{CodeTooLong}},
+		{name: "Digits only", password: "12345678", want: []string{CodeMissingLowercase, CodeMissingUppercase, CodeMissingSymbol}},
+		{name: "Contains email local part", password: "xALICE.SMITH1!", want: []string{CodeContainsEmail}},
+		{name: "Contains nickname", password: "my-wombat-R2", want: []string{CodeContainsNickname}},
+		{name: "Breached", password: "password", want: []string{CodeMissingUppercase, CodeMissingDigit, CodeMissingSymbol, CodeBreached}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password, subject)
+			if got := codes(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) codes = %v, want %v (err = %v)", tt.password, got, tt.want, err)
+			}
+			if tt.want == nil && err != nil {
+				t.Errorf("Check(%q) error = %v", tt.password, err)
+			}
+		})
+	}
+
+	// Short personal info does not count
+	if err := policy.Check("Al!ce-Rocks-9", Subject{Email: "al@example.edu", Nickname: "Al"}); err != nil {
+		t.Errorf("Check() with short personal info error = %v", err)
+	}
+}
+
+func TestPolicy_CheckZeroConfig(t *testing.T) {
+	policy, err := New(config.PasswordPolicyConfig{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := policy.Check("x", Subject{Email: "x@example.edu"}); err != nil {
+		t.Errorf("Check() with an empty policy error = %v", err)
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := &Error{Violations: []Violation{
+		{Code: CodeTooShort, Message: "must be at least 8 characters long"},
+		{Code: CodeMissingDigit, Message: "must contain a digit"},
+	}}
+	want := "password does not meet the policy: must be at least 8 characters long; must contain a digit"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	list, err := LoadBreachedList(writeBreached(t, breachedFixture))
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+	if list.Len() != 2 || !list.Contains("password") || !list.Contains("Password123") || list.Contains("Tr0ub4dor&3x") {
+		t.Errorf("LoadBreachedList() Len = %d", list.Len())
+	}
+
+	if _, err := LoadBreachedList(writeBreached(t, "not-a-hash\n")); err == nil {
+		t.Error("LoadBreachedList() with an invalid line error = nil")
+	}
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadBreachedList() of a missing file error = nil")
+	}
+
+	// A policy whose list fails to load still checks the other rules
+	policy, err := New(config.PasswordPolicyConfig{MinLength: 8, BreachedFile: "missing.txt"})
+	if err == nil || policy == nil {
+		t.Fatalf("New() with a missing file = %v, %v", policy, err)
+	}
+	if got := codes(policy.Check("short", Subject{})); !reflect.DeepEqual(got, []string{CodeTooShort}) {
+		t.Errorf("Check() codes = %v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PermissionStore resolves the permissions carried by a set of roles.
+type PermissionStore interface {
+	GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error)
+}
+
+// Authorizer enforces a Policy using the role claims of the access token.
+type Authorizer struct {
+	policy *Policy
+	store  PermissionStore
+}
+
+// NewAuthorizer creates an Authorizer.
+func NewAuthorizer(policy *Policy, store PermissionStore) *Authorizer {
+	return &Authorizer{policy: policy, store: store}
+}
+
+// UnaryServerInterceptor rejects unary calls whose caller lacks the
+// permission required by the policy. It must run after the JWT interceptor,
+// which stores the token claims in the context.
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.Authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.Authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// Authorize returns a gRPC status error unless the caller in ctx holds the
+// permission the policy requires for method.
+// Tokens flagged as needing MFA enrollment are confined to the policy's
+// enrollment methods.
+func (a *Authorizer) Authorize(ctx context.Context, method string) error {
+	claims, authenticated := jwt.ClaimsFromContext(ctx)
+	if authenticated && claims.MFAEnrollmentRequired && !a.policy.AllowedDuringMFAEnrollment(method) {
+		return status.Error(codes.PermissionDenied, "two-factor enrollment required")
+	}
+	permission, ok := a.policy.Required(method)
+	if !ok {
+		return nil
+	}
+	if !authenticated {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	denied := status.Error(codes.PermissionDenied, fmt.Sprintf("permission %q required", permission))
+	if len(claims.Roles) == 0 {
+		return denied
+	}
+	permissions, err := a.store.GetPermissionsForRoles(ctx, claims.Roles)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to check permissions")
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return nil
+		}
+	}
+	return denied
+}
@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// staticStore maps roles to permissions in memory.
+type staticStore map[string][]string
+
+func (s staticStore) GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	if _, broken := s["broken"]; broken {
+		return nil, errors.New("database unavailable")
+	}
+	var permissions []string
+	for _, role := range roles {
+		permissions = append(permissions, s[role]...)
+	}
+	return permissions, nil
+}
+
+func TestAuthorizer_UnaryServerInterceptor(t *testing.T) {
+	policy := &Policy{Methods: map[string]string{
+		"/user.v1.UserService/GrantRole":     PermissionRolesManage,
+		"/user.v1.UserService/ListUserRoles": PermissionRolesRead,
+	}, MFAEnrollmentMethods: []string{"/user.v1.UserService/EnrollMFA"}}
+	store := staticStore{
+		"admin":     {PermissionRolesRead, PermissionRolesManage},
+		"moderator": {PermissionRolesRead},
+	}
+	withRoles := func(roles ...string) context.Context {
+		return jwt.ContextWithClaims(context.Background(), &jwt.Claims{UserID: "user123", Roles: roles})
+	}
+	enrolling := jwt.ContextWithClaims(context.Background(), &jwt.Claims{UserID: "user123", Roles: []string{"admin"}, MFAEnrollmentRequired: true})
+
+	tests := []struct {
+		name     string
+		store    staticStore
+		ctx      context.Context
+		method   string
+		wantCode codes.Code
+	}{
+		{name: "Admin grants role", ctx: withRoles("admin"), method: "/user.v1.UserService/GrantRole", wantCode: codes.OK},
+		{name: "Moderator lists roles", ctx: withRoles("moderator"), method: "/user.v1.UserService/ListUserRoles", wantCode: codes.OK},
+		{name: "Moderator grants role", ctx: withRoles("moderator"), method: "/user.v1.UserService/GrantRole", wantCode: codes.PermissionDenied},
+		{name: "No roles", ctx: withRoles(), method: "/user.v1.UserService/ListUserRoles", wantCode: codes.PermissionDenied},
+		{name: "Unlisted method", ctx: withRoles(), method: "/user.v1.UserService/GetUserInfo", wantCode: codes.OK},
+		{name: "Unauthenticated", ctx: context.Background(), method: "/user.v1.UserService/GrantRole", wantCode: codes.Unauthenticated},
+		{name: "Enrollment pending allows enrollment", ctx: enrolling, method: "/user.v1.UserService/EnrollMFA", wantCode: codes.OK},
+		{name: "Enrollment pending blocks unlisted method", ctx: enrolling, method: "/user.v1.UserService/GetUserInfo", wantCode: codes.PermissionDenied},
+		{name: "Enrollment pending blocks permissions", ctx: enrolling, method: "/user.v1.UserService/GrantRole", wantCode: codes.PermissionDenied},
+		{name: "Store failure", store: staticStore{"broken": nil}, ctx: withRoles("admin"), method: "/user.v1.UserService/GrantRole", wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store
+			if tt.store != nil {
+				s = tt.store
+			}
+			interceptor := NewAuthorizer(policy, s).UnaryServerInterceptor()
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return "ok", nil
+			}
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("interceptor code = %v, want %v (err = %v)", code, tt.wantCode, err)
+			}
+			if called != (tt.wantCode == codes.OK) {
+				t.Errorf("handler called = %v", called)
+			}
+		})
+	}
+}
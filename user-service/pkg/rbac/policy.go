@@ -0,0 +1,81 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permissions checked by the user service. Roles carry them through the
+// role_permissions table.
+const (
+	PermissionRolesRead      = "roles.read"
+	PermissionRolesManage    = "roles.manage"
+	PermissionLockoutsRead   = "lockouts.read"
+	PermissionLockoutsManage = "lockouts.manage"
+)
+
+// Policy declares the permission each gRPC method requires. Methods without
+// an entry only need a valid access token, or none if they are public.
+type Policy struct {
+	// Methods maps full method names such as
+	// "/user.v1.UserService/GrantRole" to a permission.
+	Methods map[string]string `yaml:"methods"`
+	// MFAEnrollmentMethods lists the full method names still allowed for
+	// tokens of accounts that must enroll a second factor first.
+	MFAEnrollmentMethods []string `yaml:"mfa_enrollment_methods"`
+}
+
+// LoadPolicy reads a YAML policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Required returns the permission method requires, if any.
+func (p *Policy) Required(method string) (string, bool) {
+	permission, ok := p.Methods[method]
+	return permission, ok
+}
+
+// AllowedDuringMFAEnrollment reports whether method may be called by an
+// account that has not yet enrolled a required second factor.
+func (p *Policy) AllowedDuringMFAEnrollment(method string) bool {
+	for _, m := range p.MFAEnrollmentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) validate() error {
+	var errs []error
+	for method, permission := range p.Methods {
+		if !strings.HasPrefix(method, "/") || strings.Count(method, "/") != 2 {
+			errs = append(errs, fmt.Errorf("method %q is not a full gRPC method name", method))
+		}
+		if permission == "" {
+			errs = append(errs, fmt.Errorf("method %q has no permission", method))
+		}
+	}
+	for _, method := range p.MFAEnrollmentMethods {
+		if !strings.HasPrefix(method, "/") || strings.Count(method, "/") != 2 {
+			errs = append(errs, fmt.Errorf("MFA enrollment method %q is not a full gRPC method name", method))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "Valid policy",
+			content: `
+methods:
+  /user.v1.UserService/GrantRole: roles.manage
+  /user.v1.UserService/ListUserRoles: roles.read
+`,
+		},
+		{name: "Empty policy", content: ""},
+		{name: "Short method name", content: "methods:\n  GrantRole: roles.manage\n", wantErr: true},
+		{name: "Missing permission", content: "methods:\n  /user.v1.UserService/GrantRole: \"\"\n", wantErr: true},
+		{name: "Short enrollment method name", content: "mfa_enrollment_methods:\n  - EnrollMFA\n", wantErr: true},
+		{name: "Malformed YAML", content: "methods: [", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := LoadPolicy(writePolicy(t, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.name == "Valid policy" {
+				if got, ok := p.Required("/user.v1.UserService/GrantRole"); !ok || got != PermissionRolesManage {
+					t.Errorf("Required(GrantRole) = %q, %v", got, ok)
+				}
+			}
+			if _, ok := p.Required("/user.v1.UserService/Login"); ok {
+				t.Error("Required(Login) ok = true for an unlisted method")
+			}
+		})
+	}
+
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadPolicy() of a missing file error = nil")
+	}
+}
+
+func TestLoadPolicy_Shipped(t *testing.T) {
+	p, err := LoadPolicy("../../config/policy.yaml")
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	if got, _ := p.Required("/user.v1.UserService/GrantRole"); got != PermissionRolesManage {
+		t.Errorf("Required(GrantRole) = %q, want %q", got, PermissionRolesManage)
+	}
+	if got, _ := p.Required("/user.v1.UserService/UnlockAccount"); got != PermissionLockoutsManage {
+		t.Errorf("Required(UnlockAccount) = %q, want %q", got, PermissionLockoutsManage)
+	}
+	if !p.AllowedDuringMFAEnrollment("/user.v1.UserService/EnrollMFA") || p.AllowedDuringMFAEnrollment("/user.v1.UserService/GetUserInfo") {
+		t.Errorf("MFAEnrollmentMethods = %v", p.MFAEnrollmentMethods)
+	}
+}
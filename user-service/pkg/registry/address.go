@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+// AdvertiseAddressEnv is the environment variable consulted for the
+// advertise address when it is not configured, e.g. the pod IP exposed
+// through the Kubernetes downward API.
+const AdvertiseAddressEnv = "ADVERTISE_ADDRESS"
+
+// AdvertiseAddress returns the address other services should use to reach
+// this instance: the configured advertise address, else the value of
+// AdvertiseAddressEnv, else the IPv4 address of the configured interface or
+// of the interface of the default route.
+func AdvertiseAddress(cfg config.RegistryConfig) (string, error) {
+	if cfg.AdvertiseAddress != "" {
+		return cfg.AdvertiseAddress, nil
+	}
+	if addr := os.Getenv(AdvertiseAddressEnv); addr != "" {
+		return addr, nil
+	}
+	if cfg.Interface != "" {
+		iface, err := net.InterfaceByName(cfg.Interface)
+		if err != nil {
+			return "", fmt.Errorf("failed to find interface %q: %w", cfg.Interface, err)
+		}
+		return interfaceAddress(iface)
+	}
+	return primaryAddress()
+}
+
+// primaryAddress returns the local address of the default route. Connecting
+// a UDP socket only selects the route; nothing is sent. Without a default
+// route it falls back to the first interface that is up.
+func primaryAddress() (string, error) {
+	if conn, err := net.Dial("udp4", "192.0.2.1:9"); err == nil {
+		defer conn.Close()
+		if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && !addr.IP.IsLoopback() {
+			return addr.IP.String(), nil
+		}
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if addr, err := interfaceAddress(&iface); err == nil {
+			return addr, nil
+		}
+	}
+	return "", fmt.Errorf("no network interface with an IPv4 address; set registry.advertise_address or %s", AdvertiseAddressEnv)
+}
+
+// interfaceAddress returns the first IPv4 address of iface.
+func interfaceAddress(iface *net.Interface) (string, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("failed to read addresses of interface %q: %w", iface.Name, err)
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.String(), nil
+		}
+	}
+	return "", fmt.Errorf("interface %q has no IPv4 address", iface.Name)
+}
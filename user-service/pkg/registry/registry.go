@@ -0,0 +1,39 @@
+// Package registry holds what the Consul and etcd registrations of the
+// service have in common.
+package registry
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	userv1 "github.com/Tao-Zzzz/GoCampus/user-service/proto/user/v1"
+)
+
+// Registry types of registry.type.
+const (
+	TypeConsul = "consul"
+	TypeEtcd   = "etcd"
+	TypeNone   = "none"
+)
+
+// Registrar publishes this instance to a service registry.
+type Registrar interface {
+	RegisterService(ctx context.Context) error
+	DeregisterService(ctx context.Context) error
+}
+
+// Metadata returns the metadata published with the instance: the release,
+// the proto package of the API and the HTTP port, overridden by
+// registry.meta.
+func Metadata(cfg *config.Config) map[string]string {
+	meta := map[string]string{
+		"version":       cfg.Service.Version,
+		"proto_package": string(userv1.File_user_v1_user_proto.Package()),
+		"http_port":     strconv.Itoa(cfg.Service.HTTPPort),
+	}
+	for k, v := range cfg.Registry.Meta {
+		meta[k] = v
+	}
+	return meta
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+func TestAdvertiseAddress(t *testing.T) {
+	t.Setenv(AdvertiseAddressEnv, "10.0.0.7")
+
+	addr, err := AdvertiseAddress(config.RegistryConfig{AdvertiseAddress: "user-service.internal"})
+	if err != nil || addr != "user-service.internal" {
+		t.Errorf("AdvertiseAddress(configured) = %q, %v, want the configured address", addr, err)
+	}
+
+	addr, err = AdvertiseAddress(config.RegistryConfig{})
+	if err != nil || addr != "10.0.0.7" {
+		t.Errorf("AdvertiseAddress(env) = %q, %v, want the environment variable", addr, err)
+	}
+
+	t.Setenv(AdvertiseAddressEnv, "")
+	addr, err = AdvertiseAddress(config.RegistryConfig{Interface: "lo"})
+	if err != nil || addr != "127.0.0.1" {
+		t.Errorf("AdvertiseAddress(lo) = %q, %v, want 127.0.0.1", addr, err)
+	}
+
+	if _, err := AdvertiseAddress(config.RegistryConfig{Interface: "does-not-exist0"}); err == nil {
+		t.Error("AdvertiseAddress(unknown interface) error = nil")
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	cfg := &config.Config{
+		Service:  config.ServiceConfig{HTTPPort: 8081, Version: "1.4.0"},
+		Registry: config.RegistryConfig{Meta: map[string]string{"zone": "a", "version": "1.4.0-rc1"}},
+	}
+	want := map[string]string{"version": "1.4.0-rc1", "proto_package": "user.v1", "http_port": "8081", "zone": "a"}
+	meta := Metadata(cfg)
+	if len(meta) != len(want) {
+		t.Errorf("Metadata() = %v, want %v", meta, want)
+	}
+	for k, v := range want {
+		if meta[k] != v {
+			t.Errorf("Metadata()[%q] = %q, want %q", k, meta[k], v)
+		}
+	}
+}
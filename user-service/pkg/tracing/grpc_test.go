@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestInterceptors_PropagateTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+	ctx = trace.ContextWithSpanContext(ctx, parent)
+
+	// The client interceptor writes the outgoing metadata; hand it to the
+	// server interceptor as the incoming metadata of the call.
+	var sent metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := UnaryClientInterceptor()(ctx, "/user.v1.UserService/GetUserInfo", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("UnaryClientInterceptor() error = %v", err)
+	}
+	if got := sent.Get("authorization"); len(got) != 1 {
+		t.Errorf("authorization metadata = %v, want it kept", got)
+	}
+
+	var got trace.SpanContext
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = trace.SpanContextFromContext(ctx)
+		return nil, nil
+	}
+	serverCtx := metadata.NewIncomingContext(context.Background(), sent)
+	if _, err := UnaryServerInterceptor()(serverCtx, nil, &grpc.UnaryServerInfo{}, handler); err != nil {
+		t.Fatalf("UnaryServerInterceptor() error = %v", err)
+	}
+	if got.TraceID() != parent.TraceID() || got.SpanID() != parent.SpanID() || !got.IsRemote() {
+		t.Errorf("server span context = %+v, want remote %+v", got, parent)
+	}
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+// ExporterProbe returns a readiness probe that checks the trace collector
+// accepts connections, or nil when no exporter is configured. Spans are
+// exported in the background, so a failing probe only means traces are
+// being dropped.
+func ExporterProbe(cfg *config.Config) func(ctx context.Context) error {
+	var addr string
+	switch {
+	case cfg.Tracing.JaegerEnabled:
+		addr = collectorAddress(cfg.Tracing.JaegerEndpoint)
+	case cfg.Tracing.OTLPEnabled:
+		addr = collectorAddress(cfg.Tracing.OTLPEndpoint)
+	default:
+		return nil
+	}
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("trace collector %s is unreachable: %w", addr, err)
+		}
+		return conn.Close()
+	}
+}
+
+// collectorAddress returns the host:port of an endpoint given either as a
+// URL, like the Jaeger collector endpoint, or as host:port, like the OTLP
+// endpoint.
+func collectorAddress(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
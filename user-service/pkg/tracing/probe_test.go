@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+)
+
+func TestCollectorAddress(t *testing.T) {
+	tests := map[string]string{
+		"http://jaeger:14268/api/traces": "jaeger:14268",
+		"https://collector.example.com":  "collector.example.com:443",
+		"otel-collector:4317":            "otel-collector:4317",
+		"localhost:4317":                 "localhost:4317",
+	}
+	for endpoint, want := range tests {
+		if got := collectorAddress(endpoint); got != want {
+			t.Errorf("collectorAddress(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestExporterProbe(t *testing.T) {
+	if probe := ExporterProbe(&config.Config{}); probe != nil {
+		t.Errorf("ExporterProbe() with tracing disabled = non-nil")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := lis.Addr().String()
+	cfg := &config.Config{Tracing: config.TracingConfig{OTLPEnabled: true, OTLPEndpoint: addr}}
+	probe := ExporterProbe(cfg)
+	if err := probe(context.Background()); err != nil {
+		t.Errorf("probe() with collector listening error = %v", err)
+	}
+
+	lis.Close()
+	if err := probe(context.Background()); err == nil {
+		t.Errorf("probe() with collector down error = nil")
+	}
+}
@@ -0,0 +1,9 @@
+package userv1
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 2.0 description of the REST/JSON mapping of
+// UserService, generated from user.proto by protoc-gen-openapiv2.
+//
+//go:embed user.swagger.json
+var OpenAPI []byte
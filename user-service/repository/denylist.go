@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RevokeToken implements jwt.Denylist.
+func (r *PostgresRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RevokeToken")
+	defer span.End()
+
+	query := "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING"
+	if _, err := r.db.ExecContext(ctx, query, jti, expiresAt.UTC()); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke token")
+		span.RecordError(err)
+		return errors.New("failed to revoke token")
+	}
+	return nil
+}
+
+// RevokeUser implements jwt.Denylist.
+func (r *PostgresRepository) RevokeUser(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RevokeUser")
+	defer span.End()
+
+	query := `INSERT INTO revoked_users (user_id, issued_before, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET issued_before = EXCLUDED.issued_before, expires_at = EXCLUDED.expires_at`
+	if _, err := r.db.ExecContext(ctx, query, userID, issuedBefore.UTC(), expiresAt.UTC()); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke user tokens")
+		span.RecordError(err)
+		return errors.New("failed to revoke user tokens")
+	}
+
+	r.logger.Info(ctx).Msgf("Revoked tokens issued before %s for user: %s", issuedBefore.UTC().Format(time.RFC3339), userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return nil
+}
+
+// IsRevoked implements jwt.Denylist.
+func (r *PostgresRepository) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.IsRevoked")
+	defer span.End()
+
+	var one int
+	query := `SELECT 1 FROM revoked_tokens WHERE jti = $1
+		UNION ALL
+		SELECT 1 FROM revoked_users WHERE user_id = $2 AND issued_before >= $3
+		LIMIT 1`
+	err := r.db.QueryRowContext(ctx, query, jti, userID, issuedAt.UTC()).Scan(&one)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to check token denylist")
+		span.RecordError(err)
+		return false, errors.New("failed to check token denylist")
+	}
+	return true, nil
+}
+
+// PurgeExpired implements jwt.Denylist.
+func (r *PostgresRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.PurgeExpired")
+	defer span.End()
+
+	for _, query := range []string{
+		"DELETE FROM revoked_tokens WHERE expires_at < $1",
+		"DELETE FROM revoked_users WHERE expires_at < $1",
+	} {
+		if _, err := r.db.ExecContext(ctx, query, now.UTC()); err != nil {
+			r.logger.Error(ctx).Err(err).Msg("Failed to purge expired denylist entries")
+			span.RecordError(err)
+			return errors.New("failed to purge denylist")
+		}
+	}
+	return nil
+}
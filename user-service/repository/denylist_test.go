@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+func TestPostgresRepository_Denylist(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+	CREATE TABLE revoked_tokens (jti TEXT PRIMARY KEY, expires_at TIMESTAMP NOT NULL);
+	CREATE TABLE revoked_users (user_id TEXT PRIMARY KEY, issued_before TIMESTAMP NOT NULL, expires_at TIMESTAMP NOT NULL);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create denylist tables: %v", err)
+	}
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if err := repo.RevokeToken(ctx, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	// Revoking twice is not an error.
+	if err := repo.RevokeToken(ctx, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("RevokeToken() second call error = %v", err)
+	}
+	if err := repo.RevokeToken(ctx, "jti-expired", now.Add(-time.Hour)); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if err := repo.RevokeUser(ctx, "user123", now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("RevokeUser() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "Revoked jti", jti: "jti-1", userID: "other", issuedAt: now, want: true},
+		{name: "Issued before user revocation", jti: "jti-2", userID: "user123", issuedAt: now.Add(-time.Minute), want: true},
+		{name: "Issued after user revocation", jti: "jti-3", userID: "user123", issuedAt: now.Add(time.Minute), want: false},
+		{name: "Unrelated token", jti: "jti-4", userID: "other", issuedAt: now, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.IsRevoked(ctx, tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := repo.PurgeExpired(ctx, now); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM revoked_tokens").Scan(&count)
+	if count != 1 {
+		t.Errorf("PurgeExpired() left %d tokens, want 1", count)
+	}
+}
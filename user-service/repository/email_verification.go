@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CreateEmailVerificationToken stores a new email verification token.
+func (r *PostgresRepository) CreateEmailVerificationToken(ctx context.Context, token *model.EmailVerificationToken) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.CreateEmailVerificationToken")
+	defer span.End()
+
+	query := "INSERT INTO email_verification_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to create email verification token")
+		span.RecordError(err)
+		return errors.New("failed to create email verification token")
+	}
+
+	span.SetAttributes(attribute.String("user_id", token.UserID))
+	return nil
+}
+
+// GetEmailVerificationTokenByHash retrieves an email verification token by
+// the hash of its value.
+func (r *PostgresRepository) GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*model.EmailVerificationToken, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetEmailVerificationTokenByHash")
+	defer span.End()
+
+	token := &model.EmailVerificationToken{}
+	query := "SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM email_verification_tokens WHERE token_hash = $1"
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn(ctx).Msg("Email verification token not found")
+			return nil, fmt.Errorf("email verification token %w", model.ErrNotFound)
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve email verification token")
+		span.RecordError(err)
+		return nil, errors.New("failed to get email verification token")
+	}
+
+	span.SetAttributes(attribute.String("user_id", token.UserID))
+	return token, nil
+}
+
+// VerifyEmail atomically redeems an email verification token and marks the
+// user's email as verified. Every other outstanding verification token of
+// the user is invalidated too. It returns false if the token was already
+// used or has expired.
+func (r *PostgresRepository) VerifyEmail(ctx context.Context, tokenID, userID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.VerifyEmail")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to begin email verification")
+		span.RecordError(err)
+		return false, errors.New("failed to verify email")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE email_verification_tokens SET used_at = $1 WHERE id = $2 AND user_id = $3 AND used_at IS NULL AND expires_at > $1",
+		now, tokenID, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to redeem email verification token")
+		span.RecordError(err)
+		return false, errors.New("failed to verify email")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE email_verification_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL",
+		now, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to invalidate email verification tokens")
+		span.RecordError(err)
+		return false, errors.New("failed to verify email")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET email_verified = TRUE, updated_at = $1, version = version + 1 WHERE id = $2",
+		now, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to mark email as verified")
+		span.RecordError(err)
+		return false, errors.New("failed to verify email")
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to commit email verification")
+		span.RecordError(err)
+		return false, errors.New("failed to verify email")
+	}
+
+	r.logger.Info(ctx).Msgf("Email verified for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
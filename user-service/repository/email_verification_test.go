@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+func TestPostgresRepository_VerifyEmail(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	setupTestDB(t, db)
+	_, err = db.Exec(`
+	CREATE TABLE email_verification_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP,
+		used_at TIMESTAMP
+	)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create email_verification_tokens table: %v", err)
+	}
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+	ctx := context.Background()
+
+	user := &model.User{ID: "user123", Email: "test@example.edu", Password: "hash", Nickname: "TestUser", CreatedAt: time.Now().UTC()}
+	if _, err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to insert test user: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for _, token := range []*model.EmailVerificationToken{
+		{ID: "t1", UserID: "user123", TokenHash: "hash1", ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+		{ID: "t2", UserID: "user123", TokenHash: "hash2", ExpiresAt: now.Add(-time.Minute), CreatedAt: now},
+	} {
+		if err := repo.CreateEmailVerificationToken(ctx, token); err != nil {
+			t.Fatalf("CreateEmailVerificationToken() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetEmailVerificationTokenByHash(ctx, "hash1")
+	if err != nil || got.ID != "t1" || got.Used() {
+		t.Fatalf("GetEmailVerificationTokenByHash() = %+v, %v", got, err)
+	}
+
+	if ok, err := repo.VerifyEmail(ctx, "t2", "user123"); err != nil || ok {
+		t.Errorf("VerifyEmail() with expired token = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.VerifyEmail(ctx, "t1", "user123"); err != nil || !ok {
+		t.Fatalf("VerifyEmail() = %v, %v, want true", ok, err)
+	}
+	if ok, err := repo.VerifyEmail(ctx, "t1", "user123"); err != nil || ok {
+		t.Errorf("VerifyEmail() replay = %v, %v, want false", ok, err)
+	}
+
+	stored, err := repo.GetUserByEmail(ctx, "test@example.edu")
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+	if !stored.EmailVerified || stored.Version != 2 {
+		t.Errorf("GetUserByEmail() email_verified = %v, version = %d", stored.EmailVerified, stored.Version)
+	}
+}
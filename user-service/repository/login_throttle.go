@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetLoginThrottle returns the failed-login state of key, or nil if no
+// failures are recorded.
+func (r *PostgresRepository) GetLoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetLoginThrottle")
+	defer span.End()
+
+	throttle := &model.LoginThrottle{}
+	query := "SELECT throttle_key, failures, last_failure_at, blocked_until, locked FROM login_throttles WHERE throttle_key = $1"
+	err := r.db.QueryRowContext(ctx, query, key).Scan(
+		&throttle.Key,
+		&throttle.Failures,
+		&throttle.LastFailureAt,
+		&throttle.BlockedUntil,
+		&throttle.Locked,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve login throttle")
+		span.RecordError(err)
+		return nil, errors.New("failed to get login throttle")
+	}
+
+	span.SetAttributes(attribute.String("throttle_key", key))
+	return throttle, nil
+}
+
+// RecordLoginFailure counts a failed login for key at now and returns the
+// number of failures recorded. A count whose last failure happened before
+// windowStart starts over.
+func (r *PostgresRepository) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RecordLoginFailure")
+	defer span.End()
+
+	query := `INSERT INTO login_throttles (throttle_key, failures, last_failure_at, locked) VALUES ($1, 1, $2, FALSE)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = excluded.last_failure_at
+		RETURNING failures`
+	var failures int
+	if err := r.db.QueryRowContext(ctx, query, key, now.UTC(), windowStart.UTC()).Scan(&failures); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to record login failure")
+		span.RecordError(err)
+		return 0, errors.New("failed to record login failure")
+	}
+
+	span.SetAttributes(attribute.String("throttle_key", key), attribute.Int("failures", failures))
+	return failures, nil
+}
+
+// BlockLogin refuses logins for key until the given time. locked marks the
+// block as a lockout rather than a backoff delay.
+func (r *PostgresRepository) BlockLogin(ctx context.Context, key string, until time.Time, locked bool) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.BlockLogin")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE login_throttles SET blocked_until = $1, locked = $2 WHERE throttle_key = $3",
+		until.UTC(), locked, key)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to block login")
+		span.RecordError(err)
+		return errors.New("failed to block login")
+	}
+
+	span.SetAttributes(attribute.String("throttle_key", key), attribute.Bool("locked", locked))
+	return nil
+}
+
+// ClearLoginThrottle forgets the failures of key, lifting any block. It
+// returns false if nothing was recorded.
+func (r *PostgresRepository) ClearLoginThrottle(ctx context.Context, key string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.ClearLoginThrottle")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE throttle_key = $1", key)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to clear login throttle")
+		span.RecordError(err)
+		return false, errors.New("failed to clear login throttle")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	span.SetAttributes(attribute.String("throttle_key", key))
+	return true, nil
+}
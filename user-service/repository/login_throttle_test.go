@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// setupLoginThrottleDB creates the login_throttles table.
+func setupLoginThrottleDB(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`
+	CREATE TABLE login_throttles (
+		throttle_key TEXT PRIMARY KEY,
+		failures INTEGER NOT NULL DEFAULT 0,
+		last_failure_at TIMESTAMP NOT NULL,
+		blocked_until TIMESTAMP,
+		locked BOOLEAN NOT NULL DEFAULT FALSE
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create login_throttles table: %v", err)
+	}
+}
+
+func TestPostgresRepository_LoginThrottle(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	setupLoginThrottleDB(t, db)
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+	ctx := context.Background()
+	key := "account:test@example.com"
+
+	if throttle, err := repo.GetLoginThrottle(ctx, key); err != nil || throttle != nil {
+		t.Fatalf("GetLoginThrottle() before failures = %+v, %v", throttle, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for want := 1; want <= 3; want++ {
+		failures, err := repo.RecordLoginFailure(ctx, key, now, now.Add(-time.Hour))
+		if err != nil || failures != want {
+			t.Fatalf("RecordLoginFailure() = %d, %v, want %d", failures, err, want)
+		}
+	}
+
+	until := now.Add(time.Minute)
+	if err := repo.BlockLogin(ctx, key, until, true); err != nil {
+		t.Fatalf("BlockLogin() error = %v", err)
+	}
+	throttle, err := repo.GetLoginThrottle(ctx, key)
+	if err != nil || throttle.Failures != 3 || !throttle.Locked || !throttle.Blocked(now) || throttle.Blocked(until) {
+		t.Fatalf("GetLoginThrottle() = %+v, %v", throttle, err)
+	}
+
+	// Failures before the window start over
+	later := now.Add(2 * time.Hour)
+	if failures, err := repo.RecordLoginFailure(ctx, key, later, later.Add(-time.Hour)); err != nil || failures != 1 {
+		t.Errorf("RecordLoginFailure() after the window = %d, %v, want 1", failures, err)
+	}
+
+	if cleared, err := repo.ClearLoginThrottle(ctx, key); err != nil || !cleared {
+		t.Errorf("ClearLoginThrottle() = %v, %v, want true", cleared, err)
+	}
+	if cleared, err := repo.ClearLoginThrottle(ctx, key); err != nil || cleared {
+		t.Errorf("ClearLoginThrottle() twice = %v, %v, want false", cleared, err)
+	}
+	if throttle, err := repo.GetLoginThrottle(ctx, key); err != nil || throttle != nil {
+		t.Errorf("GetLoginThrottle() after clearing = %+v, %v", throttle, err)
+	}
+}
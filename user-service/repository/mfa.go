@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetMFASettings returns the TOTP settings of a user, or nil if the user has
+// never started enrollment.
+func (r *PostgresRepository) GetMFASettings(ctx context.Context, userID string) (*model.MFASettings, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetMFASettings")
+	defer span.End()
+
+	settings := &model.MFASettings{}
+	query := "SELECT user_id, secret, enabled, last_used_step, created_at, confirmed_at FROM user_mfa WHERE user_id = $1"
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.Secret,
+		&settings.Enabled,
+		&settings.LastUsedStep,
+		&settings.CreatedAt,
+		&settings.ConfirmedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve MFA settings")
+		span.RecordError(err)
+		return nil, errors.New("failed to get MFA settings")
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+	return settings, nil
+}
+
+// SaveMFASecret stores the secret of a pending enrollment, replacing any
+// earlier pending secret. It returns false if the user has already enabled
+// a second factor.
+func (r *PostgresRepository) SaveMFASecret(ctx context.Context, userID, secret string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.SaveMFASecret")
+	defer span.End()
+
+	query := `INSERT INTO user_mfa (user_id, secret, enabled, last_used_step, created_at) VALUES ($1, $2, FALSE, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, created_at = excluded.created_at
+		WHERE user_mfa.enabled = FALSE`
+	res, err := r.db.ExecContext(ctx, query, userID, secret, time.Now().UTC())
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to save MFA secret")
+		span.RecordError(err)
+		return false, errors.New("failed to save MFA secret")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
+
+// EnableMFA atomically activates a pending enrollment, records the step of
+// the code that confirmed it, and replaces the user's recovery codes with
+// the given hashes. It returns false if there was no pending enrollment.
+func (r *PostgresRepository) EnableMFA(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.EnableMFA")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to begin MFA enrollment")
+		span.RecordError(err)
+		return false, errors.New("failed to enable MFA")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_mfa SET enabled = TRUE, last_used_step = $1, confirmed_at = $2 WHERE user_id = $3 AND enabled = FALSE",
+		step, now, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to enable MFA")
+		span.RecordError(err)
+		return false, errors.New("failed to enable MFA")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to delete old recovery codes")
+		span.RecordError(err)
+		return false, errors.New("failed to enable MFA")
+	}
+	for _, hash := range recoveryCodeHashes {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)",
+			uuid.New().String(), userID, hash, now)
+		if err != nil {
+			r.logger.Error(ctx).Err(err).Msg("Failed to store recovery code")
+			span.RecordError(err)
+			return false, errors.New("failed to enable MFA")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to commit MFA enrollment")
+		span.RecordError(err)
+		return false, errors.New("failed to enable MFA")
+	}
+
+	r.logger.Info(ctx).Msgf("MFA enabled for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
+
+// DisableMFA removes the second factor and recovery codes of a user.
+func (r *PostgresRepository) DisableMFA(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.DisableMFA")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to begin disabling MFA")
+		span.RecordError(err)
+		return errors.New("failed to disable MFA")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to delete recovery codes")
+		span.RecordError(err)
+		return errors.New("failed to disable MFA")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to delete MFA settings")
+		span.RecordError(err)
+		return errors.New("failed to disable MFA")
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to commit disabling MFA")
+		span.RecordError(err)
+		return errors.New("failed to disable MFA")
+	}
+
+	r.logger.Info(ctx).Msgf("MFA disabled for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return nil
+}
+
+// UpdateMFALastStep records the TOTP step of an accepted code. It returns
+// false if a code of the same or a later step was already accepted, which
+// means the code is being replayed.
+func (r *PostgresRepository) UpdateMFALastStep(ctx context.Context, userID string, step int64) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.UpdateMFALastStep")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_mfa SET last_used_step = $1 WHERE user_id = $2 AND enabled = TRUE AND last_used_step < $1",
+		step, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to update MFA step")
+		span.RecordError(err)
+		return false, errors.New("failed to verify code")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
+
+// UseRecoveryCode redeems one of the user's recovery codes by its hash. It
+// returns false if no unused code matches.
+func (r *PostgresRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.UseRecoveryCode")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE mfa_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL",
+		time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to redeem recovery code")
+		span.RecordError(err)
+		return false, errors.New("failed to verify code")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	r.logger.Info(ctx).Msgf("Recovery code used by user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
+
+// CreateMFAChallenge stores a new MFA challenge.
+func (r *PostgresRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.CreateMFAChallenge")
+	defer span.End()
+
+	query := "INSERT INTO mfa_challenges (id, user_id, token_hash, attempts, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err := r.db.ExecContext(ctx, query, challenge.ID, challenge.UserID, challenge.TokenHash, challenge.Attempts, challenge.ExpiresAt, challenge.CreatedAt)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to create MFA challenge")
+		span.RecordError(err)
+		return errors.New("failed to create MFA challenge")
+	}
+
+	span.SetAttributes(attribute.String("user_id", challenge.UserID))
+	return nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token.
+func (r *PostgresRepository) GetMFAChallengeByHash(ctx context.Context, hash string) (*model.MFAChallenge, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetMFAChallengeByHash")
+	defer span.End()
+
+	challenge := &model.MFAChallenge{}
+	query := "SELECT id, user_id, token_hash, attempts, expires_at, created_at, used_at FROM mfa_challenges WHERE token_hash = $1"
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.TokenHash,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.CreatedAt,
+		&challenge.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn(ctx).Msg("MFA challenge not found")
+			return nil, fmt.Errorf("mfa challenge %w", model.ErrNotFound)
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve MFA challenge")
+		span.RecordError(err)
+		return nil, errors.New("failed to get MFA challenge")
+	}
+
+	span.SetAttributes(attribute.String("user_id", challenge.UserID))
+	return challenge, nil
+}
+
+// RecordMFAChallengeFailure counts a wrong code entered for a challenge.
+func (r *PostgresRepository) RecordMFAChallengeFailure(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RecordMFAChallengeFailure")
+	defer span.End()
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1", id); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to record MFA challenge failure")
+		span.RecordError(err)
+		return errors.New("failed to update MFA challenge")
+	}
+	return nil
+}
+
+// RedeemMFAChallenge marks a challenge as used. It returns false if the
+// challenge was already used or has expired.
+func (r *PostgresRepository) RedeemMFAChallenge(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RedeemMFAChallenge")
+	defer span.End()
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE mfa_challenges SET used_at = $1 WHERE id = $2 AND used_at IS NULL AND expires_at > $1",
+		now, id)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to redeem MFA challenge")
+		span.RecordError(err)
+		return false, errors.New("failed to redeem MFA challenge")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// setupMFADB creates the MFA tables.
+func setupMFADB(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`
+	CREATE TABLE user_mfa (
+		user_id TEXT PRIMARY KEY,
+		secret TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		last_used_step INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP,
+		confirmed_at TIMESTAMP
+	);
+	CREATE TABLE mfa_recovery_codes (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		code_hash TEXT NOT NULL,
+		created_at TIMESTAMP,
+		used_at TIMESTAMP,
+		UNIQUE (user_id, code_hash)
+	);
+	CREATE TABLE mfa_challenges (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP,
+		used_at TIMESTAMP
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create MFA tables: %v", err)
+	}
+}
+
+func newMFATestRepo(t *testing.T) *PostgresRepository {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	setupMFADB(t, db)
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	return &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+}
+
+func TestPostgresRepository_MFAEnrollment(t *testing.T) {
+	repo := newMFATestRepo(t)
+	ctx := context.Background()
+
+	if settings, err := repo.GetMFASettings(ctx, "user123"); err != nil || settings != nil {
+		t.Fatalf("GetMFASettings() before enrollment = %+v, %v", settings, err)
+	}
+	for _, secret := range []string{"SECRET1", "SECRET2"} {
+		if saved, err := repo.SaveMFASecret(ctx, "user123", secret); err != nil || !saved {
+			t.Fatalf("SaveMFASecret(%s) = %v, %v", secret, saved, err)
+		}
+	}
+	settings, err := repo.GetMFASettings(ctx, "user123")
+	if err != nil || settings.Secret != "SECRET2" || settings.Enabled {
+		t.Fatalf("GetMFASettings() pending = %+v, %v", settings, err)
+	}
+
+	if enabled, err := repo.EnableMFA(ctx, "user123", 100, []string{"h1", "h2"}); err != nil || !enabled {
+		t.Fatalf("EnableMFA() = %v, %v", enabled, err)
+	}
+	if enabled, err := repo.EnableMFA(ctx, "user123", 101, []string{"h3"}); err != nil || enabled {
+		t.Errorf("EnableMFA() twice = %v, %v, want false", enabled, err)
+	}
+	if saved, err := repo.SaveMFASecret(ctx, "user123", "SECRET3"); err != nil || saved {
+		t.Errorf("SaveMFASecret() after enabling = %v, %v, want false", saved, err)
+	}
+	settings, _ = repo.GetMFASettings(ctx, "user123")
+	if !settings.Enabled || settings.Secret != "SECRET2" || settings.LastUsedStep != 100 || settings.ConfirmedAt == nil {
+		t.Errorf("GetMFASettings() enabled = %+v", settings)
+	}
+
+	// Steps must increase
+	if ok, err := repo.UpdateMFALastStep(ctx, "user123", 100); err != nil || ok {
+		t.Errorf("UpdateMFALastStep(100) = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.UpdateMFALastStep(ctx, "user123", 101); err != nil || !ok {
+		t.Errorf("UpdateMFALastStep(101) = %v, %v, want true", ok, err)
+	}
+
+	// Recovery codes are single-use
+	if ok, err := repo.UseRecoveryCode(ctx, "user123", "h1"); err != nil || !ok {
+		t.Errorf("UseRecoveryCode(h1) = %v, %v, want true", ok, err)
+	}
+	if ok, err := repo.UseRecoveryCode(ctx, "user123", "h1"); err != nil || ok {
+		t.Errorf("UseRecoveryCode(h1) twice = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.UseRecoveryCode(ctx, "other", "h2"); err != nil || ok {
+		t.Errorf("UseRecoveryCode() of another user's code = %v, %v, want false", ok, err)
+	}
+
+	if err := repo.DisableMFA(ctx, "user123"); err != nil {
+		t.Fatalf("DisableMFA() error = %v", err)
+	}
+	if settings, err := repo.GetMFASettings(ctx, "user123"); err != nil || settings != nil {
+		t.Errorf("GetMFASettings() after disabling = %+v, %v", settings, err)
+	}
+	if ok, _ := repo.UseRecoveryCode(ctx, "user123", "h2"); ok {
+		t.Error("UseRecoveryCode() after disabling = true")
+	}
+}
+
+func TestPostgresRepository_MFAChallenge(t *testing.T) {
+	repo := newMFATestRepo(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for _, challenge := range []*model.MFAChallenge{
+		{ID: "c1", UserID: "user123", TokenHash: "hash1", ExpiresAt: now.Add(5 * time.Minute), CreatedAt: now},
+		{ID: "c2", UserID: "user123", TokenHash: "hash2", ExpiresAt: now.Add(-time.Minute), CreatedAt: now},
+	} {
+		if err := repo.CreateMFAChallenge(ctx, challenge); err != nil {
+			t.Fatalf("CreateMFAChallenge() error = %v", err)
+		}
+	}
+
+	if err := repo.RecordMFAChallengeFailure(ctx, "c1"); err != nil {
+		t.Fatalf("RecordMFAChallengeFailure() error = %v", err)
+	}
+	stored, err := repo.GetMFAChallengeByHash(ctx, "hash1")
+	if err != nil || stored.ID != "c1" || stored.Attempts != 1 || stored.Used() {
+		t.Fatalf("GetMFAChallengeByHash() = %+v, %v", stored, err)
+	}
+	if _, err := repo.GetMFAChallengeByHash(ctx, "unknown"); err == nil {
+		t.Error("GetMFAChallengeByHash(unknown) expected error")
+	}
+
+	if ok, err := repo.RedeemMFAChallenge(ctx, "c1"); err != nil || !ok {
+		t.Errorf("RedeemMFAChallenge(c1) = %v, %v, want true", ok, err)
+	}
+	if ok, err := repo.RedeemMFAChallenge(ctx, "c1"); err != nil || ok {
+		t.Errorf("RedeemMFAChallenge(c1) twice = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.RedeemMFAChallenge(ctx, "c2"); err != nil || ok {
+		t.Errorf("RedeemMFAChallenge(expired) = %v, %v, want false", ok, err)
+	}
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UpdatePassword replaces the password hash of a user.
+func (r *PostgresRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.UpdatePassword")
+	defer span.End()
+
+	query := "UPDATE users SET password = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	res, err := r.db.ExecContext(ctx, query, passwordHash, time.Now().UTC(), userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to update password")
+		span.RecordError(err)
+		return errors.New("failed to update password")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		r.logger.Warn(ctx).Msg("User not found by ID")
+		return fmt.Errorf("user %w", model.ErrNotFound)
+	}
+
+	r.logger.Info(ctx).Msgf("Password updated for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return nil
+}
+
+// RehashPassword replaces the password hash of a user with a new hash of the
+// same password. The version is left alone, since the password did not
+// change. It returns false if the stored hash is no longer oldHash, e.g.
+// because the password changed in the meantime.
+func (r *PostgresRepository) RehashPassword(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RehashPassword")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2 AND password = $3", newHash, userID, oldHash)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to rehash password")
+		span.RecordError(err)
+		return false, errors.New("failed to rehash password")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to rehash password")
+		span.RecordError(err)
+		return false, errors.New("failed to rehash password")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+	return n == 1, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token.
+func (r *PostgresRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.CreatePasswordResetToken")
+	defer span.End()
+
+	query := "INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to create password reset token")
+		span.RecordError(err)
+		return errors.New("failed to create password reset token")
+	}
+
+	span.SetAttributes(attribute.String("user_id", token.UserID))
+	return nil
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash
+// of its value.
+func (r *PostgresRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetPasswordResetTokenByHash")
+	defer span.End()
+
+	token := &model.PasswordResetToken{}
+	query := "SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_tokens WHERE token_hash = $1"
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn(ctx).Msg("Password reset token not found")
+			return nil, fmt.Errorf("password reset token %w", model.ErrNotFound)
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve password reset token")
+		span.RecordError(err)
+		return nil, errors.New("failed to get password reset token")
+	}
+
+	span.SetAttributes(attribute.String("user_id", token.UserID))
+	return token, nil
+}
+
+// ResetPassword atomically redeems a password reset token and replaces the
+// user's password hash. Every other outstanding reset token of the user is
+// invalidated too. It returns false without changing the password if the
+// token was already used or has expired.
+func (r *PostgresRepository) ResetPassword(ctx context.Context, tokenID, userID, passwordHash string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.ResetPassword")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to begin password reset")
+		span.RecordError(err)
+		return false, errors.New("failed to reset password")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2 AND user_id = $3 AND used_at IS NULL AND expires_at > $1",
+		now, tokenID, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to redeem password reset token")
+		span.RecordError(err)
+		return false, errors.New("failed to reset password")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL",
+		now, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to invalidate password reset tokens")
+		span.RecordError(err)
+		return false, errors.New("failed to reset password")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET password = $1, updated_at = $2, version = version + 1 WHERE id = $3",
+		passwordHash, now, userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to store new password")
+		span.RecordError(err)
+		return false, errors.New("failed to reset password")
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to commit password reset")
+		span.RecordError(err)
+		return false, errors.New("failed to reset password")
+	}
+
+	r.logger.Info(ctx).Msgf("Password reset for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+func setupPasswordResetDB(t *testing.T) *PostgresRepository {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	setupTestDB(t, db)
+	_, err = db.Exec(`
+	CREATE TABLE password_reset_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP,
+		used_at TIMESTAMP
+	)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create password_reset_tokens table: %v", err)
+	}
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+	user := &model.User{
+		ID:        "user123",
+		Email:     "test@example.com",
+		Password:  "old-hash",
+		Nickname:  "TestUser",
+		CreatedAt: time.Now().UTC(),
+	}
+	if _, err := repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to insert test user: %v", err)
+	}
+	return repo
+}
+
+func newTestResetToken(id, hash string, ttl time.Duration) *model.PasswordResetToken {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &model.PasswordResetToken{
+		ID:        id,
+		UserID:    "user123",
+		TokenHash: hash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+}
+
+func TestPostgresRepository_ResetPassword(t *testing.T) {
+	repo := setupPasswordResetDB(t)
+	ctx := context.Background()
+
+	for _, token := range []*model.PasswordResetToken{
+		newTestResetToken("t1", "hash1", time.Hour),
+		newTestResetToken("t2", "hash2", time.Hour),
+		newTestResetToken("t3", "hash3", -time.Minute),
+	} {
+		if err := repo.CreatePasswordResetToken(ctx, token); err != nil {
+			t.Fatalf("CreatePasswordResetToken() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetPasswordResetTokenByHash(ctx, "hash1")
+	if err != nil {
+		t.Fatalf("GetPasswordResetTokenByHash() error = %v", err)
+	}
+	if got.ID != "t1" || got.UserID != "user123" || got.Used() {
+		t.Errorf("GetPasswordResetTokenByHash() = %+v", got)
+	}
+	if _, err := repo.GetPasswordResetTokenByHash(ctx, "missing"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("GetPasswordResetTokenByHash() for unknown hash error = %v, want ErrNotFound", err)
+	}
+
+	if ok, err := repo.ResetPassword(ctx, "t3", "user123", "expired-hash"); err != nil || ok {
+		t.Errorf("ResetPassword() with expired token = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.ResetPassword(ctx, "t1", "other", "wrong-user-hash"); err != nil || ok {
+		t.Errorf("ResetPassword() for another user = %v, %v, want false", ok, err)
+	}
+
+	ok, err := repo.ResetPassword(ctx, "t1", "user123", "new-hash")
+	if err != nil || !ok {
+		t.Fatalf("ResetPassword() = %v, %v, want true", ok, err)
+	}
+	user, err := repo.GetUserByID(ctx, "user123")
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if user.Password != "new-hash" || user.Version != 2 {
+		t.Errorf("GetUserByID() password = %q, version = %d", user.Password, user.Version)
+	}
+
+	// The token is single-use and redeeming it invalidates its siblings.
+	if ok, err := repo.ResetPassword(ctx, "t1", "user123", "replayed-hash"); err != nil || ok {
+		t.Errorf("ResetPassword() replay = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.ResetPassword(ctx, "t2", "user123", "sibling-hash"); err != nil || ok {
+		t.Errorf("ResetPassword() with sibling token = %v, %v, want false", ok, err)
+	}
+	if got, _ := repo.GetPasswordResetTokenByHash(ctx, "hash2"); got == nil || !got.Used() {
+		t.Errorf("sibling token = %+v, want used", got)
+	}
+}
+
+func TestPostgresRepository_UpdatePassword(t *testing.T) {
+	repo := setupPasswordResetDB(t)
+	ctx := context.Background()
+
+	if err := repo.UpdatePassword(ctx, "user123", "new-hash"); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+	user, err := repo.GetUserByID(ctx, "user123")
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if user.Password != "new-hash" {
+		t.Errorf("UpdatePassword() password = %q, want new-hash", user.Password)
+	}
+	if err := repo.UpdatePassword(ctx, "invalid", "new-hash"); err == nil || err.Error() != "user not found" {
+		t.Errorf("UpdatePassword() for unknown user error = %v", err)
+	}
+}
+
+func TestPostgresRepository_RehashPassword(t *testing.T) {
+	repo := setupPasswordResetDB(t)
+	ctx := context.Background()
+	before, _ := repo.GetUserByID(ctx, "user123")
+
+	ok, err := repo.RehashPassword(ctx, "user123", "old-hash", "new-hash")
+	if err != nil || !ok {
+		t.Fatalf("RehashPassword() = %v, %v", ok, err)
+	}
+	user, err := repo.GetUserByID(ctx, "user123")
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if user.Password != "new-hash" || user.Version != before.Version {
+		t.Errorf("RehashPassword() user = %+v, want new-hash at version %d", user, before.Version)
+	}
+
+	// A hash replaced in the meantime is kept
+	if ok, err := repo.RehashPassword(ctx, "user123", "old-hash", "other-hash"); err != nil || ok {
+		t.Errorf("RehashPassword() of a stale hash = %v, %v", ok, err)
+	}
+}
@@ -4,29 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
+
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/model"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
 	_ "github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PostgresRepository implements UserRepository using PostgreSQL.
 type PostgresRepository struct {
 	db     *sql.DB
 	logger *logger.Logger
-	tracer otel.Tracer
+	tracer trace.Tracer
 }
 
 // NewPostgresRepository creates a new PostgresRepository instance.
 func NewPostgresRepository(ctx context.Context,
 	cfg *config.Config,
-	log *logger.Logger
+	log *logger.Logger,
 ) (*PostgresRepository, error) {
 	db, err := sql.Open(cfg.Database.Driver, cfg.Database.GetDSN())
 	if err != nil {
-		log.Error(ctx).Error(err).Msg("Failed to open database connection")
+		log.Error(ctx).Err(err).Msg("Failed to open database connection")
 		return nil, err
 	}
 	// Verify connection
@@ -41,6 +45,17 @@ func NewPostgresRepository(ctx context.Context,
 	}, nil
 }
 
+// Ping checks that the database accepts connections. It is used as the
+// readiness probe of the service.
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Close closes the connection pool, waiting for running queries to finish.
+func (r *PostgresRepository) Close() error {
+	return r.db.Close()
+}
+
 // CreateUserService creates a new user in the database.
 func (r *PostgresRepository) CreateUser(ctx context.Context, user *model.User) (string, error) {
 	ctx, span := r.tracer.Start(ctx, "PostgresRepository.CreateUser")
@@ -48,8 +63,15 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *model.User) (
 
 	r.logger.Info(ctx).Msgf("Creating user with email: %s", user.Email)
 
-	query := "INSERT INTO users (id, email, password, nickname, avatar, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.Password, user.Nickname, user.Avatar, user.CreatedAt)
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = user.CreatedAt
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+
+	query := "INSERT INTO users (id, email, password, nickname, avatar, email_verified, institution_id, role, created_at, updated_at, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id"
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.Password, user.Nickname, user.Avatar, user.EmailVerified, user.InstitutionID, user.Role, user.CreatedAt, user.UpdatedAt, user.Version)
 	if err != nil {
 		r.logger.Error(ctx).Err(err).Msg("Failed to create user in database")
 		span.RecordError(err)
@@ -69,19 +91,24 @@ func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (
 	r.logger.Info(ctx).Msgf("Retrieving user by email: %s", email)
 
 	user := &model.User{}
-	query := "SELECT id, email, password, nickname, avatar, created_at FROM users WHERE email = $1"
+	query := "SELECT id, email, password, nickname, avatar, email_verified, institution_id, role, created_at, updated_at, version FROM users WHERE email = $1"
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.Nickname,
 		&user.Avatar,
+		&user.EmailVerified,
+		&user.InstitutionID,
+		&user.Role,
 		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn(ctx).Msg("User not found by email")
-			return nil, errors.New("user not found")
+			return nil, fmt.Errorf("user %w", model.ErrNotFound)
 		}
 		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve user by email")
 		span.RecordError(err)
@@ -101,19 +128,24 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, id string) (*model
 	r.logger.Info(ctx).Msgf("Retrieving user by ID: %s", id)
 
 	user := &model.User{}
-	query := "SELECT id, email, password, nickname, avatar, created_at FROM users WHERE id = $1"
+	query := "SELECT id, email, password, nickname, avatar, email_verified, institution_id, role, created_at, updated_at, version FROM users WHERE id = $1"
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-			&user.ID,
+		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.Nickname,
 		&user.Avatar,
+		&user.EmailVerified,
+		&user.InstitutionID,
+		&user.Role,
 		&user.CreatedAt,
-		)
+		&user.UpdatedAt,
+		&user.Version,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn(ctx).Msg("User not found by ID")
-			return nil, errors.New("user not found")
+			return nil, fmt.Errorf("user %w", model.ErrNotFound)
 		}
 		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve user by ID")
 		span.RecordError(err)
@@ -123,4 +155,43 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, id string) (*model
 	r.logger.Info(ctx).Msgf("User retrieved by ID: %s", id)
 	span.SetAttributes(attribute.String("user_id", id))
 	return user, nil
-}
\ No newline at end of file
+}
+
+// UpdateUser saves the profile fields of user if its stored version still
+// equals user.Version. On success user.Version and user.UpdatedAt are set
+// to the stored values.
+func (r *PostgresRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.UpdateUser")
+	defer span.End()
+
+	r.logger.Info(ctx).Msgf("Updating user: %s", user.ID)
+
+	updatedAt := time.Now().UTC()
+	query := "UPDATE users SET nickname = $1, avatar = $2, updated_at = $3, version = version + 1 WHERE id = $4 AND version = $5"
+	res, err := r.db.ExecContext(ctx, query, user.Nickname, user.Avatar, updatedAt, user.ID, user.Version)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to update user in database")
+		span.RecordError(err)
+		return errors.New("failed to update user")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to update user in database")
+		span.RecordError(err)
+		return errors.New("failed to update user")
+	}
+	if n == 0 {
+		// Either the user is gone or someone else updated it first.
+		if _, err := r.GetUserByID(ctx, user.ID); err != nil {
+			return err
+		}
+		r.logger.Warn(ctx).Msgf("Version conflict updating user: %s", user.ID)
+		return model.ErrVersionConflict
+	}
+
+	user.Version++
+	user.UpdatedAt = updatedAt
+	r.logger.Info(ctx).Msgf("User updated successfully: %s", user.ID)
+	span.SetAttributes(attribute.String("user_id", user.ID), attribute.Int64("version", user.Version))
+	return nil
+}
@@ -1,16 +1,16 @@
 package repository
 
 import (
-"context"
+	"context"
 	"database/sql"
-	"errors"
 	"testing"
 	"time"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/model"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
-	"github.com/mattn/go-sqlite3"
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
 )
 
 // setupTestDB creates the users table in the PostgreSQL database.
@@ -23,7 +23,12 @@ func setupTestDB(t *testing.T, db *sql.DB) {
 		password TEXT NOT NULL,
 		nickname TEXT NOT NULL,
 		avatar TEXT,
-		created_at TIMESTAMP
+		email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+		institution_id TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1
 	)
 	`)
 	if err != nil {
@@ -46,10 +51,7 @@ func TestPostgresRepository_CreateUser(t *testing.T) {
 	defer db.Close()
 
 	// Create schema
-	_, err = db.Exec("CREATE TABLE users (id TEXT, email TEXT, password TEXT, nickname TEXT, avatar TEXT, created_at TIMESTAMP)")
-	if err != nil {
-		t.Fatalf("Failed to create table: %v", err)
-	}
+	setupTestDB(t, db)
 
 	cfg := &config.Config{
 		Database: config.DatabaseConfig{
@@ -109,8 +111,12 @@ func TestPostgresRepository_CreateUser(t *testing.T) {
 }
 
 func TestPostgresRepository_GetUserByEmail(t *testing.T) {
-	db, _ := sql.Open("sqlite3", ":memory:")
-	_, _ := db.Exec("CREATE TABLE users (id TEXT, email TEXT, password TEXT, nickname TEXT, nickname TEXT, avatar TEXT, created_at TIMESTAMP)")
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	setupTestDB(t, db)
 	cfg := &config.Config{
 		Database: config.DatabaseConfig{
 			Driver: "sqlite3",
@@ -131,6 +137,8 @@ func TestPostgresRepository_GetUserByEmail(t *testing.T) {
 		Password: "hashedpassword",
 		Nickname: "TestUser",
 		Avatar:   "http://example.com/avatar.png",
+		InstitutionID: "example",
+		Role:     model.RoleStudent,
 		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
 	}
 	_, err = repo.CreateUser(context.Background(), user)
@@ -165,7 +173,8 @@ func TestPostgresRepository_GetUserByEmail(t *testing.T) {
 			}
 			if !tt.wantErr && (gotUser.ID != tt.wantUser.ID || gotUser.Email != tt.wantUser.Email ||
 				gotUser.Password != tt.wantUser.Password || gotUser.Nickname != tt.wantUser.Nickname ||
-				gotUser.Avatar != tt.wantUser.Avatar || !gotUser.CreatedAt.UTC().Equal(tt.wantUser.CreatedAt.UTC())) {
+				gotUser.Avatar != tt.wantUser.Avatar || !gotUser.CreatedAt.UTC().Equal(tt.wantUser.CreatedAt.UTC()) ||
+				gotUser.InstitutionID != tt.wantUser.InstitutionID || gotUser.Role != tt.wantUser.Role) {
 				t.Errorf("GetUserByEmail() user = %+v, want %+v", gotUser, tt.wantUser)
 			}
 		})
@@ -190,11 +199,14 @@ func TestPostgresRepository_GetUserByID(t *testing.T) {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close() // 测试结束后关闭数据库连接
+	if err := db.Ping(); err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
 
 	// Setup the database schema
 	setupTestDB(t, db)
 	cleanupTestDB(t, db)
-	repo, err := NewPostgresRepository(cfg)
+	repo, err := NewPostgresRepository(context.Background(), cfg, logger.NewLogger(cfg))
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -246,3 +258,81 @@ func TestPostgresRepository_GetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func TestPostgresRepository_UpdateUser(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	setupTestDB(t, db)
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+
+	ctx := context.Background()
+	user := &model.User{
+		ID:        "user123",
+		Email:     "test@example.com",
+		Password:  "hashedpassword",
+		Nickname:  "TestUser",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if _, err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to insert test user: %v", err)
+	}
+
+	update := *user
+	update.Nickname = "Renamed"
+	update.Avatar = "https://example.com/avatar.png"
+	if err := repo.UpdateUser(ctx, &update); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if update.Version != 2 || update.UpdatedAt.Before(user.UpdatedAt) {
+		t.Errorf("UpdateUser() version = %d, updated_at = %v", update.Version, update.UpdatedAt)
+	}
+
+	got, err := repo.GetUserByID(ctx, "user123")
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if got.Nickname != "Renamed" || got.Avatar != update.Avatar || got.Version != 2 {
+		t.Errorf("GetUserByID() user = %+v", got)
+	}
+
+	// Writing with the version read before the first update must fail.
+	stale := *user
+	stale.Nickname = "Stale"
+	if err := repo.UpdateUser(ctx, &stale); err == nil || err.Error() != "user was modified by another request" {
+		t.Errorf("UpdateUser() with stale version error = %v", err)
+	}
+
+	missing := update
+	missing.ID = "invalid"
+	if err := repo.UpdateUser(ctx, &missing); err == nil || err.Error() != "user not found" {
+		t.Errorf("UpdateUser() on missing user error = %v", err)
+	}
+}
+
+func TestPostgresRepository_Ping(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	repo := &PostgresRepository{db: db}
+
+	if err := repo.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+	db.Close()
+	if err := repo.Ping(context.Background()); err == nil {
+		t.Errorf("Ping() on closed database error = nil")
+	}
+}
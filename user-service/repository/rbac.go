@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetUserRoles returns the names of the roles granted to a user, sorted.
+func (r *PostgresRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetUserRoles")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role", userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve user roles")
+		span.RecordError(err)
+		return nil, errors.New("failed to get user roles")
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			r.logger.Error(ctx).Err(err).Msg("Failed to scan user role")
+			span.RecordError(err)
+			return nil, errors.New("failed to get user roles")
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to iterate user roles")
+		span.RecordError(err)
+		return nil, errors.New("failed to get user roles")
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+	return roles, nil
+}
+
+// GrantRole grants an existing role to a user. It returns false if the user
+// already holds the role.
+func (r *PostgresRepository) GrantRole(ctx context.Context, userID, role, grantedBy string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GrantRole")
+	defer span.End()
+
+	var exists int
+	err := r.db.QueryRowContext(ctx, "SELECT 1 FROM roles WHERE name = $1", role).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn(ctx).Msgf("Role not found: %s", role)
+			return false, fmt.Errorf("role %w", model.ErrNotFound)
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to look up role")
+		span.RecordError(err)
+		return false, errors.New("failed to grant role")
+	}
+
+	query := "INSERT INTO user_roles (user_id, role, granted_by, granted_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, role) DO NOTHING"
+	res, err := r.db.ExecContext(ctx, query, userID, role, grantedBy, time.Now().UTC())
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to grant role")
+		span.RecordError(err)
+		return false, errors.New("failed to grant role")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	r.logger.Info(ctx).Msgf("Role %s granted to user %s by %s", role, userID, grantedBy)
+	span.SetAttributes(attribute.String("user_id", userID), attribute.String("role", role))
+	return true, nil
+}
+
+// RevokeRole removes a role from a user. It returns false if the user did
+// not hold the role.
+func (r *PostgresRepository) RevokeRole(ctx context.Context, userID, role string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RevokeRole")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1 AND role = $2", userID, role)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke role")
+		span.RecordError(err)
+		return false, errors.New("failed to revoke role")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	r.logger.Info(ctx).Msgf("Role %s revoked from user %s", role, userID)
+	span.SetAttributes(attribute.String("user_id", userID), attribute.String("role", role))
+	return true, nil
+}
+
+// GetPermissionsForRoles returns the distinct permissions carried by the
+// given roles, sorted. Unknown roles carry no permissions.
+func (r *PostgresRepository) GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetPermissionsForRoles")
+	defer span.End()
+
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(roles))
+	args := make([]interface{}, len(roles))
+	for i, role := range roles {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = role
+	}
+	query := "SELECT DISTINCT permission FROM role_permissions WHERE role IN (" + strings.Join(placeholders, ", ") + ") ORDER BY permission"
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve role permissions")
+		span.RecordError(err)
+		return nil, errors.New("failed to get permissions")
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			r.logger.Error(ctx).Err(err).Msg("Failed to scan role permission")
+			span.RecordError(err)
+			return nil, errors.New("failed to get permissions")
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to iterate role permissions")
+		span.RecordError(err)
+		return nil, errors.New("failed to get permissions")
+	}
+
+	span.SetAttributes(attribute.StringSlice("roles", roles))
+	return permissions, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+// setupRBACDB creates the RBAC tables and seeds them like scripts/init.sql.
+func setupRBACDB(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`
+	CREATE TABLE roles (name TEXT PRIMARY KEY, description TEXT NOT NULL DEFAULT '', created_at TIMESTAMP);
+	CREATE TABLE permissions (name TEXT PRIMARY KEY, description TEXT NOT NULL DEFAULT '');
+	CREATE TABLE role_permissions (role TEXT NOT NULL, permission TEXT NOT NULL, PRIMARY KEY (role, permission));
+	CREATE TABLE user_roles (user_id TEXT NOT NULL, role TEXT NOT NULL, granted_by TEXT, granted_at TIMESTAMP, PRIMARY KEY (user_id, role));
+	INSERT INTO roles (name) VALUES ('admin'), ('moderator');
+	INSERT INTO permissions (name) VALUES ('roles.read'), ('roles.manage');
+	INSERT INTO role_permissions (role, permission) VALUES ('admin', 'roles.read'), ('admin', 'roles.manage'), ('moderator', 'roles.read');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create RBAC tables: %v", err)
+	}
+}
+
+func TestPostgresRepository_Roles(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	setupRBACDB(t, db)
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	repo := &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+	ctx := context.Background()
+
+	for _, role := range []string{"moderator", "admin"} {
+		granted, err := repo.GrantRole(ctx, "user123", role, "admin1")
+		if err != nil || !granted {
+			t.Fatalf("GrantRole(%q) = %v, %v, want true", role, granted, err)
+		}
+	}
+	if granted, err := repo.GrantRole(ctx, "user123", "admin", "admin1"); err != nil || granted {
+		t.Errorf("GrantRole() twice = %v, %v, want false", granted, err)
+	}
+	if _, err := repo.GrantRole(ctx, "user123", "superuser", "admin1"); err == nil || err.Error() != "role not found" {
+		t.Errorf("GrantRole() unknown role error = %v, want role not found", err)
+	}
+
+	roles, err := repo.GetUserRoles(ctx, "user123")
+	if err != nil || !reflect.DeepEqual(roles, []string{"admin", "moderator"}) {
+		t.Errorf("GetUserRoles() = %v, %v, want [admin moderator]", roles, err)
+	}
+
+	permissions, err := repo.GetPermissionsForRoles(ctx, roles)
+	if err != nil || !reflect.DeepEqual(permissions, []string{"roles.manage", "roles.read"}) {
+		t.Errorf("GetPermissionsForRoles() = %v, %v, want [roles.manage roles.read]", permissions, err)
+	}
+	if permissions, err := repo.GetPermissionsForRoles(ctx, nil); err != nil || len(permissions) != 0 {
+		t.Errorf("GetPermissionsForRoles(nil) = %v, %v, want none", permissions, err)
+	}
+
+	if revoked, err := repo.RevokeRole(ctx, "user123", "admin"); err != nil || !revoked {
+		t.Errorf("RevokeRole() = %v, %v, want true", revoked, err)
+	}
+	if revoked, err := repo.RevokeRole(ctx, "user123", "admin"); err != nil || revoked {
+		t.Errorf("RevokeRole() twice = %v, %v, want false", revoked, err)
+	}
+	roles, _ = repo.GetUserRoles(ctx, "user123")
+	if !reflect.DeepEqual(roles, []string{"moderator"}) {
+		t.Errorf("GetUserRoles() after revoke = %v, want [moderator]", roles)
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CreateRefreshToken stores a new refresh token.
+func (r *PostgresRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.CreateRefreshToken")
+	defer span.End()
+
+	query := "INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to create refresh token")
+		span.RecordError(err)
+		return errors.New("failed to create refresh token")
+	}
+
+	span.SetAttributes(attribute.String("user_id", token.UserID), attribute.String("family_id", token.FamilyID))
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value.
+func (r *PostgresRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.GetRefreshTokenByHash")
+	defer span.End()
+
+	token := &model.RefreshToken{}
+	var replacedBy sql.NullString
+	query := "SELECT id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by FROM refresh_tokens WHERE token_hash = $1"
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.RevokedAt,
+		&replacedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn(ctx).Msg("Refresh token not found")
+			return nil, fmt.Errorf("refresh token %w", model.ErrNotFound)
+		}
+		r.logger.Error(ctx).Err(err).Msg("Failed to retrieve refresh token")
+		span.RecordError(err)
+		return nil, errors.New("failed to get refresh token")
+	}
+	token.ReplacedBy = replacedBy.String
+
+	span.SetAttributes(attribute.String("user_id", token.UserID), attribute.String("family_id", token.FamilyID))
+	return token, nil
+}
+
+// RotateRefreshToken atomically revokes the old token and stores its
+// replacement. It returns false without storing next if the old token had
+// already been revoked, which means it was replayed.
+func (r *PostgresRepository) RotateRefreshToken(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RotateRefreshToken")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to begin refresh token rotation")
+		span.RecordError(err)
+		return false, errors.New("failed to rotate refresh token")
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3 AND revoked_at IS NULL",
+		time.Now().UTC(), next.ID, oldID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke rotated refresh token")
+		span.RecordError(err)
+		return false, errors.New("failed to rotate refresh token")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to store rotated refresh token")
+		span.RecordError(err)
+		return false, errors.New("failed to rotate refresh token")
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to commit refresh token rotation")
+		span.RecordError(err)
+		return false, errors.New("failed to rotate refresh token")
+	}
+
+	span.SetAttributes(attribute.String("family_id", next.FamilyID))
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily revokes every active token in a family.
+func (r *PostgresRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RevokeRefreshTokenFamily")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		time.Now().UTC(), familyID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke refresh token family")
+		span.RecordError(err)
+		return errors.New("failed to revoke refresh tokens")
+	}
+
+	r.logger.Info(ctx).Msgf("Revoked refresh token family: %s", familyID)
+	span.SetAttributes(attribute.String("family_id", familyID))
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user.
+func (r *PostgresRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "PostgresRepository.RevokeUserRefreshTokens")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now().UTC(), userID)
+	if err != nil {
+		r.logger.Error(ctx).Err(err).Msg("Failed to revoke user refresh tokens")
+		span.RecordError(err)
+		return errors.New("failed to revoke refresh tokens")
+	}
+
+	r.logger.Info(ctx).Msgf("Revoked all refresh tokens for user: %s", userID)
+	span.SetAttributes(attribute.String("user_id", userID))
+	return nil
+}
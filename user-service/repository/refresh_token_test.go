@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+func setupRefreshTokenDB(t *testing.T) *PostgresRepository {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+	CREATE TABLE refresh_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		family_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		replaced_by TEXT
+	)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create refresh_tokens table: %v", err)
+	}
+
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	return &PostgresRepository{db: db, logger: logger.NewLogger(cfg), tracer: otel.Tracer("test-postgres-repository")}
+}
+
+func newTestRefreshToken(id, hash string) *model.RefreshToken {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &model.RefreshToken{
+		ID:        id,
+		UserID:    "user123",
+		FamilyID:  "family1",
+		TokenHash: hash,
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+}
+
+func TestPostgresRepository_RotateRefreshToken(t *testing.T) {
+	repo := setupRefreshTokenDB(t)
+	ctx := context.Background()
+
+	first := newTestRefreshToken("token1", "hash1")
+	if err := repo.CreateRefreshToken(ctx, first); err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+
+	got, err := repo.GetRefreshTokenByHash(ctx, "hash1")
+	if err != nil {
+		t.Fatalf("GetRefreshTokenByHash() error = %v", err)
+	}
+	if got.ID != first.ID || got.FamilyID != first.FamilyID || got.Revoked() {
+		t.Errorf("GetRefreshTokenByHash() = %+v, want %+v", got, first)
+	}
+
+	rotated, err := repo.RotateRefreshToken(ctx, "token1", newTestRefreshToken("token2", "hash2"))
+	if err != nil || !rotated {
+		t.Fatalf("RotateRefreshToken() = %v, %v, want true, nil", rotated, err)
+	}
+	got, _ = repo.GetRefreshTokenByHash(ctx, "hash1")
+	if !got.Revoked() || got.ReplacedBy != "token2" {
+		t.Errorf("rotated token = %+v, want revoked and replaced by token2", got)
+	}
+
+	// A second rotation of the same token is a replay.
+	rotated, err = repo.RotateRefreshToken(ctx, "token1", newTestRefreshToken("token3", "hash3"))
+	if err != nil || rotated {
+		t.Fatalf("RotateRefreshToken() replay = %v, %v, want false, nil", rotated, err)
+	}
+	if _, err := repo.GetRefreshTokenByHash(ctx, "hash3"); err == nil {
+		t.Errorf("replayed rotation must not store a new token")
+	}
+
+	if err := repo.RevokeRefreshTokenFamily(ctx, "family1"); err != nil {
+		t.Fatalf("RevokeRefreshTokenFamily() error = %v", err)
+	}
+	got, _ = repo.GetRefreshTokenByHash(ctx, "hash2")
+	if !got.Revoked() {
+		t.Errorf("RevokeRefreshTokenFamily() left token2 active")
+	}
+
+	if _, err := repo.GetRefreshTokenByHash(ctx, "missing"); err == nil {
+		t.Errorf("GetRefreshTokenByHash() expected error for unknown hash")
+	}
+}
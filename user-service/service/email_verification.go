@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errInvalidVerificationToken is returned for unknown, used and expired
+// verification tokens alike.
+var errInvalidVerificationToken = NewError(CodeInvalidArgument, ReasonInvalidVerifyToken, "invalid or expired verification token")
+
+// validateEmail checks that email is a bare address such as
+// "alice@example.edu", without a display name.
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return invalidField(ReasonInvalidEmail, "email", "invalid email address")
+	}
+	return nil
+}
+
+// VerifyEmail redeems the verification token sent at registration and marks
+// the owner's email address as verified.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.VerifyEmail")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "success").Observe(duration)
+	}()
+
+	if token == "" {
+		s.logger.Warn(ctx).Msg("Empty verification token provided")
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty verification token"))
+		return MissingFields("verification token is required", "token")
+	}
+
+	stored, err := s.repo.GetEmailVerificationTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Unknown verification token")
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return errInvalidVerificationToken
+	}
+	span.SetAttributes(attribute.String("user_id", stored.UserID))
+	if stored.Used() || stored.Expired(time.Now()) {
+		s.logger.Warn(ctx).Msg("Used or expired verification token provided")
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errInvalidVerificationToken)
+		return errInvalidVerificationToken
+	}
+
+	verified, err := s.repo.VerifyEmail(ctx, stored.ID, stored.UserID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to verify email")
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to verify email")
+	}
+	if !verified {
+		// Another request redeemed the same token first.
+		s.logger.Warn(ctx).Msg("Verification token already redeemed")
+		s.metrics.RequestDuration().WithLabelValues("VerifyEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errInvalidVerificationToken)
+		return errInvalidVerificationToken
+	}
+
+	s.logger.Info(ctx).Msgf("Email verified for user: %s", stored.UserID)
+	return nil
+}
+
+// ResendVerificationEmail issues a new verification token for an unverified
+// account. Unknown and already verified emails are silently ignored so the
+// response does not reveal which accounts exist.
+func (s *UserService) ResendVerificationEmail(ctx context.Context, email string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.ResendVerificationEmail")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("ResendVerificationEmail", "success").Observe(duration)
+	}()
+
+	if email == "" {
+		s.logger.Warn(ctx).Msg("Empty email provided")
+		s.metrics.RequestDuration().WithLabelValues("ResendVerificationEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty email"))
+		return MissingFields("email is required", "email")
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Verification requested for unknown email")
+		return nil
+	}
+	span.SetAttributes(attribute.String("user_id", user.ID))
+	if user.EmailVerified {
+		s.logger.Info(ctx).Msgf("Email already verified for user: %s", user.ID)
+		return nil
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to send verification email")
+		s.metrics.RequestDuration().WithLabelValues("ResendVerificationEmail", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to send verification email")
+	}
+	return nil
+}
+
+// sendVerificationEmail stores a new verification token for user and sends
+// it through the notifier.
+func (s *UserService) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	stored := &model.EmailVerificationToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.EmailVerification.TokenDuration()),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateEmailVerificationToken(ctx, stored); err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, notify.Message{
+		Kind:    notify.KindEmailVerification,
+		To:      user.Email,
+		Subject: "Verify your GoCampus email address",
+		Body: fmt.Sprintf("Use this code to verify your email address: %s\nIt expires at %s.",
+			token, stored.ExpiresAt.Format(time.RFC3339)),
+		Data: map[string]string{
+			"token":      token,
+			"expires_at": stored.ExpiresAt.Format(time.RFC3339),
+		},
+		SentAt: now,
+	})
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+)
+
+// newVerificationRepo returns a mock repository that keeps users and
+// verification tokens in maps.
+func newVerificationRepo() *MockUserRepository {
+	users := map[string]*model.User{}
+	tokens := map[string]*model.EmailVerificationToken{}
+	return &MockUserRepository{
+		CreateUserFunc: func(ctx context.Context, user *model.User) (string, error) {
+			u := *user
+			users[user.Email] = &u
+			return user.ID, nil
+		},
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			if u, ok := users[email]; ok {
+				copied := *u
+				return &copied, nil
+			}
+			return nil, errors.New("user not found")
+		},
+		GetUserRolesFunc: func(ctx context.Context, userID string) ([]string, error) {
+			return nil, nil
+		},
+		GetMFASettingsFunc: func(ctx context.Context, userID string) (*model.MFASettings, error) {
+			return nil, nil
+		},
+		RehashPasswordFunc: func(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+			return true, nil
+		},
+		CreateRefreshTokenFunc: func(ctx context.Context, token *model.RefreshToken) error {
+			return nil
+		},
+		CreateEmailVerificationTokenFunc: func(ctx context.Context, token *model.EmailVerificationToken) error {
+			tokens[token.TokenHash] = token
+			return nil
+		},
+		GetEmailVerificationTokenByHashFunc: func(ctx context.Context, hash string) (*model.EmailVerificationToken, error) {
+			if tok, ok := tokens[hash]; ok {
+				copied := *tok
+				return &copied, nil
+			}
+			return nil, errors.New("email verification token not found")
+		},
+		VerifyEmailFunc: func(ctx context.Context, tokenID, userID string) (bool, error) {
+			now := time.Now()
+			for _, tok := range tokens {
+				if tok.ID == tokenID {
+					if tok.Used() {
+						return false, nil
+					}
+					tok.UsedAt = &now
+				}
+			}
+			for _, u := range users {
+				if u.ID == userID {
+					u.EmailVerified = true
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		EmailVerification: config.EmailVerificationConfig{RequiredForLogin: true, TokenHours: 48},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	notifier := &captureNotifier{}
+	service := NewUserService(newVerificationRepo(), cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(notifier))
+	ctx := context.Background()
+
+	_, err := service.Register(ctx, &model.User{ID: "user123", Email: "alice@example.edu", Password: "password123", Nickname: "Alice"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if len(notifier.messages) != 1 || notifier.messages[0].Kind != notify.KindEmailVerification || notifier.messages[0].To != "alice@example.edu" {
+		t.Fatalf("Register() messages = %+v", notifier.messages)
+	}
+	token := notifier.messages[0].Data["token"]
+
+	if _, err := service.Login(ctx, "alice@example.edu", "password123"); err == nil || err.Error() != "email address is not verified" {
+		t.Errorf("Login() before verification error = %v", err)
+	}
+	if _, err := service.Login(ctx, "alice@example.edu", "wrong"); err == nil || err.Error() != "invalid credentials" {
+		t.Errorf("Login() with wrong password error = %v, want invalid credentials", err)
+	}
+
+	if err := service.VerifyEmail(ctx, "bogus"); err == nil || err.Error() != "invalid or expired verification token" {
+		t.Errorf("VerifyEmail() with unknown token error = %v", err)
+	}
+	if err := service.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if err := service.VerifyEmail(ctx, token); err == nil {
+		t.Errorf("VerifyEmail() replay expected error")
+	}
+
+	if _, err := service.Login(ctx, "alice@example.edu", "password123"); err != nil {
+		t.Errorf("Login() after verification error = %v", err)
+	}
+
+	// Verified and unknown accounts get no new token.
+	for _, email := range []string{"alice@example.edu", "nobody@example.edu"} {
+		if err := service.ResendVerificationEmail(ctx, email); err != nil {
+			t.Errorf("ResendVerificationEmail(%s) error = %v", email, err)
+		}
+	}
+	if len(notifier.messages) != 1 {
+		t.Errorf("ResendVerificationEmail() sent %d messages, want none", len(notifier.messages)-1)
+	}
+}
+
+func TestUserService_Register_InvalidEmail(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	service := NewUserService(newVerificationRepo(), cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(&captureNotifier{}))
+
+	for _, email := range []string{"not-an-email", "Alice <alice@example.edu>", "alice@", "@example.edu"} {
+		_, err := service.Register(context.Background(), &model.User{ID: "user123", Email: email, Password: "password123", Nickname: "Alice"})
+		if err == nil || err.Error() != "invalid email address" {
+			t.Errorf("Register(%q) error = %v, want invalid email address", email, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+)
+
+// Code classifies an Error. Handlers map it to a transport status code.
+type Code int
+
+// Error codes. They mirror the gRPC codes the service uses.
+const (
+	CodeInternal Code = iota
+	CodeInvalidArgument
+	CodeNotFound
+	CodeAlreadyExists
+	CodeUnauthenticated
+	CodePermissionDenied
+	CodeFailedPrecondition
+	CodeAborted
+	CodeResourceExhausted
+	CodeUnavailable
+)
+
+// Error reasons. They are stable identifiers clients can branch on and
+// localize instead of matching the English message.
+const (
+	ReasonInternal              = "INTERNAL"
+	ReasonRequiredFieldMissing  = "REQUIRED_FIELD_MISSING"
+	ReasonInvalidEmail          = "INVALID_EMAIL"
+	ReasonEmailDomainNotAllowed = "EMAIL_DOMAIN_NOT_ALLOWED"
+	ReasonInvalidNickname       = "INVALID_NICKNAME"
+	ReasonInvalidAvatar         = "INVALID_AVATAR"
+	ReasonPasswordPolicy        = "PASSWORD_POLICY"
+	ReasonPasswordUnchanged     = "PASSWORD_UNCHANGED"
+	ReasonIncorrectPassword     = "INCORRECT_PASSWORD"
+	ReasonFieldNotUpdatable     = "FIELD_NOT_UPDATABLE"
+	ReasonUserNotFound          = "USER_NOT_FOUND"
+	ReasonUserAlreadyExists     = "USER_ALREADY_EXISTS"
+	ReasonVersionConflict       = "VERSION_CONFLICT"
+	ReasonInvalidCredentials    = "INVALID_CREDENTIALS"
+	ReasonEmailNotVerified      = "EMAIL_NOT_VERIFIED"
+	ReasonUnauthenticated       = "UNAUTHENTICATED"
+	ReasonPermissionDenied      = "PERMISSION_DENIED"
+	ReasonInvalidRefreshToken   = "INVALID_REFRESH_TOKEN"
+	ReasonRefreshTokenExpired   = "REFRESH_TOKEN_EXPIRED"
+	ReasonRefreshTokenReused    = "REFRESH_TOKEN_REUSED"
+	ReasonInvalidResetToken     = "INVALID_RESET_TOKEN"
+	ReasonInvalidVerifyToken    = "INVALID_VERIFICATION_TOKEN"
+	ReasonInvalidMFAToken       = "INVALID_MFA_TOKEN"
+	ReasonInvalidMFACode        = "INVALID_MFA_CODE"
+	ReasonMFAAlreadyEnabled     = "MFA_ALREADY_ENABLED"
+	ReasonMFANotEnabled         = "MFA_NOT_ENABLED"
+	ReasonMFANotStarted         = "MFA_ENROLLMENT_NOT_STARTED"
+	ReasonMFARequired           = "MFA_REQUIRED"
+	ReasonRoleNotFound          = "ROLE_NOT_FOUND"
+	ReasonRoleAlreadyGranted    = "ROLE_ALREADY_GRANTED"
+	ReasonRoleNotGranted        = "ROLE_NOT_GRANTED"
+	ReasonSelfRevocation        = "SELF_REVOCATION"
+	ReasonLoginBackoff          = "LOGIN_BACKOFF"
+	ReasonAccountLocked         = "ACCOUNT_LOCKED"
+	ReasonAddressLocked         = "ADDRESS_LOCKED"
+	ReasonNoFailedLogins        = "NO_FAILED_LOGINS"
+	ReasonLoginUnavailable      = "LOGIN_UNAVAILABLE"
+)
+
+// Error is a failure returned by UserService. Message is the English
+// description; Reason tells failures with the same Code apart.
+type Error struct {
+	Code    Code
+	Reason  string
+	Message string
+	// Fields lists the request fields at fault, for invalid arguments.
+	Fields []FieldViolation
+	// RetryAfter is how long the client should wait before retrying.
+	RetryAfter time.Duration
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+// FieldViolation is one invalid request field. Field is the name used in
+// the API, e.g. "new_password".
+type FieldViolation struct {
+	Field       string
+	Reason      string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError returns an Error with the given code, reason and message.
+func NewError(code Code, reason, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+// MissingFields returns the error for a request that lacks the named fields.
+func MissingFields(message string, fields ...string) *Error {
+	e := NewError(CodeInvalidArgument, ReasonRequiredFieldMissing, message)
+	for _, field := range fields {
+		e.Fields = append(e.Fields, FieldViolation{
+			Field:       field,
+			Reason:      ReasonRequiredFieldMissing,
+			Description: field + " is required",
+		})
+	}
+	return e
+}
+
+// invalidField returns an InvalidArgument error for a single field.
+func invalidField(reason, field, message string) *Error {
+	e := NewError(CodeInvalidArgument, reason, message)
+	e.Fields = []FieldViolation{{Field: field, Reason: reason, Description: message}}
+	return e
+}
+
+// internalError returns an Internal error. message must not reveal details
+// of the failure; those are logged instead.
+func internalError(message string) *Error {
+	return NewError(CodeInternal, ReasonInternal, message)
+}
+
+// repoError converts an error from the repository. A missing record becomes
+// NotFound with reason and a lost version race Aborted; anything else is
+// Internal. Repository messages are already safe to return.
+func repoError(err error, reason string) *Error {
+	var e *Error
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		e = NewError(CodeNotFound, reason, err.Error())
+	case errors.Is(err, model.ErrVersionConflict):
+		e = NewError(CodeAborted, ReasonVersionConflict, err.Error())
+	default:
+		e = internalError(err.Error())
+	}
+	e.Err = err
+	return e
+}
+
+// passwordPolicyError converts a password policy rejection of field into an
+// InvalidArgument error with one violation per broken rule. Other errors
+// are returned unchanged.
+func passwordPolicyError(err error, field string) error {
+	var perr *pwpolicy.Error
+	if !errors.As(err, &perr) {
+		return err
+	}
+	e := NewError(CodeInvalidArgument, ReasonPasswordPolicy, err.Error())
+	e.Err = err
+	for _, v := range perr.Violations {
+		e.Fields = append(e.Fields, FieldViolation{
+			Field:       field,
+			Reason:      PasswordViolationReason(v.Code),
+			Description: v.Message,
+		})
+	}
+	return e
+}
+
+// PasswordViolationReason returns the field violation reason for the
+// password policy violation code, e.g. "PASSWORD_TOO_SHORT".
+func PasswordViolationReason(code string) string {
+	return "PASSWORD_" + strings.ToUpper(code)
+}
+
+// loginBlockedError converts a LoginBlockedError into a ResourceExhausted
+// error.
+func loginBlockedError(blocked *LoginBlockedError) *Error {
+	reason := ReasonLoginBackoff
+	switch {
+	case blocked.Locked && blocked.Scope == LockoutScopeIP:
+		reason = ReasonAddressLocked
+	case blocked.Locked:
+		reason = ReasonAccountLocked
+	}
+	e := NewError(CodeResourceExhausted, reason, blocked.Error())
+	e.RetryAfter = blocked.RetryAfter
+	e.Err = blocked
+	return e
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/lockout"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Scopes of login throttles, also used as the lockout metric label.
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+)
+
+// LoginBlockedError is returned by Login while earlier failures hold off
+// further attempts for the account or the client IP.
+type LoginBlockedError struct {
+	Scope      string // LockoutScopeAccount or LockoutScopeIP
+	Locked     bool   // a lockout rather than a backoff delay
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	retry := (e.RetryAfter + time.Second - 1).Truncate(time.Second)
+	switch {
+	case e.Locked && e.Scope == LockoutScopeIP:
+		return fmt.Sprintf("too many failed logins from this address, try again in %s", retry)
+	case e.Locked:
+		return fmt.Sprintf("account temporarily locked, try again in %s", retry)
+	default:
+		return fmt.Sprintf("too many failed logins, try again in %s", retry)
+	}
+}
+
+// LockoutStatus is the failed-login state of an account and a client IP.
+// Either is nil when no recent failures are recorded.
+type LockoutStatus struct {
+	Account *model.LoginThrottle
+	IP      *model.LoginThrottle
+}
+
+// throttleKey is a login throttle together with the policy and the
+// counting window applied to it.
+type throttleKey struct {
+	key    string
+	scope  string
+	policy lockout.Policy
+	window time.Duration
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginThrottleKeys returns the throttles a login attempt for email counts
+// against under cfg. Failures are tracked by email so unknown accounts are
+// throttled the same way as existing ones.
+func (s *UserService) loginThrottleKeys(ctx context.Context, cfg *config.LockoutConfig, email string) []throttleKey {
+	keys := []throttleKey{{
+		key:    accountThrottleKey(email),
+		scope:  LockoutScopeAccount,
+		policy: lockout.AccountPolicy(cfg),
+		window: cfg.Window(),
+	}}
+	if ip, ok := lockout.ClientIP(ctx); ok {
+		keys = append(keys, throttleKey{
+			key:    ipThrottleKey(ip),
+			scope:  LockoutScopeIP,
+			policy: lockout.IPPolicy(cfg),
+			window: cfg.Window(),
+		})
+	}
+	return keys
+}
+
+// checkLoginThrottles returns a *LoginBlockedError if any of keys is
+// currently blocked.
+func (s *UserService) checkLoginThrottles(ctx context.Context, keys []throttleKey) error {
+	now := time.Now()
+	for _, k := range keys {
+		throttle, err := s.repo.GetLoginThrottle(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.Blocked(now) {
+			return &LoginBlockedError{
+				Scope:      k.scope,
+				Locked:     throttle.Locked,
+				RetryAfter: throttle.BlockedUntil.Sub(now),
+			}
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against keys and blocks the keys
+// whose policy calls for it. Errors are only logged, so a storage problem
+// never turns a wrong password into a different response.
+func (s *UserService) recordLoginFailure(ctx context.Context, keys []throttleKey) {
+	now := time.Now()
+	for _, k := range keys {
+		failures, err := s.repo.RecordLoginFailure(ctx, k.key, now, now.Add(-k.window))
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to record login failure")
+			continue
+		}
+		delay, locked := k.policy.Block(failures)
+		if delay <= 0 {
+			continue
+		}
+		if err := s.repo.BlockLogin(ctx, k.key, now.Add(delay), locked); err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to block login")
+			continue
+		}
+		if locked {
+			s.metrics.Lockouts().WithLabelValues(k.scope).Inc()
+			s.logger.Warn(ctx).Msgf("Login locked for %s after %d failures", k.key, failures)
+		}
+	}
+}
+
+// clearAccountFailures forgives the failed logins of the account among keys
+// once a login, including any second factor, has succeeded. The client IP's
+// are kept, since they may target other accounts.
+func (s *UserService) clearAccountFailures(ctx context.Context, keys []throttleKey) {
+	for _, k := range keys {
+		if k.scope != LockoutScopeAccount {
+			continue
+		}
+		if _, err := s.repo.ClearLoginThrottle(ctx, k.key); err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to clear login failures")
+		}
+	}
+}
+
+// GetLockoutStatus returns the failed-login state of an account, named by
+// userID or email, and of a client IP. At least one must be given.
+func (s *UserService) GetLockoutStatus(ctx context.Context, userID, email, ip string) (*LockoutStatus, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetLockoutStatus")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "success").Observe(duration)
+	}()
+
+	email, err := s.lockoutAccountEmail(ctx, userID, email)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Failed to resolve account")
+		s.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, repoError(err, ReasonUserNotFound)
+	}
+	if email == "" && ip == "" {
+		s.logger.Warn(ctx).Msg("No account or IP provided")
+		s.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("no account or IP"))
+		return nil, MissingFields("user ID, email or IP is required", "user_id", "email", "ip")
+	}
+
+	status := &LockoutStatus{}
+	if email != "" {
+		if status.Account, err = s.repo.GetLoginThrottle(ctx, accountThrottleKey(email)); err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to get account lockout state")
+			s.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, internalError("failed to get lockout status")
+		}
+	}
+	if ip != "" {
+		if status.IP, err = s.repo.GetLoginThrottle(ctx, ipThrottleKey(ip)); err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to get IP lockout state")
+			s.metrics.RequestDuration().WithLabelValues("GetLockoutStatus", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, internalError("failed to get lockout status")
+		}
+	}
+	return status, nil
+}
+
+// UnlockAccount clears the failed logins of an account, named by userID or
+// email, and of a client IP on behalf of actorID, lifting any lockout.
+func (s *UserService) UnlockAccount(ctx context.Context, actorID, userID, email, ip string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.UnlockAccount")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("UnlockAccount", "success").Observe(duration)
+	}()
+
+	span.SetAttributes(attribute.String("actor_id", actorID))
+	email, err := s.lockoutAccountEmail(ctx, userID, email)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Failed to resolve account")
+		s.metrics.RequestDuration().WithLabelValues("UnlockAccount", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return repoError(err, ReasonUserNotFound)
+	}
+	if email == "" && ip == "" {
+		s.logger.Warn(ctx).Msg("No account or IP provided")
+		s.metrics.RequestDuration().WithLabelValues("UnlockAccount", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("no account or IP"))
+		return MissingFields("user ID, email or IP is required", "user_id", "email", "ip")
+	}
+
+	var keys []string
+	if email != "" {
+		keys = append(keys, accountThrottleKey(email))
+	}
+	if ip != "" {
+		keys = append(keys, ipThrottleKey(ip))
+	}
+	cleared := false
+	for _, key := range keys {
+		ok, err := s.repo.ClearLoginThrottle(ctx, key)
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to clear login throttle")
+			s.metrics.RequestDuration().WithLabelValues("UnlockAccount", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return internalError("failed to unlock")
+		}
+		if ok {
+			s.logger.Info(ctx).Msgf("Login failures for %s cleared by %s", key, actorID)
+		}
+		cleared = cleared || ok
+	}
+	if !cleared {
+		s.logger.Warn(ctx).Msg("No failed logins to clear")
+		s.metrics.RequestDuration().WithLabelValues("UnlockAccount", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("nothing to unlock"))
+		return NewError(CodeNotFound, ReasonNoFailedLogins, "no failed logins recorded")
+	}
+	return nil
+}
+
+// lockoutAccountEmail returns email, or the email of userID if email is
+// empty.
+func (s *UserService) lockoutAccountEmail(ctx context.Context, userID, email string) (string, error) {
+	if email != "" || userID == "" {
+		return strings.TrimSpace(email), nil
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", repoError(err, ReasonUserNotFound)
+	}
+	return user.Email, nil
+}
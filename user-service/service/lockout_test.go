@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/totp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/peer"
+)
+
+// newLockoutRepo extends newRefreshTokenRepo with login throttles kept in a
+// map.
+func newLockoutRepo() (*MockUserRepository, map[string]*model.LoginThrottle) {
+	repo, _ := newRefreshTokenRepo()
+	throttles := map[string]*model.LoginThrottle{}
+	repo.GetUserByIDFunc = func(ctx context.Context, id string) (*model.User, error) {
+		if id != "user123" {
+			return nil, errors.New("user not found")
+		}
+		return &model.User{ID: id, Email: "test@example.com"}, nil
+	}
+	repo.GetLoginThrottleFunc = func(ctx context.Context, key string) (*model.LoginThrottle, error) {
+		if t, ok := throttles[key]; ok {
+			copied := *t
+			return &copied, nil
+		}
+		return nil, nil
+	}
+	repo.RecordLoginFailureFunc = func(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+		t, ok := throttles[key]
+		if !ok || t.LastFailureAt.Before(windowStart) {
+			t = &model.LoginThrottle{Key: key}
+			throttles[key] = t
+		}
+		t.Failures++
+		t.LastFailureAt = now
+		return t.Failures, nil
+	}
+	repo.BlockLoginFunc = func(ctx context.Context, key string, until time.Time, locked bool) error {
+		throttles[key].BlockedUntil = &until
+		throttles[key].Locked = locked
+		return nil
+	}
+	repo.ClearLoginThrottleFunc = func(ctx context.Context, key string) (bool, error) {
+		_, ok := throttles[key]
+		delete(throttles, key)
+		return ok, nil
+	}
+	return repo, throttles
+}
+
+func newLockoutTestService(repo *MockUserRepository, lockout config.LockoutConfig) *UserService {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+		Lockout: lockout,
+	}
+	return NewUserService(repo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+}
+
+func fromIP(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+}
+
+func TestUserService_Login_AccountLockout(t *testing.T) {
+	repo, throttles := newLockoutRepo()
+	service := newLockoutTestService(repo, config.LockoutConfig{
+		Enabled:        true,
+		WindowMinutes:  30,
+		LockoutMinutes: 15,
+		Account:        config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 3},
+		IP:             config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 100},
+	})
+	ctx := fromIP("10.0.0.7")
+	lockouts := testutil.ToFloat64(service.metrics.Lockouts().WithLabelValues(LockoutScopeAccount))
+
+	// A success resets the account's count
+	for i := 0; i < 2; i++ {
+		if _, err := service.Login(ctx, "test@example.com", "wrong"); err == nil || err.Error() != "invalid credentials" {
+			t.Fatalf("Login() with a wrong password error = %v", err)
+		}
+	}
+	if _, err := service.Login(ctx, "test@example.com", "password123"); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, ok := throttles["account:test@example.com"]; ok {
+		t.Error("Login() success kept the account's failures")
+	}
+
+	for i := 0; i < 3; i++ {
+		service.Login(ctx, "Test@Example.com", "wrong")
+	}
+	_, err := service.Login(ctx, "test@example.com", "password123")
+	var blocked *LoginBlockedError
+	if !errors.As(err, &blocked) || blocked.Scope != LockoutScopeAccount || !blocked.Locked || blocked.RetryAfter <= 14*time.Minute {
+		t.Fatalf("Login() while locked error = %v", err)
+	}
+	if got := testutil.ToFloat64(service.metrics.Lockouts().WithLabelValues(LockoutScopeAccount)); got != lockouts+1 {
+		t.Errorf("lockout counter = %v, want %v", got, lockouts+1)
+	}
+
+	status, err := service.GetLockoutStatus(context.Background(), "user123", "", "10.0.0.7")
+	if err != nil {
+		t.Fatalf("GetLockoutStatus() error = %v", err)
+	}
+	if status.Account == nil || status.Account.Failures != 3 || !status.Account.Locked || status.IP == nil || status.IP.Failures != 5 {
+		t.Errorf("GetLockoutStatus() = %+v", status)
+	}
+	if _, err := service.GetLockoutStatus(context.Background(), "", "", ""); err == nil {
+		t.Error("GetLockoutStatus() without account or IP expected error")
+	}
+
+	if err := service.UnlockAccount(context.Background(), "admin1", "", "test@example.com", ""); err != nil {
+		t.Fatalf("UnlockAccount() error = %v", err)
+	}
+	if err := service.UnlockAccount(context.Background(), "admin1", "", "test@example.com", ""); err == nil {
+		t.Error("UnlockAccount() twice expected error")
+	}
+	if _, err := service.Login(ctx, "test@example.com", "password123"); err != nil {
+		t.Errorf("Login() after unlock error = %v", err)
+	}
+}
+
+func TestUserService_ApplyConfig_Lockout(t *testing.T) {
+	repo, throttles := newLockoutRepo()
+	service := newLockoutTestService(repo, config.LockoutConfig{})
+	ctx := fromIP("10.0.0.7")
+
+	for i := 0; i < 3; i++ {
+		service.Login(ctx, "test@example.com", "wrong")
+	}
+	if len(throttles) != 0 {
+		t.Fatalf("Login() with lockout disabled recorded %d throttles", len(throttles))
+	}
+
+	cfg := *service.cfg
+	cfg.Lockout = config.LockoutConfig{
+		Enabled:        true,
+		WindowMinutes:  30,
+		LockoutMinutes: 15,
+		Account:        config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 2},
+		IP:             config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 100},
+	}
+	service.ApplyConfig(&cfg)
+	for i := 0; i < 2; i++ {
+		service.Login(ctx, "test@example.com", "wrong")
+	}
+	var blocked *LoginBlockedError
+	if _, err := service.Login(ctx, "test@example.com", "password123"); !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("Login() after enabling lockout error = %v", err)
+	}
+
+	cfg.Lockout.Enabled = false
+	service.ApplyConfig(&cfg)
+	if _, err := service.Login(ctx, "test@example.com", "password123"); err != nil {
+		t.Errorf("Login() after disabling lockout error = %v", err)
+	}
+}
+
+func TestUserService_VerifyMFA_Lockout(t *testing.T) {
+	repo := newMFARepo(model.RoleStudent)
+	lockoutRepo, throttles := newLockoutRepo()
+	repo.GetLoginThrottleFunc = lockoutRepo.GetLoginThrottleFunc
+	repo.RecordLoginFailureFunc = lockoutRepo.RecordLoginFailureFunc
+	repo.BlockLoginFunc = lockoutRepo.BlockLoginFunc
+	repo.ClearLoginThrottleFunc = lockoutRepo.ClearLoginThrottleFunc
+	service := newMFATestService(repo)
+	cfg := *service.cfg
+	cfg.Lockout = config.LockoutConfig{
+		Enabled:        true,
+		WindowMinutes:  30,
+		LockoutMinutes: 15,
+		Account:        config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 3},
+		IP:             config.LockoutThresholds{BackoffAfter: 100, LockoutAfter: 100},
+	}
+	service.ApplyConfig(&cfg)
+	ctx := fromIP("10.0.0.7")
+
+	enrollment, err := service.EnrollMFA(ctx, "user123")
+	if err != nil {
+		t.Fatalf("EnrollMFA() error = %v", err)
+	}
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	recoveryCodes, err := service.ConfirmMFA(ctx, "user123", code)
+	if err != nil {
+		t.Fatalf("ConfirmMFA() error = %v", err)
+	}
+
+	// Each login starts a new challenge, but the wrong codes add up
+	var mfaToken string
+	for i := 0; i < 3; i++ {
+		tokens, err := service.Login(ctx, "test@example.edu", "password123")
+		if err != nil || tokens.MFAToken == "" {
+			t.Fatalf("Login() %d = %+v, %v", i, tokens, err)
+		}
+		mfaToken = tokens.MFAToken
+		if _, err := service.VerifyMFA(ctx, mfaToken, "wrong"); err == nil {
+			t.Fatalf("VerifyMFA() %d with a wrong code expected error", i)
+		}
+	}
+	if throttle := throttles["account:test@example.edu"]; throttle == nil || !throttle.Locked {
+		t.Fatalf("account throttle after wrong codes = %+v, want locked", throttle)
+	}
+	if throttle := throttles["ip:10.0.0.7"]; throttle == nil || throttle.Failures != 3 {
+		t.Errorf("IP throttle after wrong codes = %+v, want 3 failures", throttle)
+	}
+
+	var blocked *LoginBlockedError
+	if _, err := service.Login(ctx, "test@example.edu", "password123"); !errors.As(err, &blocked) || blocked.Scope != LockoutScopeAccount {
+		t.Errorf("Login() while locked error = %v", err)
+	}
+	if _, err := service.VerifyMFA(ctx, mfaToken, recoveryCodes[0]); !errors.As(err, &blocked) {
+		t.Errorf("VerifyMFA() while locked error = %v", err)
+	}
+
+	// Only a complete login forgives the account's failures
+	if err := service.UnlockAccount(context.Background(), "admin1", "user123", "", ""); err != nil {
+		t.Fatalf("UnlockAccount() error = %v", err)
+	}
+	tokens, err := service.Login(ctx, "test@example.edu", "password123")
+	if err != nil {
+		t.Fatalf("Login() after unlock error = %v", err)
+	}
+	service.VerifyMFA(ctx, tokens.MFAToken, "wrong")
+	if throttle := throttles["account:test@example.edu"]; throttle == nil || throttle.Failures != 1 {
+		t.Errorf("account throttle after a wrong code = %+v, want 1 failure", throttle)
+	}
+	if _, err := service.VerifyMFA(ctx, tokens.MFAToken, recoveryCodes[0]); err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if _, ok := throttles["account:test@example.edu"]; ok {
+		t.Error("VerifyMFA() success kept the account's failures")
+	}
+}
+
+func TestUserService_Login_Backoff(t *testing.T) {
+	repo, throttles := newLockoutRepo()
+	service := newLockoutTestService(repo, config.LockoutConfig{
+		Enabled:            true,
+		WindowMinutes:      30,
+		BackoffBaseSeconds: 60,
+		BackoffMaxSeconds:  600,
+		LockoutMinutes:     15,
+		Account:            config.LockoutThresholds{BackoffAfter: 2, LockoutAfter: 10},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		service.Login(ctx, "test@example.com", "wrong")
+	}
+	throttle := throttles["account:test@example.com"]
+	if throttle.BlockedUntil == nil || throttle.Locked {
+		t.Fatalf("throttle after backoff = %+v", throttle)
+	}
+	_, err := service.Login(ctx, "test@example.com", "password123")
+	var blocked *LoginBlockedError
+	if !errors.As(err, &blocked) || blocked.Locked || blocked.RetryAfter > time.Minute {
+		t.Fatalf("Login() during backoff error = %v", err)
+	}
+	if err.Error() != "too many failed logins, try again in 1m0s" {
+		t.Errorf("Login() during backoff message = %q", err.Error())
+	}
+	if len(throttles) != 1 {
+		t.Errorf("throttles without a peer = %v, want only the account", throttles)
+	}
+}
+
+func TestUserService_Login_IPLockout(t *testing.T) {
+	repo, _ := newLockoutRepo()
+	service := newLockoutTestService(repo, config.LockoutConfig{
+		Enabled:        true,
+		WindowMinutes:  30,
+		LockoutMinutes: 15,
+		Account:        config.LockoutThresholds{LockoutAfter: 100},
+		IP:             config.LockoutThresholds{LockoutAfter: 3},
+	})
+	attacker := fromIP("192.0.2.1")
+
+	// Spraying different accounts from one address locks the address
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		service.Login(attacker, email, "wrong")
+	}
+	_, err := service.Login(attacker, "test@example.com", "password123")
+	var blocked *LoginBlockedError
+	if !errors.As(err, &blocked) || blocked.Scope != LockoutScopeIP || !blocked.Locked {
+		t.Fatalf("Login() from a locked IP error = %v", err)
+	}
+	if _, err := service.Login(fromIP("192.0.2.2"), "test@example.com", "password123"); err != nil {
+		t.Errorf("Login() from another IP error = %v", err)
+	}
+
+	if err := service.UnlockAccount(context.Background(), "admin1", "", "", "192.0.2.1"); err != nil {
+		t.Fatalf("UnlockAccount() of an IP error = %v", err)
+	}
+	if _, err := service.Login(attacker, "test@example.com", "password123"); err != nil {
+		t.Errorf("Login() after unlocking the IP error = %v", err)
+	}
+}
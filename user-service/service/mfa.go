@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/totp"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Recovery codes are recoveryCodeLength characters from recoveryCodeAlphabet,
+// shown split in two halves. The alphabet leaves out look-alike characters.
+const (
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// MFAEnrollment is the secret of a pending TOTP enrollment.
+type MFAEnrollment struct {
+	Secret          string
+	ProvisioningURI string // otpauth:// URI to render as a QR code
+}
+
+// EnrollMFA starts TOTP enrollment for a user. The returned secret is only
+// enforced after ConfirmMFA; enrolling again before that replaces it.
+func (s *UserService) EnrollMFA(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.EnrollMFA")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "success").Observe(duration)
+	}()
+
+	if userID == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID provided")
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID"))
+		return nil, MissingFields("user ID is required", "user_id")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, repoError(err, ReasonUserNotFound)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate TOTP secret")
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to start enrollment")
+	}
+	saved, err := s.repo.SaveMFASecret(ctx, userID, secret)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to save TOTP secret")
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to start enrollment")
+	}
+	if !saved {
+		s.logger.Warn(ctx).Msgf("MFA already enabled for user: %s", userID)
+		s.metrics.RequestDuration().WithLabelValues("EnrollMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa already enabled"))
+		return nil, NewError(CodeFailedPrecondition, ReasonMFAAlreadyEnabled, "two-factor authentication is already enabled")
+	}
+
+	s.logger.Info(ctx).Msgf("MFA enrollment started for user: %s", userID)
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.cfg.MFA.Issuer, user.Email),
+	}, nil
+}
+
+// ConfirmMFA enables the pending enrollment of a user once they prove they
+// can generate codes, and returns their recovery codes. The plaintext codes
+// are only returned here. Tokens flagged for enrollment must be refreshed to
+// lose the flag.
+func (s *UserService) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.ConfirmMFA")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "success").Observe(duration)
+	}()
+
+	if userID == "" || code == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID or code provided")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID or code"))
+		return nil, MissingFields("code is required", "code")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	settings, err := s.repo.GetMFASettings(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get MFA settings")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to confirm enrollment")
+	}
+	if settings == nil {
+		s.logger.Warn(ctx).Msg("No pending MFA enrollment")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("no pending enrollment"))
+		return nil, NewError(CodeFailedPrecondition, ReasonMFANotStarted, "two-factor enrollment has not been started")
+	}
+	if settings.Enabled {
+		s.logger.Warn(ctx).Msgf("MFA already enabled for user: %s", userID)
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa already enabled"))
+		return nil, NewError(CodeFailedPrecondition, ReasonMFAAlreadyEnabled, "two-factor authentication is already enabled")
+	}
+
+	step, ok := totp.Validate(settings.Secret, code, time.Now(), s.cfg.MFA.Skew)
+	if !ok {
+		s.logger.Warn(ctx).Msg("Invalid TOTP code provided")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("invalid code"))
+		return nil, invalidField(ReasonInvalidMFACode, "code", "invalid two-factor code")
+	}
+
+	codes := make([]string, s.cfg.MFA.RecoveryCodeCount())
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to generate recovery code")
+			s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, internalError("failed to confirm enrollment")
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	enabled, err := s.repo.EnableMFA(ctx, userID, step, hashes)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to enable MFA")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to confirm enrollment")
+	}
+	if !enabled {
+		// A concurrent confirmation won.
+		s.metrics.RequestDuration().WithLabelValues("ConfirmMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa already enabled"))
+		return nil, NewError(CodeFailedPrecondition, ReasonMFAAlreadyEnabled, "two-factor authentication is already enabled")
+	}
+
+	s.logger.Info(ctx).Msgf("MFA enabled for user: %s", userID)
+	return codes, nil
+}
+
+// DisableMFA removes the second factor of a user after checking a current
+// TOTP code or an unused recovery code. Accounts whose role requires a
+// second factor cannot disable it.
+func (s *UserService) DisableMFA(ctx context.Context, userID, code string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.DisableMFA")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "success").Observe(duration)
+	}()
+
+	if userID == "" || code == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID or code provided")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID or code"))
+		return MissingFields("code is required", "code")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return repoError(err, ReasonUserNotFound)
+	}
+	if s.mfaRequired(user) {
+		s.logger.Warn(ctx).Msgf("Refused to disable required MFA for user: %s", userID)
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa required"))
+		return NewError(CodeFailedPrecondition, ReasonMFARequired, "two-factor authentication is required for this account")
+	}
+
+	settings, err := s.repo.GetMFASettings(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get MFA settings")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to disable two-factor authentication")
+	}
+	if settings == nil || !settings.Enabled {
+		s.logger.Warn(ctx).Msg("MFA not enabled")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa not enabled"))
+		return NewError(CodeFailedPrecondition, ReasonMFANotEnabled, "two-factor authentication is not enabled")
+	}
+
+	ok, err := s.checkSecondFactor(ctx, settings, code)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to verify second factor")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to disable two-factor authentication")
+	}
+	if !ok {
+		s.logger.Warn(ctx).Msg("Invalid second factor provided")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("invalid code"))
+		return invalidField(ReasonInvalidMFACode, "code", "invalid two-factor code")
+	}
+
+	if err := s.repo.DisableMFA(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to disable MFA")
+		s.metrics.RequestDuration().WithLabelValues("DisableMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to disable two-factor authentication")
+	}
+
+	s.logger.Info(ctx).Msgf("MFA disabled for user: %s", userID)
+	return nil
+}
+
+// VerifyMFA completes a login that returned an MFA token. code is either a
+// current TOTP code or an unused recovery code. A challenge is refused once
+// it has expired, been redeemed, or seen too many wrong codes.
+func (s *UserService) VerifyMFA(ctx context.Context, mfaToken, code string) (*TokenPair, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.VerifyMFA")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "success").Observe(duration)
+	}()
+
+	if mfaToken == "" || code == "" {
+		s.logger.Warn(ctx).Msg("Empty MFA token or code provided")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty MFA token or code"))
+		return nil, MissingFields("MFA token and code are required", "mfa_token", "code")
+	}
+
+	challenge, err := s.repo.GetMFAChallengeByHash(ctx, hashToken(mfaToken))
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Unknown MFA token")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidMFAToken, "invalid or expired MFA token")
+	}
+	span.SetAttributes(attribute.String("user_id", challenge.UserID))
+
+	if challenge.Used() || challenge.Expired(time.Now()) || challenge.Attempts >= s.cfg.MFA.Attempts() {
+		s.logger.Warn(ctx).Msg("Used, expired or exhausted MFA challenge provided")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("unusable MFA challenge"))
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidMFAToken, "invalid or expired MFA token")
+	}
+
+	// Wrong codes count against the same throttles as wrong passwords, so
+	// that starting a new challenge does not allow more guesses.
+	rc := s.runtimeCfg.Load()
+	var throttles []throttleKey
+	if rc.lockout.Enabled {
+		user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to get user by ID")
+			s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, internalError("failed to verify code")
+		}
+		throttles = s.loginThrottleKeys(ctx, &rc.lockout, user.Email)
+		if err := s.checkLoginThrottles(ctx, throttles); err != nil {
+			var blocked *LoginBlockedError
+			if !errors.As(err, &blocked) {
+				s.logger.Error(ctx).Err(err).Msg("Failed to check login throttles")
+				s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+				span.RecordError(err)
+				return nil, NewError(CodeUnavailable, ReasonLoginUnavailable, "login temporarily unavailable")
+			}
+			s.logger.Warn(ctx).Msgf("Second factor blocked for user: %s", challenge.UserID)
+			s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, loginBlockedError(blocked)
+		}
+	}
+
+	settings, err := s.repo.GetMFASettings(ctx, challenge.UserID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get MFA settings")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to verify code")
+	}
+	if settings == nil || !settings.Enabled {
+		// The factor was removed after the challenge was issued.
+		s.logger.Warn(ctx).Msg("MFA no longer enabled for challenge")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("mfa not enabled"))
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidMFAToken, "invalid or expired MFA token")
+	}
+
+	ok, err := s.checkSecondFactor(ctx, settings, code)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to verify second factor")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to verify code")
+	}
+	if !ok {
+		if err := s.repo.RecordMFAChallengeFailure(ctx, challenge.ID); err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to record MFA challenge failure")
+		}
+		s.recordLoginFailure(ctx, throttles)
+		s.logger.Warn(ctx).Msg("Invalid second factor provided")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("invalid code"))
+		return nil, invalidField(ReasonInvalidMFACode, "code", "invalid two-factor code")
+	}
+	s.clearAccountFailures(ctx, throttles)
+
+	redeemed, err := s.repo.RedeemMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to redeem MFA challenge")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to verify code")
+	}
+	if !redeemed {
+		// Another request redeemed the same challenge first.
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("challenge already redeemed"))
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidMFAToken, "invalid or expired MFA token")
+	}
+
+	tokens, err := s.issueTokens(ctx, challenge.UserID, uuid.New().String())
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate tokens")
+		s.metrics.RequestDuration().WithLabelValues("VerifyMFA", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to generate token")
+	}
+
+	s.logger.Info(ctx).Msgf("User logged in with second factor: %s", challenge.UserID)
+	return tokens, nil
+}
+
+// newMFAChallenge stores a challenge for the second step of a login and
+// returns its token.
+func (s *UserService) newMFAChallenge(ctx context.Context, userID string) (*TokenPair, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	challenge := &model.MFAChallenge{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.MFA.ChallengeDuration()),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateMFAChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		MFAToken:     token,
+		MFAExpiresIn: s.cfg.MFA.ChallengeDuration(),
+	}, nil
+}
+
+// checkSecondFactor accepts a TOTP code that has not been used before, or
+// redeems a recovery code.
+func (s *UserService) checkSecondFactor(ctx context.Context, settings *model.MFASettings, code string) (bool, error) {
+	if step, ok := totp.Validate(settings.Secret, code, time.Now(), s.cfg.MFA.Skew); ok {
+		return s.repo.UpdateMFALastStep(ctx, settings.UserID, step)
+	}
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false, nil
+	}
+	return s.repo.UseRecoveryCode(ctx, settings.UserID, hashToken(normalized))
+}
+
+// mfaRequired reports whether the campus role of user requires a second
+// factor.
+func (s *UserService) mfaRequired(user *model.User) bool {
+	for _, role := range s.cfg.MFA.RequiredRoles {
+		if user.Role != "" && user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// newRecoveryCode returns a random recovery code such as "abcde-fghjk".
+func newRecoveryCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode strips the separators and case users may type.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
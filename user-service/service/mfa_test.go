@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/totp"
+)
+
+// newMFARepo extends newRefreshTokenRepo with MFA state kept in memory for
+// user123, whose campus role is role.
+func newMFARepo(role string) *MockUserRepository {
+	repo, _ := newRefreshTokenRepo()
+	getByEmail := repo.GetUserByEmailFunc
+	repo.GetUserByEmailFunc = func(ctx context.Context, email string) (*model.User, error) {
+		user, err := getByEmail(ctx, email)
+		if err == nil {
+			user.Role = role
+		}
+		return user, err
+	}
+	repo.GetUserByIDFunc = func(ctx context.Context, id string) (*model.User, error) {
+		if id != "user123" {
+			return nil, errors.New("user not found")
+		}
+		return &model.User{ID: id, Email: "test@example.edu", Role: role}, nil
+	}
+
+	var settings *model.MFASettings
+	recovery := map[string]bool{}
+	challenges := map[string]*model.MFAChallenge{}
+	repo.GetMFASettingsFunc = func(ctx context.Context, userID string) (*model.MFASettings, error) {
+		if settings == nil || userID != settings.UserID {
+			return nil, nil
+		}
+		copied := *settings
+		return &copied, nil
+	}
+	repo.SaveMFASecretFunc = func(ctx context.Context, userID, secret string) (bool, error) {
+		if settings != nil && settings.Enabled {
+			return false, nil
+		}
+		settings = &model.MFASettings{UserID: userID, Secret: secret}
+		return true, nil
+	}
+	repo.EnableMFAFunc = func(ctx context.Context, userID string, step int64, hashes []string) (bool, error) {
+		if settings == nil || settings.Enabled {
+			return false, nil
+		}
+		settings.Enabled = true
+		settings.LastUsedStep = step
+		for _, hash := range hashes {
+			recovery[hash] = true
+		}
+		return true, nil
+	}
+	repo.DisableMFAFunc = func(ctx context.Context, userID string) error {
+		settings = nil
+		recovery = map[string]bool{}
+		return nil
+	}
+	repo.UpdateMFALastStepFunc = func(ctx context.Context, userID string, step int64) (bool, error) {
+		if step <= settings.LastUsedStep {
+			return false, nil
+		}
+		settings.LastUsedStep = step
+		return true, nil
+	}
+	repo.UseRecoveryCodeFunc = func(ctx context.Context, userID, codeHash string) (bool, error) {
+		if !recovery[codeHash] {
+			return false, nil
+		}
+		delete(recovery, codeHash)
+		return true, nil
+	}
+	repo.CreateMFAChallengeFunc = func(ctx context.Context, challenge *model.MFAChallenge) error {
+		challenges[challenge.TokenHash] = challenge
+		return nil
+	}
+	repo.GetMFAChallengeByHashFunc = func(ctx context.Context, hash string) (*model.MFAChallenge, error) {
+		if c, ok := challenges[hash]; ok {
+			copied := *c
+			return &copied, nil
+		}
+		return nil, errors.New("mfa challenge not found")
+	}
+	repo.RecordMFAChallengeFailureFunc = func(ctx context.Context, id string) error {
+		for _, c := range challenges {
+			if c.ID == id {
+				c.Attempts++
+			}
+		}
+		return nil
+	}
+	repo.RedeemMFAChallengeFunc = func(ctx context.Context, id string) (bool, error) {
+		for _, c := range challenges {
+			if c.ID == id && c.UsedAt == nil {
+				now := time.Now()
+				c.UsedAt = &now
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return repo
+}
+
+func newMFATestService(repo *MockUserRepository, requiredRoles ...string) *UserService {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+		MFA: config.MFAConfig{
+			Issuer:        "GoCampus",
+			MaxAttempts:   3,
+			Skew:          1,
+			RecoveryCodes: 4,
+			RequiredRoles: requiredRoles,
+		},
+	}
+	return NewUserService(repo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+}
+
+func TestUserService_MFA(t *testing.T) {
+	service := newMFATestService(newMFARepo(model.RoleStudent))
+	ctx := context.Background()
+
+	if _, err := service.ConfirmMFA(ctx, "user123", "123456"); err == nil {
+		t.Error("ConfirmMFA() before enrollment expected error")
+	}
+	enrollment, err := service.EnrollMFA(ctx, "user123")
+	if err != nil {
+		t.Fatalf("EnrollMFA() error = %v", err)
+	}
+	if enrollment.Secret == "" || enrollment.ProvisioningURI != totp.ProvisioningURI(enrollment.Secret, "GoCampus", "test@example.edu") {
+		t.Errorf("EnrollMFA() = %+v", enrollment)
+	}
+
+	// Logins are not challenged until enrollment is confirmed
+	tokens, err := service.Login(ctx, "test@example.edu", "password123")
+	if err != nil || tokens.AccessToken == "" || tokens.MFAToken != "" {
+		t.Fatalf("Login() before confirmation = %+v, %v", tokens, err)
+	}
+
+	if _, err := service.ConfirmMFA(ctx, "user123", "000000"); err == nil {
+		t.Error("ConfirmMFA() with a wrong code expected error")
+	}
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	recoveryCodes, err := service.ConfirmMFA(ctx, "user123", code)
+	if err != nil {
+		t.Fatalf("ConfirmMFA() error = %v", err)
+	}
+	if len(recoveryCodes) != 4 || len(recoveryCodes[0]) != recoveryCodeLength+1 {
+		t.Errorf("ConfirmMFA() recovery codes = %v", recoveryCodes)
+	}
+	if _, err := service.EnrollMFA(ctx, "user123"); err == nil {
+		t.Error("EnrollMFA() after confirmation expected error")
+	}
+
+	login := func() string {
+		t.Helper()
+		tokens, err := service.Login(ctx, "test@example.edu", "password123")
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		if tokens.AccessToken != "" || tokens.RefreshToken != "" || tokens.MFAToken == "" || tokens.MFAExpiresIn != 5*time.Minute {
+			t.Fatalf("Login() with MFA = %+v", tokens)
+		}
+		return tokens.MFAToken
+	}
+
+	// The confirmation code cannot be replayed; the next step's code works
+	mfaToken := login()
+	if _, err := service.VerifyMFA(ctx, mfaToken, code); err == nil {
+		t.Error("VerifyMFA() with a replayed code expected error")
+	}
+	next, _ := totp.Code(enrollment.Secret, time.Now().Add(totp.Period))
+	tokens, err = service.VerifyMFA(ctx, mfaToken, next)
+	if err != nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("VerifyMFA() = %+v, %v", tokens, err)
+	}
+	if _, err := service.VerifyMFA(ctx, mfaToken, recoveryCodes[0]); err == nil {
+		t.Error("VerifyMFA() with a redeemed MFA token expected error")
+	}
+
+	// Recovery codes are accepted once, regardless of case and separators
+	mfaToken = login()
+	typed := " " + strings.ToUpper(recoveryCodes[1][:3]) + " " + recoveryCodes[1][3:] + " "
+	if _, err := service.VerifyMFA(ctx, mfaToken, typed); err != nil {
+		t.Errorf("VerifyMFA() with a recovery code error = %v", err)
+	}
+	mfaToken = login()
+	if _, err := service.VerifyMFA(ctx, mfaToken, recoveryCodes[1]); err == nil {
+		t.Error("VerifyMFA() with a used recovery code expected error")
+	}
+
+	// Challenges stop accepting codes after MaxAttempts wrong ones
+	for i := 0; i < 2; i++ {
+		if _, err := service.VerifyMFA(ctx, mfaToken, "000000"); err == nil {
+			t.Error("VerifyMFA() with a wrong code expected error")
+		}
+	}
+	if _, err := service.VerifyMFA(ctx, mfaToken, recoveryCodes[2]); err == nil {
+		t.Error("VerifyMFA() on an exhausted challenge expected error")
+	}
+	if _, err := service.VerifyMFA(ctx, "unknown", recoveryCodes[2]); err == nil {
+		t.Error("VerifyMFA() with an unknown MFA token expected error")
+	}
+
+	if err := service.DisableMFA(ctx, "user123", "000000"); err == nil {
+		t.Error("DisableMFA() with a wrong code expected error")
+	}
+	if err := service.DisableMFA(ctx, "user123", recoveryCodes[2]); err != nil {
+		t.Fatalf("DisableMFA() error = %v", err)
+	}
+	if tokens, err := service.Login(ctx, "test@example.edu", "password123"); err != nil || tokens.MFAToken != "" {
+		t.Errorf("Login() after disabling = %+v, %v", tokens, err)
+	}
+}
+
+func TestUserService_MFA_RequiredRole(t *testing.T) {
+	repo := newMFARepo(model.RoleStaff)
+	service := newMFATestService(repo, model.RoleStaff)
+	ctx := context.Background()
+
+	tokens, err := service.Login(ctx, "test@example.edu", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := service.jwt.ParseClaims(tokens.AccessToken)
+	if err != nil || !tokens.MFAEnrollmentRequired || !claims.MFAEnrollmentRequired {
+		t.Fatalf("Login() enrollment flag = %v, claims %+v, %v", tokens.MFAEnrollmentRequired, claims, err)
+	}
+
+	enrollment, err := service.EnrollMFA(ctx, "user123")
+	if err != nil {
+		t.Fatalf("EnrollMFA() error = %v", err)
+	}
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	recoveryCodes, err := service.ConfirmMFA(ctx, "user123", code)
+	if err != nil {
+		t.Fatalf("ConfirmMFA() error = %v", err)
+	}
+
+	// Refreshing after enrollment lifts the restriction
+	refreshed, err := service.RefreshToken(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	claims, _ = service.jwt.ParseClaims(refreshed.AccessToken)
+	if refreshed.MFAEnrollmentRequired || claims.MFAEnrollmentRequired {
+		t.Errorf("RefreshToken() after enrollment still restricted: %+v", claims)
+	}
+
+	if err := service.DisableMFA(ctx, "user123", recoveryCodes[0]); err == nil {
+		t.Error("DisableMFA() for a required role expected error")
+	}
+
+	// Unaffected roles never get the flag
+	student := newMFATestService(newMFARepo(model.RoleStudent), model.RoleStaff)
+	tokens, err = student.Login(ctx, "test@example.edu", "password123")
+	if err != nil || tokens.MFAEnrollmentRequired {
+		t.Errorf("Login() for a student = %+v, %v", tokens, err)
+	}
+}
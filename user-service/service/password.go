@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errInvalidResetToken is returned for unknown, used and expired reset
+// tokens alike, so callers cannot tell them apart.
+var errInvalidResetToken = NewError(CodeInvalidArgument, ReasonInvalidResetToken, "invalid or expired reset token")
+
+// ChangePassword replaces the password of an authenticated user after
+// checking the current one. Every session of the user is revoked, so the
+// caller has to log in again with the new password.
+func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "success").Observe(duration)
+	}()
+
+	// Validate input
+	if userID == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID provided")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID"))
+		return MissingFields("user ID is required", "user_id")
+	}
+	if oldPassword == "" || newPassword == "" {
+		s.logger.Warn(ctx).Msg("Empty password provided")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty password"))
+		return MissingFields("old and new password are required", "old_password", "new_password")
+	}
+	if oldPassword == newPassword {
+		s.logger.Warn(ctx).Msg("New password equals old password")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("unchanged password"))
+		return invalidField(ReasonPasswordUnchanged, "new_password", "new password must differ from the old password")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return repoError(err, ReasonUserNotFound)
+	}
+
+	// Verify the current password
+	if ok, err := s.hasher.Verify(oldPassword, user.Password); err != nil || !ok {
+		s.logger.Warn(ctx).Msg("Invalid old password provided")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("invalid password"))
+		return invalidField(ReasonIncorrectPassword, "old_password", "old password is incorrect")
+	}
+	if err := s.passwords.Check(newPassword, pwpolicy.Subject{Email: user.Email, Nickname: user.Nickname}); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("New password rejected by policy")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return passwordPolicyError(err, "new_password")
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to hash password")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to hash password")
+	}
+	if err := s.repo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to update password")
+		s.metrics.RequestDuration().WithLabelValues("ChangePassword", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to change password")
+	}
+
+	s.revokeSessionsAfterPasswordChange(ctx, userID)
+	s.logger.Info(ctx).Msgf("Password changed for user: %s", userID)
+	return nil
+}
+
+// RequestPasswordReset sends a single-use reset token to the user with the
+// given email. Unknown emails are silently ignored so the response does not
+// reveal which accounts exist.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.RequestPasswordReset")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "success").Observe(duration)
+	}()
+
+	if email == "" {
+		s.logger.Warn(ctx).Msg("Empty email provided")
+		s.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty email"))
+		return MissingFields("email is required", "email")
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Password reset requested for unknown email")
+		return nil
+	}
+	span.SetAttributes(attribute.String("user_id", user.ID))
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate password reset token")
+		s.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to request password reset")
+	}
+	now := time.Now().UTC()
+	stored := &model.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.PasswordReset.TokenDuration()),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreatePasswordResetToken(ctx, stored); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to store password reset token")
+		s.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to request password reset")
+	}
+
+	msg := notify.Message{
+		Kind:    notify.KindPasswordReset,
+		To:      user.Email,
+		Subject: "Reset your GoCampus password",
+		Body: fmt.Sprintf("Use this code to reset your password: %s\nIt expires at %s. If you did not ask for a reset, ignore this message.",
+			token, stored.ExpiresAt.Format(time.RFC3339)),
+		Data: map[string]string{
+			"token":      token,
+			"expires_at": stored.ExpiresAt.Format(time.RFC3339),
+		},
+		SentAt: now,
+	}
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to send password reset token")
+		s.metrics.RequestDuration().WithLabelValues("RequestPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to request password reset")
+	}
+
+	s.logger.Info(ctx).Msgf("Password reset token issued for user: %s", user.ID)
+	return nil
+}
+
+// ConfirmPasswordReset redeems a reset token and sets a new password. The
+// token and every other outstanding token of the user become unusable, and
+// all sessions of the user are revoked.
+func (s *UserService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.ConfirmPasswordReset")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "success").Observe(duration)
+	}()
+
+	if token == "" || newPassword == "" {
+		s.logger.Warn(ctx).Msg("Empty reset token or password provided")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty reset token or password"))
+		return MissingFields("reset token and new password are required", "token", "new_password")
+	}
+
+	stored, err := s.repo.GetPasswordResetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Unknown password reset token")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return errInvalidResetToken
+	}
+	span.SetAttributes(attribute.String("user_id", stored.UserID))
+	if stored.Used() || stored.Expired(time.Now()) {
+		s.logger.Warn(ctx).Msg("Used or expired password reset token provided")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errInvalidResetToken)
+		return errInvalidResetToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to reset password")
+	}
+	if err := s.passwords.Check(newPassword, pwpolicy.Subject{Email: user.Email, Nickname: user.Nickname}); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("New password rejected by policy")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return passwordPolicyError(err, "new_password")
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to hash password")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to hash password")
+	}
+
+	reset, err := s.repo.ResetPassword(ctx, stored.ID, stored.UserID, hashedPassword)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to reset password")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to reset password")
+	}
+	if !reset {
+		// Another request redeemed the same token first.
+		s.logger.Warn(ctx).Msg("Password reset token already redeemed")
+		s.metrics.RequestDuration().WithLabelValues("ConfirmPasswordReset", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errInvalidResetToken)
+		return errInvalidResetToken
+	}
+
+	s.revokeSessionsAfterPasswordChange(ctx, stored.UserID)
+	s.logger.Info(ctx).Msgf("Password reset completed for user: %s", stored.UserID)
+	return nil
+}
+
+// revokeSessionsAfterPasswordChange logs out every session of a user whose
+// password changed. The password change itself has already been stored, so
+// failures are only logged.
+func (s *UserService) revokeSessionsAfterPasswordChange(ctx context.Context, userID string) {
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke refresh tokens after password change")
+	}
+	if err := s.jwt.RevokeUserTokens(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke access tokens after password change")
+	}
+}
+
+// rehashPassword replaces oldHash, which password was just verified against,
+// with a hash made with the current algorithm and parameters. The login
+// succeeds either way, so failures are only logged and the rehash is
+// retried on the next login.
+func (s *UserService) rehashPassword(ctx context.Context, userID, oldHash, password string) {
+	newHash, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to rehash password")
+		return
+	}
+	ok, err := s.repo.RehashPassword(ctx, userID, oldHash, newHash)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to store rehashed password")
+		return
+	}
+	if ok {
+		s.logger.Info(ctx).Msgf("Password rehashed for user: %s", userID)
+	}
+}
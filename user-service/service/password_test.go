@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// captureNotifier records every message instead of delivering it.
+type captureNotifier struct {
+	messages []notify.Message
+}
+
+func (n *captureNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// passwordRepo is an in-memory repository for the password flows.
+type passwordRepo struct {
+	user          *model.User
+	resetTokens   map[string]*model.PasswordResetToken
+	revokedTokens bool
+}
+
+func newPasswordRepo(t *testing.T) (*MockUserRepository, *passwordRepo) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+	state := &passwordRepo{
+		user:        &model.User{ID: "user123", Email: "test@example.com", Password: string(hashedPassword)},
+		resetTokens: map[string]*model.PasswordResetToken{},
+	}
+	repo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			if id != state.user.ID {
+				return nil, errors.New("user not found")
+			}
+			u := *state.user
+			return &u, nil
+		},
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			if email != state.user.Email {
+				return nil, errors.New("user not found")
+			}
+			u := *state.user
+			return &u, nil
+		},
+		UpdatePasswordFunc: func(ctx context.Context, userID, passwordHash string) error {
+			state.user.Password = passwordHash
+			return nil
+		},
+		CreatePasswordResetTokenFunc: func(ctx context.Context, token *model.PasswordResetToken) error {
+			state.resetTokens[token.TokenHash] = token
+			return nil
+		},
+		GetPasswordResetTokenByHashFunc: func(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+			if tok, ok := state.resetTokens[hash]; ok {
+				copied := *tok
+				return &copied, nil
+			}
+			return nil, errors.New("password reset token not found")
+		},
+		ResetPasswordFunc: func(ctx context.Context, tokenID, userID, passwordHash string) (bool, error) {
+			now := time.Now()
+			var redeemed bool
+			for _, tok := range state.resetTokens {
+				if tok.ID == tokenID && !tok.Used() && !tok.Expired(now) {
+					redeemed = true
+				}
+			}
+			if !redeemed {
+				return false, nil
+			}
+			for _, tok := range state.resetTokens {
+				if tok.UserID == userID && tok.UsedAt == nil {
+					tok.UsedAt = &now
+				}
+			}
+			state.user.Password = passwordHash
+			return true, nil
+		},
+		RevokeUserRefreshTokensFunc: func(ctx context.Context, userID string) error {
+			state.revokedTokens = true
+			return nil
+		},
+	}
+	return repo, state
+}
+
+func newPasswordTestConfig() *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		PasswordReset: config.PasswordResetConfig{TokenMinutes: 30},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	cfg := newPasswordTestConfig()
+
+	tests := []struct {
+		name        string
+		userID      string
+		oldPassword string
+		newPassword string
+		wantErr     string
+	}{
+		{name: "Successful change", userID: "user123", oldPassword: "password123", newPassword: "newpassword456"},
+		{name: "Wrong old password", userID: "user123", oldPassword: "wrong", newPassword: "newpassword456", wantErr: "old password is incorrect"},
+		{name: "Unchanged password", userID: "user123", oldPassword: "password123", newPassword: "password123", wantErr: "new password must differ from the old password"},
+		{name: "Empty new password", userID: "user123", oldPassword: "password123", wantErr: "old and new password are required"},
+		{name: "Unknown user", userID: "invalid", oldPassword: "password123", newPassword: "newpassword456", wantErr: "user not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, state := newPasswordRepo(t)
+			service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+
+			err := service.ChangePassword(context.Background(), tt.userID, tt.oldPassword, tt.newPassword)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ChangePassword() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+			if ok, _ := service.hasher.Verify(tt.newPassword, state.user.Password); !ok {
+				t.Errorf("ChangePassword() did not store the new password")
+			}
+			if !state.revokedTokens {
+				t.Errorf("ChangePassword() did not revoke refresh tokens")
+			}
+		})
+	}
+}
+
+func TestUserService_PasswordReset(t *testing.T) {
+	cfg := newPasswordTestConfig()
+	mockRepo, state := newPasswordRepo(t)
+	notifier := &captureNotifier{}
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(notifier))
+	ctx := context.Background()
+
+	// Unknown emails succeed without sending anything.
+	if err := service.RequestPasswordReset(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() for unknown email error = %v", err)
+	}
+	if len(notifier.messages) != 0 {
+		t.Fatalf("RequestPasswordReset() for unknown email sent %d messages", len(notifier.messages))
+	}
+
+	if err := service.RequestPasswordReset(ctx, "test@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if len(notifier.messages) != 1 {
+		t.Fatalf("RequestPasswordReset() sent %d messages, want 1", len(notifier.messages))
+	}
+	msg := notifier.messages[0]
+	token := msg.Data["token"]
+	if msg.Kind != notify.KindPasswordReset || msg.To != "test@example.com" || token == "" {
+		t.Fatalf("RequestPasswordReset() message = %+v", msg)
+	}
+	if _, ok := state.resetTokens[token]; ok {
+		t.Errorf("RequestPasswordReset() stored the plaintext token")
+	}
+
+	if err := service.ConfirmPasswordReset(ctx, "bogus", "newpassword456"); err == nil || err.Error() != "invalid or expired reset token" {
+		t.Errorf("ConfirmPasswordReset() with unknown token error = %v", err)
+	}
+	if err := service.ConfirmPasswordReset(ctx, token, "newpassword456"); err != nil {
+		t.Fatalf("ConfirmPasswordReset() error = %v", err)
+	}
+	if ok, _ := service.hasher.Verify("newpassword456", state.user.Password); !ok {
+		t.Errorf("ConfirmPasswordReset() did not store the new password")
+	}
+	if !state.revokedTokens {
+		t.Errorf("ConfirmPasswordReset() did not revoke refresh tokens")
+	}
+
+	// Tokens are single-use.
+	if err := service.ConfirmPasswordReset(ctx, token, "anotherpassword789"); err == nil || err.Error() != "invalid or expired reset token" {
+		t.Errorf("ConfirmPasswordReset() replay error = %v", err)
+	}
+}
+
+func TestUserService_ConfirmPasswordReset_Expired(t *testing.T) {
+	cfg := newPasswordTestConfig()
+	mockRepo, state := newPasswordRepo(t)
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(&captureNotifier{}))
+
+	state.resetTokens[hashToken("expired")] = &model.PasswordResetToken{
+		ID:        "t1",
+		UserID:    "user123",
+		TokenHash: hashToken("expired"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	if err := service.ConfirmPasswordReset(context.Background(), "expired", "newpassword456"); err == nil || err.Error() != "invalid or expired reset token" {
+		t.Errorf("ConfirmPasswordReset() error = %v, want invalid or expired reset token", err)
+	}
+}
+
+func TestUserService_PasswordPolicy(t *testing.T) {
+	cfg := newPasswordTestConfig()
+	cfg.PasswordPolicy = config.PasswordPolicyConfig{MinLength: 10, RequireDigit: true, DisallowPersonalInfo: true}
+	mockRepo, state := newPasswordRepo(t)
+	state.user.Nickname = "Wombat"
+	notifier := &captureNotifier{}
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(notifier))
+	ctx := context.Background()
+
+	violations := func(err error) []string {
+		t.Helper()
+		var perr *pwpolicy.Error
+		if !errors.As(err, &perr) {
+			t.Fatalf("error = %v, want a *pwpolicy.Error", err)
+		}
+		var codes []string
+		for _, v := range perr.Violations {
+			codes = append(codes, v.Code)
+		}
+		return codes
+	}
+
+	_, err := service.Register(ctx, &model.User{Email: "new@example.com", Password: "short", Nickname: "Newbie"})
+	if got := violations(err); !reflect.DeepEqual(got, []string{pwpolicy.CodeTooShort, pwpolicy.CodeMissingDigit}) {
+		t.Errorf("Register() violations = %v", got)
+	}
+
+	err = service.ChangePassword(ctx, "user123", "password123", "my-wombat-2024")
+	if got := violations(err); !reflect.DeepEqual(got, []string{pwpolicy.CodeContainsNickname}) {
+		t.Errorf("ChangePassword() violations = %v", got)
+	}
+
+	if err := service.RequestPasswordReset(ctx, "test@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	token := notifier.messages[0].Data["token"]
+	err = service.ConfirmPasswordReset(ctx, token, "test@example.com1")
+	if got := violations(err); !reflect.DeepEqual(got, []string{pwpolicy.CodeContainsEmail}) {
+		t.Errorf("ConfirmPasswordReset() violations = %v", got)
+	}
+	// A rejected password leaves the token usable
+	if err := service.ConfirmPasswordReset(ctx, token, "correct-horse-42"); err != nil {
+		t.Errorf("ConfirmPasswordReset() error = %v", err)
+	}
+}
+
+func TestUserService_Login_Rehash(t *testing.T) {
+	repo, _ := newRefreshTokenRepo()
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	stored := string(legacy)
+	repo.GetUserByEmailFunc = func(ctx context.Context, email string) (*model.User, error) {
+		return &model.User{ID: "user123", Email: email, Password: stored}, nil
+	}
+	rehashes := 0
+	repo.RehashPasswordFunc = func(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+		if oldHash != stored {
+			return false, nil
+		}
+		stored = newHash
+		rehashes++
+		return true, nil
+	}
+	cfg := newPasswordTestConfig()
+	cfg.PasswordHashing = config.PasswordHashingConfig{
+		Algorithm: "argon2id",
+		Argon2id:  config.Argon2idConfig{MemoryKiB: 64, Iterations: 1, Parallelism: 1},
+	}
+	service := NewUserService(repo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+	ctx := context.Background()
+
+	// The bcrypt hash is replaced by an Argon2id one once, on the first login
+	for i := 0; i < 2; i++ {
+		if _, err := service.Login(ctx, "test@example.com", "password123"); err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+	}
+	if rehashes != 1 || !strings.HasPrefix(stored, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("after logins: %d rehashes, stored hash %q", rehashes, stored)
+	}
+
+	// A wrong password never triggers a rehash
+	cfg.PasswordHashing.Argon2id.Iterations = 2
+	service = NewUserService(repo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+	if _, err := service.Login(ctx, "test@example.com", "wrong"); err == nil {
+		t.Fatal("Login() with a wrong password expected error")
+	}
+	if rehashes != 1 {
+		t.Errorf("Login() with a wrong password rehashed")
+	}
+	if _, err := service.Login(ctx, "test@example.com", "password123"); err != nil || rehashes != 2 {
+		t.Errorf("Login() after raising the iterations = %v, %d rehashes", err, rehashes)
+	}
+}
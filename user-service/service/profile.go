@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Field mask paths accepted by UpdateUser.
+const (
+	FieldNickname = "nickname"
+	FieldAvatar   = "avatar"
+)
+
+const (
+	maxNicknameLength = 32
+	maxAvatarLength   = 2048
+)
+
+// profileFields maps each updatable field mask path to the function that
+// validates the new value and copies it onto the stored user.
+var profileFields = map[string]func(dst, src *model.User) error{
+	FieldNickname: func(dst, src *model.User) error {
+		nickname := strings.TrimSpace(src.Nickname)
+		if err := validateNickname(nickname); err != nil {
+			return err
+		}
+		dst.Nickname = nickname
+		return nil
+	},
+	FieldAvatar: func(dst, src *model.User) error {
+		avatar := strings.TrimSpace(src.Avatar)
+		if err := validateAvatar(avatar); err != nil {
+			return err
+		}
+		dst.Avatar = avatar
+		return nil
+	},
+}
+
+// UpdateUser applies the fields of changes named in paths to the user with
+// ID changes.ID. changes.Version must equal the stored version; a stale
+// version is rejected so concurrent edits are never silently overwritten.
+func (s *UserService) UpdateUser(ctx context.Context, changes *model.User, paths []string) (*model.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "success").Observe(duration)
+	}()
+
+	s.logger.Info(ctx).Msgf("Updating user %s fields %v", changes.ID, paths)
+
+	// Validate input
+	if changes.ID == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID provided")
+		err := MissingFields("user ID is required", "user.user_id")
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, err
+	}
+	if len(paths) == 0 {
+		s.logger.Warn(ctx).Msg("Empty update mask provided")
+		err := MissingFields("update mask is required", "update_mask")
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, err
+	}
+	if changes.Version <= 0 {
+		s.logger.Warn(ctx).Msg("Missing user version")
+		err := MissingFields("user version is required", "user.version")
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, err
+	}
+	for _, path := range paths {
+		if _, ok := profileFields[path]; !ok {
+			s.logger.Warn(ctx).Msgf("Unsupported update mask path: %s", path)
+			err := invalidField(ReasonFieldNotUpdatable, "update_mask", fmt.Sprintf("field %q cannot be updated", path))
+			s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	user, err := s.repo.GetUserByID(ctx, changes.ID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, repoError(err, ReasonUserNotFound)
+	}
+	if user.Version != changes.Version {
+		s.logger.Warn(ctx).Msgf("Stale version %d for user %s at version %d", changes.Version, user.ID, user.Version)
+		err := NewError(CodeAborted, ReasonVersionConflict, model.ErrVersionConflict.Error())
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if err := profileFields[path](user, changes); err != nil {
+			s.logger.Warn(ctx).Err(err).Msg("Invalid profile field")
+			s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to update user")
+		s.metrics.RequestDuration().WithLabelValues("UpdateUser", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, repoError(err, ReasonUserNotFound)
+	}
+
+	s.logger.Info(ctx).Msgf("User updated successfully: %s", user.ID)
+	span.SetAttributes(attribute.String("user_id", user.ID), attribute.Int64("version", user.Version))
+	return user, nil
+}
+
+// validateNickname checks a trimmed nickname.
+func validateNickname(nickname string) error {
+	if nickname == "" {
+		return invalidField(ReasonInvalidNickname, FieldNickname, "nickname must not be empty")
+	}
+	if utf8.RuneCountInString(nickname) > maxNicknameLength {
+		return invalidField(ReasonInvalidNickname, FieldNickname, fmt.Sprintf("nickname must be at most %d characters", maxNicknameLength))
+	}
+	for _, r := range nickname {
+		if unicode.IsControl(r) {
+			return invalidField(ReasonInvalidNickname, FieldNickname, "nickname must not contain control characters")
+		}
+	}
+	return nil
+}
+
+// validateAvatar checks a trimmed avatar reference. An empty value clears
+// the avatar; anything else must be an http(s) URL or an absolute path.
+func validateAvatar(avatar string) error {
+	if avatar == "" {
+		return nil
+	}
+	if len(avatar) > maxAvatarLength {
+		return invalidField(ReasonInvalidAvatar, FieldAvatar, fmt.Sprintf("avatar must be at most %d bytes", maxAvatarLength))
+	}
+	u, err := url.Parse(avatar)
+	if err != nil {
+		return invalidField(ReasonInvalidAvatar, FieldAvatar, "avatar must be a valid URL")
+	}
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		if u.Host == "" {
+			return invalidField(ReasonInvalidAvatar, FieldAvatar, "avatar URL must have a host")
+		}
+	case u.Scheme == "" && strings.HasPrefix(u.Path, "/"):
+	default:
+		return invalidField(ReasonInvalidAvatar, FieldAvatar, "avatar must be an http(s) URL or an absolute path")
+	}
+	return nil
+}
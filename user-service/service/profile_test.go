@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+)
+
+// newProfileRepo returns a mock repository holding one user at version 3
+// that enforces the version check like the Postgres implementation.
+func newProfileRepo() (*MockUserRepository, *model.User) {
+	stored := &model.User{
+		ID:       "user123",
+		Email:    "test@example.com",
+		Nickname: "TestUser",
+		Avatar:   "https://example.com/old.png",
+		Version:  3,
+	}
+	repo := &MockUserRepository{
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			if id != stored.ID {
+				return nil, errors.New("user not found")
+			}
+			u := *stored
+			return &u, nil
+		},
+		UpdateUserFunc: func(ctx context.Context, user *model.User) error {
+			if user.Version != stored.Version {
+				return errors.New("user was modified by another request")
+			}
+			user.Version++
+			user.UpdatedAt = time.Now().UTC()
+			*stored = *user
+			return nil
+		},
+	}
+	return repo, stored
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		changes      *model.User
+		paths        []string
+		wantErr      string
+		wantNickname string
+		wantAvatar   string
+	}{
+		{
+			name:         "Update nickname only",
+			changes:      &model.User{ID: "user123", Nickname: "  NewName ", Avatar: "ignored", Version: 3},
+			paths:        []string{FieldNickname},
+			wantNickname: "NewName",
+			wantAvatar:   "https://example.com/old.png",
+		},
+		{
+			name:         "Clear avatar",
+			changes:      &model.User{ID: "user123", Version: 3},
+			paths:        []string{FieldAvatar},
+			wantNickname: "TestUser",
+			wantAvatar:   "",
+		},
+		{
+			name:         "Update both fields",
+			changes:      &model.User{ID: "user123", Nickname: "NewName", Avatar: "/avatars/new.png", Version: 3},
+			paths:        []string{FieldNickname, FieldAvatar},
+			wantNickname: "NewName",
+			wantAvatar:   "/avatars/new.png",
+		},
+		{
+			name:    "Stale version",
+			changes: &model.User{ID: "user123", Nickname: "NewName", Version: 2},
+			paths:   []string{FieldNickname},
+			wantErr: "user was modified by another request",
+		},
+		{
+			name:    "Missing version",
+			changes: &model.User{ID: "user123", Nickname: "NewName"},
+			paths:   []string{FieldNickname},
+			wantErr: "user version is required",
+		},
+		{
+			name:    "Empty mask",
+			changes: &model.User{ID: "user123", Nickname: "NewName", Version: 3},
+			wantErr: "update mask is required",
+		},
+		{
+			name:    "Email is not updatable",
+			changes: &model.User{ID: "user123", Email: "new@example.com", Version: 3},
+			paths:   []string{"email"},
+			wantErr: `field "email" cannot be updated`,
+		},
+		{
+			name:    "Empty nickname",
+			changes: &model.User{ID: "user123", Nickname: "   ", Version: 3},
+			paths:   []string{FieldNickname},
+			wantErr: "nickname must not be empty",
+		},
+		{
+			name:    "Nickname too long",
+			changes: &model.User{ID: "user123", Nickname: strings.Repeat("名", maxNicknameLength+1), Version: 3},
+			paths:   []string{FieldNickname},
+			wantErr: "nickname must be at most 32 characters",
+		},
+		{
+			name:    "Invalid avatar scheme",
+			changes: &model.User{ID: "user123", Avatar: "javascript:alert(1)", Version: 3},
+			paths:   []string{FieldAvatar},
+			wantErr: "avatar must be an http(s) URL or an absolute path",
+		},
+		{
+			name:    "User not found",
+			changes: &model.User{ID: "invalid", Nickname: "NewName", Version: 3},
+			paths:   []string{FieldNickname},
+			wantErr: "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, stored := newProfileRepo()
+			service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+
+			user, err := service.UpdateUser(context.Background(), tt.changes, tt.paths)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("UpdateUser() error = %v, want %q", err, tt.wantErr)
+				}
+				if stored.Version != 3 {
+					t.Errorf("UpdateUser() stored version = %d, want unchanged", stored.Version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			if user.Nickname != tt.wantNickname || user.Avatar != tt.wantAvatar || user.Version != 4 || user.UpdatedAt.IsZero() {
+				t.Errorf("UpdateUser() user = %+v", user)
+			}
+			if user.Email != "test@example.com" {
+				t.Errorf("UpdateUser() changed email to %q", user.Email)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// refreshTokenBytes is the amount of randomness in an opaque token.
+const refreshTokenBytes = 32
+
+// TokenPair is the result of a successful login or token refresh. When a
+// login needs a second factor, only MFAToken and MFAExpiresIn are set and
+// the tokens are obtained from VerifyMFA.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // lifetime of AccessToken
+	MFAToken     string
+	MFAExpiresIn time.Duration // lifetime of MFAToken
+	// MFAEnrollmentRequired is set when AccessToken only allows enrolling
+	// the second factor the account is required to have.
+	MFAEnrollmentRequired bool
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// token is revoked and replaced; presenting an already rotated token revokes
+// its whole family, logging out every session derived from the same login.
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.RefreshToken")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "success").Observe(duration)
+	}()
+
+	if refreshToken == "" {
+		s.logger.Warn(ctx).Msg("Empty refresh token provided")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty refresh token"))
+		return nil, MissingFields("refresh token is required", "refresh_token")
+	}
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Unknown refresh token")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidRefreshToken, "invalid refresh token")
+	}
+	span.SetAttributes(attribute.String("user_id", stored.UserID), attribute.String("family_id", stored.FamilyID))
+
+	if stored.Revoked() {
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		return nil, s.handleReuse(ctx, stored)
+	}
+	if stored.Expired(time.Now()) {
+		s.logger.Warn(ctx).Msg("Expired refresh token provided")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("expired refresh token"))
+		return nil, NewError(CodeUnauthenticated, ReasonRefreshTokenExpired, "refresh token expired")
+	}
+
+	// Claims are rebuilt so role changes and MFA enrollment apply on refresh
+	claims, err := s.accessTokenClaims(ctx, stored.UserID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to build access token claims")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to generate token")
+	}
+	accessToken, err := s.jwt.GenerateTokenFromClaims(claims)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate JWT token")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to generate token")
+	}
+	next, nextToken, err := s.newRefreshToken(stored.UserID, stored.FamilyID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate refresh token")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to generate token")
+	}
+
+	rotated, err := s.repo.RotateRefreshToken(ctx, stored.ID, next)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to rotate refresh token")
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to refresh token")
+	}
+	if !rotated {
+		// Another request rotated the same token first.
+		s.metrics.RequestDuration().WithLabelValues("RefreshToken", "error").Observe(time.Since(start).Seconds())
+		return nil, s.handleReuse(ctx, stored)
+	}
+
+	s.logger.Info(ctx).Msgf("Refresh token rotated for user: %s", stored.UserID)
+	return &TokenPair{
+		AccessToken:           accessToken,
+		RefreshToken:          nextToken,
+		ExpiresIn:             s.jwt.Duration(),
+		MFAEnrollmentRequired: claims.MFAEnrollmentRequired,
+	}, nil
+}
+
+// handleReuse revokes the family of a replayed refresh token.
+func (s *UserService) handleReuse(ctx context.Context, stored *model.RefreshToken) error {
+	s.logger.Warn(ctx).Msgf("Refresh token reuse detected for user %s, revoking family %s", stored.UserID, stored.FamilyID)
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke refresh token family")
+	}
+	return NewError(CodeUnauthenticated, ReasonRefreshTokenReused, "refresh token reused")
+}
+
+// issueTokens creates an access token carrying the user's roles and stores a
+// new refresh token in the given family.
+func (s *UserService) issueTokens(ctx context.Context, userID, familyID string) (*TokenPair, error) {
+	claims, err := s.accessTokenClaims(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.jwt.GenerateTokenFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	stored, refreshToken, err := s.newRefreshToken(userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		ExpiresIn:             s.jwt.Duration(),
+		MFAEnrollmentRequired: claims.MFAEnrollmentRequired,
+	}, nil
+}
+
+// accessTokenClaims builds the private claims of a user's access token: the
+// roles they hold, and whether they still have to enroll a second factor.
+func (s *UserService) accessTokenClaims(ctx context.Context, userID string) (jwt.Claims, error) {
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	claims := jwt.Claims{UserID: userID, Roles: roles}
+	if len(s.cfg.MFA.RequiredRoles) == 0 {
+		return claims, nil
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	if !s.mfaRequired(user) {
+		return claims, nil
+	}
+	settings, err := s.repo.GetMFASettings(ctx, userID)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	claims.MFAEnrollmentRequired = settings == nil || !settings.Enabled
+	return claims, nil
+}
+
+// newRefreshToken generates a random refresh token and the record to store
+// for it. The plaintext token is only returned to the caller.
+func (s *UserService) newRefreshToken(userID, familyID string) (*model.RefreshToken, string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	return &model.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.JWT.RefreshTokenDuration()),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// newOpaqueToken returns a random URL-safe token.
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of an opaque token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
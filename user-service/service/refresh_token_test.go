@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newRefreshTokenRepo returns a mock repository that keeps refresh tokens in a map.
+func newRefreshTokenRepo() (*MockUserRepository, map[string]*model.RefreshToken) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	tokens := map[string]*model.RefreshToken{}
+	revokeFamily := func(ctx context.Context, familyID string) error {
+		now := time.Now()
+		for _, tok := range tokens {
+			if tok.FamilyID == familyID && tok.RevokedAt == nil {
+				tok.RevokedAt = &now
+			}
+		}
+		return nil
+	}
+	return &MockUserRepository{
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			return &model.User{ID: "user123", Email: email, Password: string(hashedPassword)}, nil
+		},
+		GetUserRolesFunc: func(ctx context.Context, userID string) ([]string, error) {
+			return nil, nil
+		},
+		GetMFASettingsFunc: func(ctx context.Context, userID string) (*model.MFASettings, error) {
+			return nil, nil
+		},
+		RehashPasswordFunc: func(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+			return true, nil
+		},
+		CreateRefreshTokenFunc: func(ctx context.Context, token *model.RefreshToken) error {
+			tokens[token.TokenHash] = token
+			return nil
+		},
+		GetRefreshTokenByHashFunc: func(ctx context.Context, hash string) (*model.RefreshToken, error) {
+			if tok, ok := tokens[hash]; ok {
+				copied := *tok
+				return &copied, nil
+			}
+			return nil, errors.New("refresh token not found")
+		},
+		RotateRefreshTokenFunc: func(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error) {
+			for _, tok := range tokens {
+				if tok.ID == oldID {
+					if tok.RevokedAt != nil {
+						return false, nil
+					}
+					now := time.Now()
+					tok.RevokedAt = &now
+					tok.ReplacedBy = next.ID
+				}
+			}
+			tokens[next.TokenHash] = next
+			return true, nil
+		},
+		RevokeRefreshTokenFamilyFunc: revokeFamily,
+	}, tokens
+}
+
+func TestUserService_RefreshToken(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	mockRepo, _ := newRefreshTokenRepo()
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+	ctx := context.Background()
+
+	login, err := service.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if login.ExpiresIn != 15*time.Minute {
+		t.Errorf("Login() ExpiresIn = %v, want %v", login.ExpiresIn, 15*time.Minute)
+	}
+
+	rotated, err := service.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if rotated.AccessToken == "" || rotated.RefreshToken == "" || rotated.RefreshToken == login.RefreshToken {
+		t.Fatalf("RefreshToken() returned unexpected pair %+v", rotated)
+	}
+
+	// Replaying the first token must fail and revoke the whole family,
+	// including the token that replaced it.
+	if _, err := service.RefreshToken(ctx, login.RefreshToken); err == nil {
+		t.Fatalf("RefreshToken() with reused token expected error")
+	}
+	if _, err := service.RefreshToken(ctx, rotated.RefreshToken); err == nil {
+		t.Errorf("RefreshToken() after reuse detection expected family to be revoked")
+	}
+
+	if _, err := service.RefreshToken(ctx, "unknown"); err == nil {
+		t.Errorf("RefreshToken() with unknown token expected error")
+	}
+	if _, err := service.RefreshToken(ctx, ""); err == nil {
+		t.Errorf("RefreshToken() with empty token expected error")
+	}
+}
+
+func TestUserService_RefreshToken_Expired(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	mockRepo, _ := newRefreshTokenRepo()
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg))
+
+	login, err := service.Login(context.Background(), "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := service.RefreshToken(context.Background(), login.RefreshToken); err == nil {
+		t.Errorf("RefreshToken() with expired token expected error")
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GrantRole grants role to a user on behalf of actorID. The role is added
+// to the user's access tokens from their next login or token refresh.
+func (s *UserService) GrantRole(ctx context.Context, actorID, userID, role string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.GrantRole")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("GrantRole", "success").Observe(duration)
+	}()
+
+	if userID == "" || role == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID or role provided")
+		s.metrics.RequestDuration().WithLabelValues("GrantRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID or role"))
+		return MissingFields("user ID and role are required", "user_id", "role")
+	}
+	span.SetAttributes(attribute.String("user_id", userID), attribute.String("role", role), attribute.String("actor_id", actorID))
+
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Failed to get user by ID")
+		s.metrics.RequestDuration().WithLabelValues("GrantRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return repoError(err, ReasonUserNotFound)
+	}
+
+	granted, err := s.repo.GrantRole(ctx, userID, role, actorID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to grant role")
+		s.metrics.RequestDuration().WithLabelValues("GrantRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return repoError(err, ReasonRoleNotFound)
+	}
+	if !granted {
+		s.logger.Warn(ctx).Msgf("User %s already has role %s", userID, role)
+		s.metrics.RequestDuration().WithLabelValues("GrantRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("role already granted"))
+		return NewError(CodeAlreadyExists, ReasonRoleAlreadyGranted, "user already has this role")
+	}
+
+	s.logger.Info(ctx).Msgf("Role %s granted to user %s by %s", role, userID, actorID)
+	return nil
+}
+
+// RevokeRole removes role from a user on behalf of actorID. The user's
+// access tokens are revoked so the role stops working immediately; their
+// refresh tokens stay valid and yield tokens without the role.
+func (s *UserService) RevokeRole(ctx context.Context, actorID, userID, role string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.RevokeRole")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("RevokeRole", "success").Observe(duration)
+	}()
+
+	if userID == "" || role == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID or role provided")
+		s.metrics.RequestDuration().WithLabelValues("RevokeRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID or role"))
+		return MissingFields("user ID and role are required", "user_id", "role")
+	}
+	// Keeps the last admin from locking everyone out by accident.
+	if userID == actorID {
+		s.logger.Warn(ctx).Msg("Attempt to revoke own role")
+		s.metrics.RequestDuration().WithLabelValues("RevokeRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("self revocation"))
+		return NewError(CodeFailedPrecondition, ReasonSelfRevocation, "cannot revoke your own role")
+	}
+	span.SetAttributes(attribute.String("user_id", userID), attribute.String("role", role), attribute.String("actor_id", actorID))
+
+	revoked, err := s.repo.RevokeRole(ctx, userID, role)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke role")
+		s.metrics.RequestDuration().WithLabelValues("RevokeRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to revoke role")
+	}
+	if !revoked {
+		s.logger.Warn(ctx).Msgf("User %s does not have role %s", userID, role)
+		s.metrics.RequestDuration().WithLabelValues("RevokeRole", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("role not held"))
+		return NewError(CodeNotFound, ReasonRoleNotGranted, "user does not have this role")
+	}
+
+	// The role is already gone from the database, so failures are only logged.
+	if err := s.jwt.RevokeUserTokens(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke access tokens after role revocation")
+	}
+
+	s.logger.Info(ctx).Msgf("Role %s revoked from user %s by %s", role, userID, actorID)
+	return nil
+}
+
+// GetUserRoles returns the roles granted to a user.
+func (s *UserService) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUserRoles")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("GetUserRoles", "success").Observe(duration)
+	}()
+
+	if userID == "" {
+		s.logger.Warn(ctx).Msg("Empty user ID provided")
+		s.metrics.RequestDuration().WithLabelValues("GetUserRoles", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID"))
+		return nil, MissingFields("user ID is required", "user_id")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get user roles")
+		s.metrics.RequestDuration().WithLabelValues("GetUserRoles", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to get user roles")
+	}
+	return roles, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+)
+
+// newRolesRepo extends newRefreshTokenRepo with roles kept in a map.
+func newRolesRepo() *MockUserRepository {
+	repo, _ := newRefreshTokenRepo()
+	roles := map[string]bool{}
+	repo.GetUserByIDFunc = func(ctx context.Context, id string) (*model.User, error) {
+		if id == "user123" || id == "admin1" {
+			return &model.User{ID: id}, nil
+		}
+		return nil, errors.New("user not found")
+	}
+	repo.GetUserRolesFunc = func(ctx context.Context, userID string) ([]string, error) {
+		var held []string
+		for _, role := range []string{"admin", "moderator"} {
+			if roles[userID+"/"+role] {
+				held = append(held, role)
+			}
+		}
+		return held, nil
+	}
+	repo.GrantRoleFunc = func(ctx context.Context, userID, role, grantedBy string) (bool, error) {
+		if role != "admin" && role != "moderator" {
+			return false, errors.New("role not found")
+		}
+		if roles[userID+"/"+role] {
+			return false, nil
+		}
+		roles[userID+"/"+role] = true
+		return true, nil
+	}
+	repo.RevokeRoleFunc = func(ctx context.Context, userID, role string) (bool, error) {
+		if !roles[userID+"/"+role] {
+			return false, nil
+		}
+		delete(roles, userID+"/"+role)
+		return true, nil
+	}
+	return repo
+}
+
+func TestUserService_Roles(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	jwtUtil := jwt.NewJWTUtilFromConfig(cfg, jwt.WithDenylist(jwt.NewMemoryDenylist()))
+	service := NewUserService(newRolesRepo(), cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithJWTUtil(jwtUtil))
+	ctx := context.Background()
+
+	login, err := service.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, _ := jwtUtil.Verify(ctx, login.AccessToken)
+	if len(claims.Roles) != 0 {
+		t.Errorf("Login() roles = %v, want none", claims.Roles)
+	}
+
+	if err := service.GrantRole(ctx, "admin1", "user123", "admin"); err != nil {
+		t.Fatalf("GrantRole() error = %v", err)
+	}
+	if err := service.GrantRole(ctx, "admin1", "user123", "admin"); err == nil || err.Error() != "user already has this role" {
+		t.Errorf("GrantRole() twice error = %v", err)
+	}
+	if err := service.GrantRole(ctx, "admin1", "user123", "superuser"); err == nil || err.Error() != "role not found" {
+		t.Errorf("GrantRole() unknown role error = %v", err)
+	}
+	if err := service.GrantRole(ctx, "admin1", "ghost", "admin"); err == nil || err.Error() != "user not found" {
+		t.Errorf("GrantRole() unknown user error = %v", err)
+	}
+	if err := service.GrantRole(ctx, "admin1", "", "admin"); err == nil {
+		t.Errorf("GrantRole() without user ID expected error")
+	}
+
+	// The grant shows up in the next refreshed access token
+	refreshed, err := service.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	claims, _ = jwtUtil.Verify(ctx, refreshed.AccessToken)
+	if !claims.HasRole("admin") {
+		t.Errorf("RefreshToken() roles = %v, want admin", claims.Roles)
+	}
+	if roles, err := service.GetUserRoles(ctx, "user123"); err != nil || len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("GetUserRoles() = %v, %v, want [admin]", roles, err)
+	}
+
+	if err := service.RevokeRole(ctx, "user123", "user123", "admin"); err == nil || err.Error() != "cannot revoke your own role" {
+		t.Errorf("RevokeRole() of own role error = %v", err)
+	}
+	if err := service.RevokeRole(ctx, "admin1", "user123", "admin"); err != nil {
+		t.Fatalf("RevokeRole() error = %v", err)
+	}
+	if _, err := jwtUtil.Verify(ctx, refreshed.AccessToken); err == nil {
+		t.Errorf("Verify() of a token carrying a revoked role expected error")
+	}
+	if err := service.RevokeRole(ctx, "admin1", "user123", "admin"); err == nil || err.Error() != "user does not have this role" {
+		t.Errorf("RevokeRole() twice error = %v", err)
+	}
+
+	// Tokens issued within the same second as a revocation are revoked too;
+	// wait so the next refreshed token survives it.
+	time.Sleep(time.Second)
+	again, err := service.RefreshToken(ctx, refreshed.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() after revocation error = %v", err)
+	}
+	claims, err = jwtUtil.Verify(ctx, again.AccessToken)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.HasRole("admin") {
+		t.Errorf("RefreshToken() roles after revocation = %v, want none", claims.Roles)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Logout revokes the access token described by claims. If refreshToken is
+// set, the refresh token family it belongs to is revoked as well.
+func (s *UserService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.Logout")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("Logout", "success").Observe(duration)
+	}()
+
+	if claims == nil {
+		s.metrics.RequestDuration().WithLabelValues("Logout", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("missing claims"))
+		return NewError(CodeUnauthenticated, ReasonUnauthenticated, "unauthenticated")
+	}
+	span.SetAttributes(attribute.String("user_id", claims.UserID))
+
+	if err := s.jwt.RevokeToken(ctx, claims); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke access token")
+		s.metrics.RequestDuration().WithLabelValues("Logout", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to logout")
+	}
+
+	if refreshToken != "" {
+		stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+		// Never let a caller revoke another user's session.
+		if err == nil && stored.UserID == claims.UserID {
+			if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+				s.logger.Error(ctx).Err(err).Msg("Failed to revoke refresh token family")
+				s.metrics.RequestDuration().WithLabelValues("Logout", "error").Observe(time.Since(start).Seconds())
+				span.RecordError(err)
+				return internalError("failed to logout")
+			}
+		}
+	}
+
+	s.logger.Info(ctx).Msgf("User logged out: %s", claims.UserID)
+	return nil
+}
+
+// RevokeAllSessions revokes every access and refresh token issued to a user,
+// e.g. after a stolen-device report or a password change.
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID string) error {
+	ctx, span := s.tracer.Start(ctx, "UserService.RevokeAllSessions")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		s.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "success").Observe(duration)
+	}()
+
+	if userID == "" {
+		s.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("empty user ID"))
+		return MissingFields("user ID is required", "user_id")
+	}
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke refresh tokens")
+		s.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to revoke sessions")
+	}
+	if err := s.jwt.RevokeUserTokens(ctx, userID); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to revoke access tokens")
+		s.metrics.RequestDuration().WithLabelValues("RevokeAllSessions", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return internalError("failed to revoke sessions")
+	}
+
+	s.logger.Info(ctx).Msgf("Revoked all sessions for user: %s", userID)
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tao-Zzzz/GoCampus/user-service/config"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+)
+
+func TestUserService_Logout(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	jwtUtil := jwt.NewJWTUtilFromConfig(cfg, jwt.WithDenylist(jwt.NewMemoryDenylist()))
+	mockRepo, _ := newRefreshTokenRepo()
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithJWTUtil(jwtUtil))
+	ctx := context.Background()
+
+	login, err := service.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := jwtUtil.Verify(ctx, login.AccessToken)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if err := service.Logout(ctx, claims, login.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := jwtUtil.Verify(ctx, login.AccessToken); err == nil {
+		t.Errorf("Verify() after Logout expected error")
+	}
+	if _, err := service.RefreshToken(ctx, login.RefreshToken); err == nil {
+		t.Errorf("RefreshToken() after Logout expected error")
+	}
+	if err := service.Logout(ctx, nil, ""); err == nil {
+		t.Errorf("Logout() without claims expected error")
+	}
+}
+
+func TestUserService_RevokeAllSessions(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:             "secret-key",
+			AccessTokenMinutes: 15,
+			RefreshTokenHours:  24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+	}
+	jwtUtil := jwt.NewJWTUtilFromConfig(cfg, jwt.WithDenylist(jwt.NewMemoryDenylist()))
+	mockRepo, tokens := newRefreshTokenRepo()
+	mockRepo.RevokeUserRefreshTokensFunc = func(ctx context.Context, userID string) error {
+		for _, tok := range tokens {
+			if tok.UserID == userID {
+				return mockRepo.RevokeRefreshTokenFamilyFunc(ctx, tok.FamilyID)
+			}
+		}
+		return nil
+	}
+	service := NewUserService(mockRepo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithJWTUtil(jwtUtil))
+	ctx := context.Background()
+
+	login, err := service.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if err := service.RevokeAllSessions(ctx, "user123"); err != nil {
+		t.Fatalf("RevokeAllSessions() error = %v", err)
+	}
+	if _, err := jwtUtil.Verify(ctx, login.AccessToken); err == nil {
+		t.Errorf("Verify() after RevokeAllSessions expected error")
+	}
+	if _, err := service.RefreshToken(ctx, login.RefreshToken); err == nil {
+		t.Errorf("RefreshToken() after RevokeAllSessions expected error")
+	}
+	if err := service.RevokeAllSessions(ctx, ""); err == nil {
+		t.Errorf("RevokeAllSessions() with empty user ID expected error")
+	}
+}
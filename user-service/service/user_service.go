@@ -3,16 +3,24 @@ package service
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/model"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/campus"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/notify"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/passhash"
+	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/pwpolicy"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // UserRepository defines the interface for data access.
@@ -20,6 +28,38 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) (string, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) error
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	UpdatePassword(ctx context.Context, userID, passwordHash string) error
+	RehashPassword(ctx context.Context, userID, oldHash, newHash string) (bool, error)
+	CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, hash string) (*model.PasswordResetToken, error)
+	ResetPassword(ctx context.Context, tokenID, userID, passwordHash string) (bool, error)
+	CreateEmailVerificationToken(ctx context.Context, token *model.EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*model.EmailVerificationToken, error)
+	VerifyEmail(ctx context.Context, tokenID, userID string) (bool, error)
+	GetUserRoles(ctx context.Context, userID string) ([]string, error)
+	GrantRole(ctx context.Context, userID, role, grantedBy string) (bool, error)
+	RevokeRole(ctx context.Context, userID, role string) (bool, error)
+	GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error)
+	GetMFASettings(ctx context.Context, userID string) (*model.MFASettings, error)
+	SaveMFASecret(ctx context.Context, userID, secret string) (bool, error)
+	EnableMFA(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error)
+	DisableMFA(ctx context.Context, userID string) error
+	UpdateMFALastStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error
+	GetMFAChallengeByHash(ctx context.Context, hash string) (*model.MFAChallenge, error)
+	RecordMFAChallengeFailure(ctx context.Context, id string) error
+	RedeemMFAChallenge(ctx context.Context, id string) (bool, error)
+	GetLoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error)
+	BlockLogin(ctx context.Context, key string, until time.Time, locked bool) error
+	ClearLoginThrottle(ctx context.Context, key string) (bool, error)
 }
 
 // UserService implements user-related business logic.
@@ -28,20 +68,105 @@ type UserService struct {
 	cfg          *config.Config
 	logger       *logger.Logger
 	metrics      *metrics.Metrics
-	tracer       otel.Tracer
-	jwtKey       string
+	tracer       trace.Tracer
+	jwt          *jwt.JWTUtil
+	notifier     notify.Notifier
+	directory    *campus.Directory
+	passwords    *pwpolicy.Policy
+	hasher       *passhash.Hasher
+	runtimeCfg   atomic.Pointer[runtimeConfig]
+}
+
+// runtimeConfig holds the settings that ApplyConfig may change while the
+// service runs.
+type runtimeConfig struct {
+	lockout              config.LockoutConfig
+	requireVerifiedEmail bool
+}
+
+// Option configures optional UserService dependencies.
+type Option func(*UserService)
+
+// WithJWTUtil sets the JWTUtil used to issue and revoke tokens. Pass the same
+// instance as the auth interceptor so both share one denylist.
+func WithJWTUtil(j *jwt.JWTUtil) Option {
+	return func(s *UserService) { s.jwt = j }
+}
+
+// WithNotifier sets the Notifier used to deliver password reset and email
+// verification tokens.
+func WithNotifier(n notify.Notifier) Option {
+	return func(s *UserService) { s.notifier = n }
+}
+
+// WithDirectory sets the campus Directory that decides which email domains
+// may register and which affiliation they get.
+func WithDirectory(d *campus.Directory) Option {
+	return func(s *UserService) { s.directory = d }
+}
+
+// WithPasswordPolicy sets the Policy new passwords are checked against.
+func WithPasswordPolicy(p *pwpolicy.Policy) Option {
+	return func(s *UserService) { s.passwords = p }
+}
+
+// WithPasswordHasher sets the Hasher passwords are hashed and verified with.
+func WithPasswordHasher(h *passhash.Hasher) Option {
+	return func(s *UserService) { s.hasher = h }
 }
 
 // NewUserService creates a new UserService instance.
-func NewUserService(repo UserRepository, cfg *config.Config, log *logger.Logger, met *metrics.Metrics) *UserService {
-	return &UserService{
+func NewUserService(repo UserRepository, cfg *config.Config, log *logger.Logger, met *metrics.Metrics, opts ...Option) *UserService {
+	s := &UserService{
 		repo:    repo,
 		cfg:     cfg,
 		logger:  log,
 		metrics: met,
 		tracer:  otel.Tracer("user-service"),
-		jwtKey:  cfg.JWT.Secret,
 	}
+	s.ApplyConfig(cfg)
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.jwt == nil {
+		s.jwt = jwt.NewJWTUtilFromConfig(cfg, jwt.WithDenylist(jwt.NewMemoryDenylist()))
+	}
+	if s.notifier == nil {
+		s.notifier = notify.NewLogNotifier(log)
+	}
+	if s.directory == nil {
+		dir, err := campus.NewDirectory(cfg)
+		if err != nil {
+			log.Error(context.Background()).Err(err).Msg("Invalid campus configuration; affected domains cannot register")
+		}
+		s.directory = dir
+	}
+	if s.passwords == nil {
+		policy, err := pwpolicy.New(cfg.PasswordPolicy)
+		if err != nil {
+			log.Error(context.Background()).Err(err).Msg("Failed to load the breached password file; breached passwords are not checked")
+		}
+		s.passwords = policy
+	}
+	if s.hasher == nil {
+		hasher, err := passhash.New(cfg.PasswordHashing)
+		if err != nil {
+			log.Error(context.Background()).Err(err).Msg("Invalid password hashing configuration; hashing new passwords with bcrypt at the default cost, existing bcrypt and argon2id hashes still verify")
+		}
+		s.hasher = hasher
+	}
+	return s
+}
+
+// ApplyConfig applies the settings of cfg that can change at runtime: the
+// lockout section and email_verification.required_for_login. Requests in
+// progress keep the settings they started with. All other settings are
+// read once, by NewUserService.
+func (s *UserService) ApplyConfig(cfg *config.Config) {
+	s.runtimeCfg.Store(&runtimeConfig{
+		lockout:              cfg.Lockout,
+		requireVerifiedEmail: cfg.EmailVerification.RequiredForLogin,
+	})
 }
 
 // Register creates a new user with hashed password.
@@ -58,33 +183,83 @@ func (s *UserService) Register(ctx context.Context, user *model.User) (string, e
 	s.logger.Info(ctx).Msgf("Registering user with email: %s", user.Email)
 
 	// Validate input
-	if email == "" || password == "" || nickname == "" {
-		return "", errors.New("email, password, and nickname are required")
+	if user.Email == "" || user.Password == "" || user.Nickname == "" {
+		s.logger.Warn(ctx).Msg("Missing required registration fields")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("missing required fields"))
+		return "", missingRegistrationFields(user)
+	}
+	user.Email = strings.TrimSpace(user.Email)
+	user.Nickname = strings.TrimSpace(user.Nickname)
+	user.Avatar = strings.TrimSpace(user.Avatar)
+	if err := validateEmail(user.Email); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Invalid email")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return "", err
+	}
+	affiliation, ok := s.directory.Resolve(user.Email)
+	if !ok && s.directory.Restricted() {
+		s.logger.Warn(ctx).Msgf("Email domain not allowed for registration: %s", user.Email)
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("email domain not allowed"))
+		return "", invalidField(ReasonEmailDomainNotAllowed, "email", "email domain is not allowed")
+	}
+	if err := validateNickname(user.Nickname); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Invalid nickname")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return "", err
+	}
+	if err := validateAvatar(user.Avatar); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Invalid avatar")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return "", err
+	}
+	if err := s.passwords.Check(user.Password, pwpolicy.Subject{Email: user.Email, Nickname: user.Nickname}); err != nil {
+		s.logger.Warn(ctx).Err(err).Msg("Password rejected by policy")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return "", passwordPolicyError(err, "password")
 	}
 
 	// Check if user already exists
-	_, err := s.repo.GetUserByEmail(ctx, email)
+	_, err := s.repo.GetUserByEmail(ctx, user.Email)
 	if err == nil {
-		return "", errors.New("user already exists")
+		s.logger.Warn(ctx).Msg("User already exists")
+		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("user already exists"))
+		return "", NewError(CodeAlreadyExists, ReasonUserAlreadyExists, "user already exists")
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(user.Password)
 	if err != nil {
 		s.logger.Error(ctx).Err(err).Msg("Failed to hash password")
 		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(err)
-		return "", errors.New("failed to hash password")
+		return "", internalError("failed to hash password")
 	}
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
-	// Create user
+	// Create user; the email stays unverified until VerifyEmail
+	user.EmailVerified = false
+	user.InstitutionID = affiliation.InstitutionID
+	user.Role = affiliation.Role
 	userID, err := s.repo.CreateUser(ctx, user)
 	if err != nil {
 		s.logger.Error(ctx).Err(err).Msg("Failed to create user")
 		s.metrics.RequestDuration().WithLabelValues("Register", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(err)
-		return "", errors.New("failed to create user")
+		return "", internalError("failed to create user")
+	}
+
+	// The account exists at this point; a failed send can be retried with
+	// ResendVerificationEmail.
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to send verification email")
+		span.RecordError(err)
 	}
 
 	s.logger.Info(ctx).Msgf("User registered successfully: %s", userID)
@@ -92,8 +267,21 @@ func (s *UserService) Register(ctx context.Context, user *model.User) (string, e
 	return userID, nil
 }
 
-// Login authenticates a user and generates a JWT token.
-func (s *UserService) Login(ctx context.Context, email, password string) (string, error) {
+// missingRegistrationFields returns the error for a registration lacking
+// some of the required fields.
+func missingRegistrationFields(user *model.User) *Error {
+	var fields []string
+	for field, value := range map[string]string{"email": user.Email, "password": user.Password, "nickname": user.Nickname} {
+		if value == "" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return MissingFields("email, password, and nickname are required", fields...)
+}
+
+// Login authenticates a user and issues an access token and a refresh token.
+func (s *UserService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
 	ctx, span := s.tracer.Start(ctx, "UserService.Login")
 	defer span.End()
 
@@ -110,38 +298,96 @@ func (s *UserService) Login(ctx context.Context, email, password string) (string
 		s.logger.Warn(ctx).Msg("Empty email or password provided")
 		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(errors.New("empty email or password"))
-		return "", errors.New("email and password are required")
+		return nil, MissingFields("email and password are required", "email", "password")
+	}
+
+	// Refuse attempts while earlier failures hold off the account or client
+	rc := s.runtimeCfg.Load()
+	var throttles []throttleKey
+	if rc.lockout.Enabled {
+		throttles = s.loginThrottleKeys(ctx, &rc.lockout, email)
+		if err := s.checkLoginThrottles(ctx, throttles); err != nil {
+			var blocked *LoginBlockedError
+			if !errors.As(err, &blocked) {
+				s.logger.Error(ctx).Err(err).Msg("Failed to check login throttles")
+				s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
+				span.RecordError(err)
+				return nil, NewError(CodeUnavailable, ReasonLoginUnavailable, "login temporarily unavailable")
+			}
+			s.logger.Warn(ctx).Msgf("Login blocked for email: %s", email)
+			s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, loginBlockedError(blocked)
+		}
 	}
 
 	// Get user by email
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
+		s.recordLoginFailure(ctx, throttles)
 		s.logger.Error(ctx).Err(err).Msg("Failed to get user by email")
 		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(err)
-		return "", errors.New("invalid credentials")
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidCredentials, "invalid credentials")
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	if ok, err := s.hasher.Verify(password, user.Password); err != nil || !ok {
+		s.recordLoginFailure(ctx, throttles)
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msgf("Unreadable password hash for user: %s", user.ID)
+		}
 		s.logger.Warn(ctx).Msg("Invalid password provided")
 		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(errors.New("invalid password"))
-		return "", errors.New("invalid credentials")
+		return nil, NewError(CodeUnauthenticated, ReasonInvalidCredentials, "invalid credentials")
+	}
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user.ID, user.Password, password)
+	}
+
+	// Checked after the password so unverified accounts are not revealed
+	if rc.requireVerifiedEmail && !user.EmailVerified {
+		s.logger.Warn(ctx).Msgf("Login refused for unverified user: %s", user.ID)
+		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(errors.New("email not verified"))
+		return nil, NewError(CodeFailedPrecondition, ReasonEmailNotVerified, "email address is not verified")
+	}
+
+	// Accounts with a second factor finish logging in through VerifyMFA
+	settings, err := s.repo.GetMFASettings(ctx, user.ID)
+	if err != nil {
+		s.logger.Error(ctx).Err(err).Msg("Failed to get MFA settings")
+		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
+		span.RecordError(err)
+		return nil, internalError("failed to generate token")
+	}
+	if settings != nil && settings.Enabled {
+		challenge, err := s.newMFAChallenge(ctx, user.ID)
+		if err != nil {
+			s.logger.Error(ctx).Err(err).Msg("Failed to create MFA challenge")
+			s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
+			span.RecordError(err)
+			return nil, internalError("failed to generate token")
+		}
+		s.logger.Info(ctx).Msgf("Second factor required for user: %s", user.ID)
+		span.SetAttributes(attribute.String("user_id", user.ID))
+		return challenge, nil
 	}
 
-	// Generate JWT token
-	token, err := jwt.GenerateToken(user.ID, s.jwtKey, time.Duration(s.cfg.JWT.DurationHours)*time.Hour)
+	// Generate tokens; every login starts a new refresh token family
+	tokens, err := s.issueTokens(ctx, user.ID, uuid.New().String())
 	if err != nil {
-		s.logger.Error(ctx).Err(err).Msg("Failed to generate JWT token")
+		s.logger.Error(ctx).Err(err).Msg("Failed to generate tokens")
 		s.metrics.RequestDuration().WithLabelValues("Login", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(err)
-		return "", errors.New("failed to generate token")
+		return nil, internalError("failed to generate token")
 	}
+	s.clearAccountFailures(ctx, throttles)
 
 	s.logger.Info(ctx).Msgf("User logged in successfully: %s", user.ID)
 	span.SetAttributes(attribute.String("user_id", user.ID))
-	return token, nil
+	return tokens, nil
 }
 
 // GetUserInfo retrieves user information by ID.
@@ -162,7 +408,7 @@ func (s *UserService) GetUserInfo(ctx context.Context, userID string) (*model.Us
 		s.logger.Warn(ctx).Msg("Empty user ID provided")
 		s.metrics.RequestDuration().WithLabelValues("GetUserInfo", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(errors.New("empty user ID"))
-		return nil, errors.New("user ID is required")
+		return nil, MissingFields("user ID is required", "user_id")
 	}
 
 	// Get user by ID
@@ -171,7 +417,7 @@ func (s *UserService) GetUserInfo(ctx context.Context, userID string) (*model.Us
 		s.logger.Error(ctx).Err(err).Msg("Failed to get user by ID")
 		s.metrics.RequestDuration().WithLabelValues("GetUserInfo", "error").Observe(time.Since(start).Seconds())
 		span.RecordError(err)
-		return nil, errors.New("failed to get user")
+		return nil, repoError(err, ReasonUserNotFound)
 	}
 
 	s.logger.Info(ctx).Msgf("User info retrieved successfully: %s", userID)
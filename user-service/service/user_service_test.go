@@ -8,7 +8,6 @@ import (
 
 	"github.com/Tao-Zzzz/GoCampus/user-service/config"
 	"github.com/Tao-Zzzz/GoCampus/user-service/model"
-	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/jwt"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/logger"
 	"github.com/Tao-Zzzz/GoCampus/user-service/pkg/metrics"
 	"github.com/google/uuid"
@@ -16,9 +15,41 @@ import (
 )
 
 type MockUserRepository struct {
-	CreateUserFunc    func(ctx context.Context, user *model.User) (string, error)
-	GetUserByEmailFunc func(ctx context.Context, email string) (*model.User, error)
-	GetUserByIDFunc   func(ctx context.Context, id string) (*model.User, error)
+	CreateUserFunc                      func(ctx context.Context, user *model.User) (string, error)
+	GetUserByEmailFunc                  func(ctx context.Context, email string) (*model.User, error)
+	GetUserByIDFunc                     func(ctx context.Context, id string) (*model.User, error)
+	UpdateUserFunc                      func(ctx context.Context, user *model.User) error
+	CreateRefreshTokenFunc              func(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHashFunc           func(ctx context.Context, hash string) (*model.RefreshToken, error)
+	RotateRefreshTokenFunc              func(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamilyFunc        func(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokensFunc         func(ctx context.Context, userID string) error
+	UpdatePasswordFunc                  func(ctx context.Context, userID, passwordHash string) error
+	RehashPasswordFunc                  func(ctx context.Context, userID, oldHash, newHash string) (bool, error)
+	CreatePasswordResetTokenFunc        func(ctx context.Context, token *model.PasswordResetToken) error
+	GetPasswordResetTokenByHashFunc     func(ctx context.Context, hash string) (*model.PasswordResetToken, error)
+	ResetPasswordFunc                   func(ctx context.Context, tokenID, userID, passwordHash string) (bool, error)
+	CreateEmailVerificationTokenFunc    func(ctx context.Context, token *model.EmailVerificationToken) error
+	GetEmailVerificationTokenByHashFunc func(ctx context.Context, hash string) (*model.EmailVerificationToken, error)
+	VerifyEmailFunc                     func(ctx context.Context, tokenID, userID string) (bool, error)
+	GetUserRolesFunc                    func(ctx context.Context, userID string) ([]string, error)
+	GrantRoleFunc                       func(ctx context.Context, userID, role, grantedBy string) (bool, error)
+	RevokeRoleFunc                      func(ctx context.Context, userID, role string) (bool, error)
+	GetPermissionsForRolesFunc          func(ctx context.Context, roles []string) ([]string, error)
+	GetMFASettingsFunc                  func(ctx context.Context, userID string) (*model.MFASettings, error)
+	SaveMFASecretFunc                   func(ctx context.Context, userID, secret string) (bool, error)
+	EnableMFAFunc                       func(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error)
+	DisableMFAFunc                      func(ctx context.Context, userID string) error
+	UpdateMFALastStepFunc               func(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCodeFunc                 func(ctx context.Context, userID, codeHash string) (bool, error)
+	CreateMFAChallengeFunc              func(ctx context.Context, challenge *model.MFAChallenge) error
+	GetMFAChallengeByHashFunc           func(ctx context.Context, hash string) (*model.MFAChallenge, error)
+	RecordMFAChallengeFailureFunc       func(ctx context.Context, id string) error
+	RedeemMFAChallengeFunc              func(ctx context.Context, id string) (bool, error)
+	GetLoginThrottleFunc                func(ctx context.Context, key string) (*model.LoginThrottle, error)
+	RecordLoginFailureFunc              func(ctx context.Context, key string, now, windowStart time.Time) (int, error)
+	BlockLoginFunc                      func(ctx context.Context, key string, until time.Time, locked bool) error
+	ClearLoginThrottleFunc              func(ctx context.Context, key string) (bool, error)
 }
 
 func (m *MockUserRepository) CreateUser(ctx context.Context, user *model.User) (string, error) {
@@ -33,8 +64,135 @@ func (m *MockUserRepository) GetUserByID(ctx context.Context, id string) (*model
 	return m.GetUserByIDFunc(ctx, id)
 }
 
+func (m *MockUserRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	return m.UpdateUserFunc(ctx, user)
+}
+
+func (m *MockUserRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return m.CreateRefreshTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	return m.GetRefreshTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) RotateRefreshToken(ctx context.Context, oldID string, next *model.RefreshToken) (bool, error) {
+	return m.RotateRefreshTokenFunc(ctx, oldID, next)
+}
+
+func (m *MockUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return m.RevokeRefreshTokenFamilyFunc(ctx, familyID)
+}
+
+func (m *MockUserRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	return m.RevokeUserRefreshTokensFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	return m.UpdatePasswordFunc(ctx, userID, passwordHash)
+}
+
+func (m *MockUserRepository) RehashPassword(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+	return m.RehashPasswordFunc(ctx, userID, oldHash, newHash)
+}
+
+func (m *MockUserRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	return m.CreatePasswordResetTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+	return m.GetPasswordResetTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) ResetPassword(ctx context.Context, tokenID, userID, passwordHash string) (bool, error) {
+	return m.ResetPasswordFunc(ctx, tokenID, userID, passwordHash)
+}
+
+func (m *MockUserRepository) CreateEmailVerificationToken(ctx context.Context, token *model.EmailVerificationToken) error {
+	return m.CreateEmailVerificationTokenFunc(ctx, token)
+}
+
+func (m *MockUserRepository) GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*model.EmailVerificationToken, error) {
+	return m.GetEmailVerificationTokenByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, tokenID, userID string) (bool, error) {
+	return m.VerifyEmailFunc(ctx, tokenID, userID)
+}
+
+func (m *MockUserRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	return m.GetUserRolesFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) GrantRole(ctx context.Context, userID, role, grantedBy string) (bool, error) {
+	return m.GrantRoleFunc(ctx, userID, role, grantedBy)
+}
+
+func (m *MockUserRepository) RevokeRole(ctx context.Context, userID, role string) (bool, error) {
+	return m.RevokeRoleFunc(ctx, userID, role)
+}
+
+func (m *MockUserRepository) GetPermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	return m.GetPermissionsForRolesFunc(ctx, roles)
+}
+
+func (m *MockUserRepository) GetMFASettings(ctx context.Context, userID string) (*model.MFASettings, error) {
+	return m.GetMFASettingsFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) SaveMFASecret(ctx context.Context, userID, secret string) (bool, error) {
+	return m.SaveMFASecretFunc(ctx, userID, secret)
+}
+
+func (m *MockUserRepository) EnableMFA(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error) {
+	return m.EnableMFAFunc(ctx, userID, step, recoveryCodeHashes)
+}
+
+func (m *MockUserRepository) DisableMFA(ctx context.Context, userID string) error {
+	return m.DisableMFAFunc(ctx, userID)
+}
+
+func (m *MockUserRepository) UpdateMFALastStep(ctx context.Context, userID string, step int64) (bool, error) {
+	return m.UpdateMFALastStepFunc(ctx, userID, step)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	return m.UseRecoveryCodeFunc(ctx, userID, codeHash)
+}
+
+func (m *MockUserRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	return m.CreateMFAChallengeFunc(ctx, challenge)
+}
+
+func (m *MockUserRepository) GetMFAChallengeByHash(ctx context.Context, hash string) (*model.MFAChallenge, error) {
+	return m.GetMFAChallengeByHashFunc(ctx, hash)
+}
+
+func (m *MockUserRepository) RecordMFAChallengeFailure(ctx context.Context, id string) error {
+	return m.RecordMFAChallengeFailureFunc(ctx, id)
+}
+
+func (m *MockUserRepository) RedeemMFAChallenge(ctx context.Context, id string) (bool, error) {
+	return m.RedeemMFAChallengeFunc(ctx, id)
+}
+
+func (m *MockUserRepository) GetLoginThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	return m.GetLoginThrottleFunc(ctx, key)
+}
+
+func (m *MockUserRepository) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	return m.RecordLoginFailureFunc(ctx, key, now, windowStart)
+}
+
+func (m *MockUserRepository) BlockLogin(ctx context.Context, key string, until time.Time, locked bool) error {
+	return m.BlockLoginFunc(ctx, key, until, locked)
+}
+
+func (m *MockUserRepository) ClearLoginThrottle(ctx context.Context, key string) (bool, error) {
+	return m.ClearLoginThrottleFunc(ctx, key)
+}
+
 func TestUserService_Register(t *testing.T) {
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	mockRepo := &MockUserRepository{
 		CreateUserFunc: func(ctx context.Context, user *model.User) (string, error) {
 			if user.Email == "test@example.com" {
@@ -48,6 +206,9 @@ func TestUserService_Register(t *testing.T) {
 			}
 			return nil, errors.New("user not found")
 		},
+		CreateEmailVerificationTokenFunc: func(ctx context.Context, token *model.EmailVerificationToken) error {
+			return nil
+		},
 	}
 	cfg := &config.Config{
 		JWT: config.JWTConfig{
@@ -64,7 +225,6 @@ func TestUserService_Register(t *testing.T) {
 	met := metrics.NewMetrics(cfg)
 	service := NewUserService(mockRepo, cfg, log, met)
 
-
 	tests := []struct {
 		name       string
 		email      string
@@ -103,7 +263,14 @@ func TestUserService_Register(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			userID, err := service.Register(context.Background(), tt.email, tt.password, tt.nickname, tt.avatar)
+			user := &model.User{
+				ID:       uuid.New().String(),
+				Email:    tt.email,
+				Password: tt.password,
+				Nickname: tt.nickname,
+				Avatar:   tt.avatar,
+			}
+			userID, err := service.Register(context.Background(), user)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Register() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -117,6 +284,18 @@ func TestUserService_Register(t *testing.T) {
 func TestUserService_Login(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	mockRepo := &MockUserRepository{
+		GetUserRolesFunc: func(ctx context.Context, userID string) ([]string, error) {
+			return nil, nil
+		},
+		GetMFASettingsFunc: func(ctx context.Context, userID string) (*model.MFASettings, error) {
+			return nil, nil
+		},
+		RehashPasswordFunc: func(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+			return true, nil
+		},
+		CreateRefreshTokenFunc: func(ctx context.Context, token *model.RefreshToken) error {
+			return nil
+		},
 		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
 			if email == "test@example.com" {
 				return &model.User{
@@ -174,12 +353,12 @@ func TestUserService_Login(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := service.Login(context.Background(), tt.email, tt.password)
+			tokens, err := service.Login(context.Background(), tt.email, tt.password)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			if !tt.wantErr && token == "" {
-				t.Errorf("Login() expected non-empty token")
+			if !tt.wantErr && (tokens.AccessToken == "" || tokens.RefreshToken == "") {
+				t.Errorf("Login() expected non-empty tokens")
 			}
 		})
 	}
@@ -222,14 +401,14 @@ func TestUserService_GetUserInfo(t *testing.T) {
 		wantUser *model.User
 	}{
 		{
-			name:   "Successful get user info",
-			userID: "user123",
+			name:    "Successful get user info",
+			userID:  "user123",
 			wantErr: false,
 			wantUser: &model.User{
-				ID:        "user123",
-				Email:     "test@example.com",
-				Nickname:  "TestUser",
-				Avatar:    "http://example.com/avatar.png",
+				ID:       "user123",
+				Email:    "test@example.com",
+				Nickname: "TestUser",
+				Avatar:   "http://example.com/avatar.png",
 			},
 		},
 		{
@@ -254,4 +433,60 @@ func TestUserService_GetUserInfo(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestUserService_Register_Campus(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "secret-key",
+			DurationHours: 24,
+		},
+		Service: config.ServiceConfig{
+			Name:     "test-service",
+			Port:     8080,
+			LogLevel: "debug",
+		},
+		Campus: config.CampusConfig{
+			Institutions: []config.InstitutionConfig{{
+				ID:   "example",
+				Name: "Example University",
+				Domains: []config.DomainConfig{
+					{Domain: "example.edu", Role: model.RoleStaff},
+					{Domain: "stu.example.edu", Role: model.RoleStudent},
+				},
+			}},
+		},
+	}
+	repo := newVerificationRepo()
+	service := NewUserService(repo, cfg, logger.NewLogger(cfg), metrics.NewMetrics(cfg), WithNotifier(&captureNotifier{}))
+
+	tests := []struct {
+		name            string
+		email           string
+		wantErr         string
+		wantInstitution string
+		wantRole        string
+	}{
+		{name: "Student domain", email: "alice@stu.example.edu", wantInstitution: "example", wantRole: model.RoleStudent},
+		{name: "Staff subdomain", email: "bob@cs.example.edu", wantInstitution: "example", wantRole: model.RoleStaff},
+		{name: "Other domain", email: "mallory@gmail.com", wantErr: "email domain is not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Register(context.Background(), &model.User{ID: uuid.New().String(), Email: tt.email, Password: "password123", Nickname: "User"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Register() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			stored, _ := repo.GetUserByEmail(context.Background(), tt.email)
+			if stored.InstitutionID != tt.wantInstitution || stored.Role != tt.wantRole {
+				t.Errorf("stored affiliation = %q/%q, want %q/%q", stored.InstitutionID, stored.Role, tt.wantInstitution, tt.wantRole)
+			}
+		})
+	}
+}